@@ -0,0 +1,453 @@
+package boopy
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+var (
+	ERR_MERKLE_REQUEST  = errors.New("merkle request out of range")
+	ERR_MERKLE_RESPONSE = errors.New("merkle response does not match request")
+)
+
+const (
+	// merkleDepth gives trees 2^merkleDepth leaves per range.
+	merkleDepth    = 8
+	maxMerkleDepth = 16
+	// merkleCacheDuration is how long a replica keeps a tree it built for
+	// an owner walking down it.
+	merkleCacheDuration = time.Minute
+)
+
+// merkleTree hashes the keys of a ring range. The range is split evenly
+// into leaves, leaf i covering (bounds[i], bounds[i+1]]. levels[d] holds
+// the 2^d hashes at depth d; subtrees without keys hash to nothing.
+type merkleTree struct {
+	bounds [][]byte
+	leaves [][]*api.KV
+	levels [][][]byte
+	built  time.Time
+}
+
+// splitRange returns parts+1 ring positions splitting (from, to] evenly.
+// Equal from and to stand for the whole ring.
+func splitRange(from, to []byte, parts, size int) ([][]byte, []*big.Int) {
+	ring := new(big.Int).Lsh(big.NewInt(1), uint(size*8))
+	start := new(big.Int).SetBytes(from)
+	span := new(big.Int).Sub(new(big.Int).SetBytes(to), start)
+	span.Mod(span, ring)
+	if span.Sign() == 0 {
+		span.Set(ring)
+	}
+
+	bounds := make([][]byte, parts+1)
+	offsets := make([]*big.Int, parts+1)
+	for i := range bounds {
+		offsets[i] = new(big.Int).Mul(span, big.NewInt(int64(i)))
+		offsets[i].Div(offsets[i], big.NewInt(int64(parts)))
+		pos := new(big.Int).Add(start, offsets[i])
+		bounds[i] = make([]byte, size)
+		sum := pos.Mod(pos, ring).Bytes()
+		copy(bounds[i][size-len(sum):], sum)
+	}
+	return bounds, offsets
+}
+
+// buildMerkleTree hashes the keys of namespaces found in (from, to].
+func (n *Node) buildMerkleTree(from, to []byte, namespaces []string, depth int, keys []*api.KV) *merkleTree {
+	size := n.cnf.HashSize / 8
+	parts := 1 << uint(depth)
+	bounds, offsets := splitRange(from, to, parts, size)
+	tree := &merkleTree{
+		bounds: bounds,
+		leaves: make([][]*api.KV, parts),
+		levels: make([][][]byte, depth+1),
+		built:  time.Now(),
+	}
+
+	wanted := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		wanted[ns] = true
+	}
+	ring := new(big.Int).Lsh(big.NewInt(1), uint(size*8))
+	start := new(big.Int).SetBytes(from)
+	for _, kv := range keys {
+		if !wanted[kv.Namespace] {
+			continue
+		}
+		id, err := n.hashKey(namespacedKey(kv.Namespace, kv.Key))
+		if err != nil {
+			continue
+		}
+		offset := new(big.Int).Sub(new(big.Int).SetBytes(id), start)
+		offset.Mod(offset, ring)
+		if offset.Sign() == 0 {
+			offset.Set(ring)
+		}
+		leaf := sort.Search(parts, func(i int) bool {
+			return offset.Cmp(offsets[i+1]) <= 0
+		})
+		if leaf < parts {
+			tree.leaves[leaf] = append(tree.leaves[leaf], kv)
+		}
+	}
+
+	tree.levels[depth] = make([][]byte, parts)
+	for i, leaf := range tree.leaves {
+		tree.levels[depth][i] = hashLeaf(leaf)
+	}
+	for d := depth - 1; d >= 0; d-- {
+		below := tree.levels[d+1]
+		tree.levels[d] = make([][]byte, len(below)/2)
+		for i := range tree.levels[d] {
+			left, right := below[2*i], below[2*i+1]
+			if len(left) == 0 && len(right) == 0 {
+				continue
+			}
+			h := sha1.New()
+			h.Write(left)
+			h.Write(right)
+			tree.levels[d][i] = h.Sum(nil)
+		}
+	}
+	return tree
+}
+
+// hashLeaf hashes pairs independently of the order they were read in.
+func hashLeaf(kvs []*api.KV) []byte {
+	if len(kvs) == 0 {
+		return nil
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		if kvs[i].Namespace != kvs[j].Namespace {
+			return kvs[i].Namespace < kvs[j].Namespace
+		}
+		return kvs[i].Key < kvs[j].Key
+	})
+	h := sha1.New()
+	var num [8]byte
+	for _, kv := range kvs {
+		for _, field := range []string{kv.Namespace, kv.Key, kv.Value} {
+			h.Write([]byte(field))
+			h.Write([]byte{0})
+		}
+		for _, field := range []int64{kv.Expires, kv.Version, int64(kv.Flags)} {
+			binary.BigEndian.PutUint64(num[:], uint64(field))
+			h.Write(num[:])
+		}
+		if kv.Deleted {
+			h.Write([]byte{1})
+		} else {
+			h.Write([]byte{0})
+		}
+	}
+	return h.Sum(nil)
+}
+
+// merkleCache keeps the trees a replica built recently, so an owner walking
+// down one level at a time compares against a single snapshot.
+type merkleCache struct {
+	mtx   sync.Mutex
+	trees map[string]*merkleTree
+}
+
+func newMerkleCache() *merkleCache {
+	return &merkleCache{trees: make(map[string]*merkleTree)}
+}
+
+func merkleCacheKey(req *api.MerkleRequest) string {
+	return strings.Join([]string{
+		hex.EncodeToString(req.From),
+		hex.EncodeToString(req.To),
+		strconv.Itoa(int(req.Depth)),
+		strings.Join(req.Namespaces, "/"),
+	}, ":")
+}
+
+func (c *merkleCache) get(key string) *merkleTree {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	tree, ok := c.trees[key]
+	if !ok || time.Since(tree.built) > merkleCacheDuration {
+		return nil
+	}
+	return tree
+}
+
+func (c *merkleCache) put(key string, tree *merkleTree) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for k, old := range c.trees {
+		if time.Since(old.built) > merkleCacheDuration {
+			delete(c.trees, k)
+		}
+	}
+	c.trees[key] = tree
+}
+
+// rateLimiter hands out up to rate tokens a second, allowing bursts of one
+// second's worth. A rate of 0 or less is unlimited.
+type rateLimiter struct {
+	mtx    sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{rate: float64(rate), tokens: float64(rate), last: time.Now()}
+}
+
+// take returns how many of want tokens are available now, at most want.
+func (r *rateLimiter) take(want int) int {
+	if r.rate <= 0 {
+		return want
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	now := time.Now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.rate {
+		r.tokens = r.rate
+	}
+	r.last = now
+
+	got := want
+	if float64(got) > r.tokens {
+		got = int(r.tokens)
+	}
+	r.tokens -= float64(got)
+	return got
+}
+
+// antiEntropy compares the keys we own with our replicas and repairs the
+// ranges that differ. Keys missing on either side are copied over and the
+// newer version wins where both have one, ours on a tie. Deletes are
+// compared as tombstones and repair like writes until TombstoneGracePeriod
+// purges them; a replica that missed a delete for longer copies the key
+// back.
+func (n *Node) antiEntropy() {
+	n.predMtx.RLock()
+	pred := n.predecessor
+	n.predMtx.RUnlock()
+	if pred == nil {
+		return
+	}
+
+	n.stMtx.RLock()
+	keys, err := n.storage.Between(pred.Id, n.Id)
+	n.stMtx.RUnlock()
+	if err != nil {
+		return
+	}
+
+	factors := make(map[string]uint32)
+	var most uint32
+	for _, kv := range keys {
+		if _, ok := factors[kv.Namespace]; ok {
+			continue
+		}
+		settings, err := n.namespaceSettings(kv.Namespace)
+		if err != nil {
+			continue
+		}
+		factors[kv.Namespace] = settings.ReplicationFactor
+		if settings.ReplicationFactor > most {
+			most = settings.ReplicationFactor
+		}
+	}
+	if most <= 1 {
+		return
+	}
+	n.metrics.inc("anti_entropy_rounds")
+
+	for i, replica := range n.replicas(&api.Namespace{ReplicationFactor: most}) {
+		var namespaces []string
+		for ns, factor := range factors {
+			if factor > uint32(i+1) {
+				namespaces = append(namespaces, ns)
+			}
+		}
+		sort.Strings(namespaces)
+		if err := n.repairReplica(replica, pred.Id, namespaces, keys); err != nil {
+			log.Println("error comparing with replica: ", replica.Addr, err)
+			n.metrics.inc("anti_entropy_failures")
+		}
+	}
+}
+
+// repairReplica walks down the Merkle trees of (from, n.Id] on both sides,
+// only asking for the children of subtrees that differ, and reconciles the
+// keys of the leaves that still differ.
+func (n *Node) repairReplica(replica *api.Node, from []byte, namespaces []string, keys []*api.KV) error {
+	local := n.buildMerkleTree(from, n.Id, namespaces, merkleDepth, keys)
+	indices := []uint32{0}
+	for level := 0; ; level++ {
+		res, err := n.merkleRPC(replica, &api.MerkleRequest{
+			From:       from,
+			To:         n.Id,
+			Namespaces: namespaces,
+			Depth:      merkleDepth,
+			Level:      uint32(level),
+			Indices:    indices,
+		})
+		if err != nil {
+			return err
+		}
+		if len(res.Hashes) != len(indices) {
+			return ERR_MERKLE_RESPONSE
+		}
+		n.metrics.add("anti_entropy_hashes_compared", uint64(len(indices)))
+
+		differ := make([]uint32, 0, len(indices))
+		for i, idx := range indices {
+			if !bytes.Equal(local.levels[level][idx], res.Hashes[i]) {
+				differ = append(differ, idx)
+			}
+		}
+		if len(differ) == 0 {
+			return nil
+		}
+		if level == merkleDepth {
+			indices = differ
+			break
+		}
+		indices = make([]uint32, 0, 2*len(differ))
+		for _, idx := range differ {
+			indices = append(indices, 2*idx, 2*idx+1)
+		}
+	}
+
+	wanted := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		wanted[ns] = true
+	}
+	for _, leaf := range indices {
+		remote, err := n.requestKeysRPC(replica, local.bounds[leaf], local.bounds[leaf+1])
+		if err != nil {
+			return err
+		}
+		theirs := remote[:0]
+		for _, kv := range remote {
+			if wanted[kv.Namespace] {
+				theirs = append(theirs, kv)
+			}
+		}
+		if err := n.reconcile(replica, local.leaves[leaf], theirs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcile copies the pairs that differ between ours and theirs, within
+// the repair rate limit.
+func (n *Node) reconcile(replica *api.Node, ours, theirs []*api.KV) error {
+	index := func(kv *api.KV) string {
+		return kv.Namespace + "\x00" + kv.Key
+	}
+	remote := make(map[string]*api.KV, len(theirs))
+	for _, kv := range theirs {
+		remote[index(kv)] = kv
+	}
+	local := make(map[string]bool, len(ours))
+	var push, pull []*api.KV
+	for _, kv := range ours {
+		local[index(kv)] = true
+		other, ok := remote[index(kv)]
+		switch {
+		case !ok || other.Version < kv.Version:
+			push = append(push, kv)
+		case other.Version > kv.Version:
+			pull = append(pull, other)
+		case other.Value != kv.Value || other.Expires != kv.Expires || other.Deleted != kv.Deleted || other.Flags != kv.Flags:
+			push = append(push, kv)
+			if kv.Type != api.KV_STRING && other.Type == kv.Type {
+				pull = append(pull, other) // counters and sets merge both ways
+			}
+		}
+	}
+	for _, kv := range theirs {
+		if !local[index(kv)] {
+			pull = append(pull, kv)
+		}
+	}
+
+	push = push[:n.repairBudget(len(push))]
+	if len(push) > 0 {
+		if err := n.storeKeysRPC(replica, push); err != nil {
+			return err
+		}
+		n.metrics.add("anti_entropy_keys_pushed", uint64(len(push)))
+	}
+	pull = pull[:n.repairBudget(len(pull))]
+	if len(pull) > 0 {
+		if _, err := n.XStoreKeys(context.Background(), &api.StoreKeysRequest{Values: pull}); err != nil {
+			return err
+		}
+		n.metrics.add("anti_entropy_keys_pulled", uint64(len(pull)))
+	}
+	return nil
+}
+
+// repairBudget returns how many of want repairs may go ahead now.
+func (n *Node) repairBudget(want int) int {
+	got := n.repairs.take(want)
+	if got < want {
+		n.metrics.add("anti_entropy_throttled", uint64(want-got))
+	}
+	return got
+}
+
+func (n *Node) merkleRPC(node *api.Node, req *api.MerkleRequest) (*api.MerkleResponse, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.XMerkle(context.Background(), req)
+	}
+	return n.transport.Merkle(node, req)
+}
+
+func (n *Node) XMerkle(ctx context.Context, req *api.MerkleRequest) (*api.MerkleResponse, error) {
+	if req.Depth > maxMerkleDepth || req.Level > req.Depth {
+		return nil, ERR_MERKLE_REQUEST
+	}
+
+	// Walks start at the root, so that is when a fresh snapshot is taken.
+	key := merkleCacheKey(req)
+	var tree *merkleTree
+	if req.Level > 0 {
+		tree = n.merkles.get(key)
+	}
+	if tree == nil {
+		n.stMtx.RLock()
+		keys, err := n.storage.Between(req.From, req.To)
+		n.stMtx.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		tree = n.buildMerkleTree(req.From, req.To, req.Namespaces, int(req.Depth), keys)
+		n.merkles.put(key, tree)
+	}
+
+	level := tree.levels[req.Level]
+	res := &api.MerkleResponse{Hashes: make([][]byte, len(req.Indices))}
+	for i, idx := range req.Indices {
+		if int(idx) >= len(level) {
+			return nil, ERR_MERKLE_REQUEST
+		}
+		res.Hashes[i] = level[idx]
+	}
+	return res, nil
+}
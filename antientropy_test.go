@@ -0,0 +1,146 @@
+package boopy
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+)
+
+func Test_splitRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to []byte
+		parts    int
+		want     [][]byte
+	}{
+		{"whole ring", []byte{0x00}, []byte{0x00}, 4, [][]byte{{0x00}, {0x40}, {0x80}, {0xc0}, {0x00}}},
+		{"wraps", []byte{0xf0}, []byte{0x10}, 2, [][]byte{{0xf0}, {0x00}, {0x10}}},
+		{"uneven", []byte{0x00}, []byte{0x05}, 2, [][]byte{{0x00}, {0x02}, {0x05}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := splitRange(tt.from, tt.to, tt.parts, 1)
+			if len(got) != len(tt.want) {
+				t.Fatalf("splitRange() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], tt.want[i]) {
+					t.Errorf("splitRange()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_rateLimiter(t *testing.T) {
+	limiter := newRateLimiter(5)
+	if got := limiter.take(3); got != 3 {
+		t.Errorf("take(3) = %d, want 3", got)
+	}
+	if got := limiter.take(3); got != 2 {
+		t.Errorf("take(3) = %d, want 2", got)
+	}
+	if got := newRateLimiter(0).take(100); got != 100 {
+		t.Errorf("unlimited take(100) = %d, want 100", got)
+	}
+}
+
+// setupReplicated stores count keys in a namespace replicated to the whole
+// ring and then damages ten of the copies nodes[1] holds as a replica.
+func setupReplicated(t *testing.T, nodes []*Node, count int) {
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "rep", ReplicationFactor: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < count; i++ {
+		if err := nodes[i%3].SetIn("rep", fmt.Sprintf("key%d", i), "v", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	damaged := nodes[1]
+	replicaOf := func(key string) bool {
+		return !bytesEqual(ringOwner(nodes, namespacedKey("rep", key)).Id, damaged.Id)
+	}
+	damaged.stMtx.Lock()
+	defer damaged.stMtx.Unlock()
+	for i, n := 0, 0; n < 10; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if !replicaOf(key) {
+			continue
+		}
+		if n < 5 {
+			damaged.storage.MDelete("rep", key)
+		} else {
+			damaged.storage.Set("rep", key, "stale", time.Time{}, 0)
+		}
+		n++
+	}
+	extra := "extra"
+	for i := 0; !replicaOf(extra); i++ {
+		extra = fmt.Sprintf("extra%d", i)
+	}
+	damaged.storage.Set("rep", extra, "v", time.Time{}, 0)
+}
+
+func TestNode_antiEntropy(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	setupReplicated(t, nodes, 30)
+
+	for round := 0; round < 3; round++ {
+		for _, node := range nodes {
+			node.antiEntropy()
+		}
+	}
+	for _, node := range nodes {
+		for i := 0; i < 30; i++ {
+			val, err := node.storage.Get("rep", fmt.Sprintf("key%d", i))
+			if err != nil || string(val) != "v" {
+				t.Errorf("%s key%d = %q, %v, want v", node.Addr, i, val, err)
+			}
+		}
+		if keys, _ := node.storage.Usage("rep"); keys != 31 {
+			t.Errorf("%s holds %d keys, want 31", node.Addr, keys)
+		}
+	}
+
+	var pushed, pulled uint64
+	for _, node := range nodes {
+		pushed += node.Metrics()["anti_entropy_keys_pushed"]
+		pulled += node.Metrics()["anti_entropy_keys_pulled"]
+	}
+	if pushed < 10 || pulled != 1 {
+		t.Errorf("pushed %d keys and pulled %d, want at least 10 and 1", pushed, pulled)
+	}
+
+	// In sync, only the roots are compared.
+	for _, node := range nodes {
+		before := node.Metrics()["anti_entropy_hashes_compared"]
+		node.antiEntropy()
+		if got := node.Metrics()["anti_entropy_hashes_compared"] - before; got != 2 {
+			t.Errorf("%s compared %d hashes in sync, want 2", node.Addr, got)
+		}
+	}
+}
+
+func TestNode_antiEntropy_rateLimited(t *testing.T) {
+	nodes := newTestRing(t, 3, func(cnf *Config) {
+		cnf.RepairRate = 2
+	})
+	setupReplicated(t, nodes, 30)
+
+	for _, node := range nodes {
+		node.antiEntropy()
+	}
+	var repaired, throttled uint64
+	for _, node := range nodes {
+		metrics := node.Metrics()
+		repaired += metrics["anti_entropy_keys_pushed"] + metrics["anti_entropy_keys_pulled"]
+		throttled += metrics["anti_entropy_throttled"]
+	}
+	if repaired > 6 || throttled == 0 {
+		t.Errorf("repaired %d keys with %d throttled, want at most 6 and some throttled", repaired, throttled)
+	}
+}
@@ -24,6 +24,184 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// Type tags values that replicas merge instead of replacing. Counters
+// and sets are stored as a PNCounter and an ORSet.
+type KV_Type int32
+
+const (
+	KV_STRING  KV_Type = 0
+	KV_COUNTER KV_Type = 1
+	KV_SET     KV_Type = 2
+)
+
+var KV_Type_name = map[int32]string{
+	0: "STRING",
+	1: "COUNTER",
+	2: "SET",
+}
+
+var KV_Type_value = map[string]int32{
+	"STRING":  0,
+	"COUNTER": 1,
+	"SET":     2,
+}
+
+func (x KV_Type) String() string {
+	return proto.EnumName(KV_Type_name, int32(x))
+}
+
+func (KV_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{17, 0}
+}
+
+type KVBatchRequest_Op int32
+
+const (
+	KVBatchRequest_GET    KVBatchRequest_Op = 0
+	KVBatchRequest_SET    KVBatchRequest_Op = 1
+	KVBatchRequest_DELETE KVBatchRequest_Op = 2
+)
+
+var KVBatchRequest_Op_name = map[int32]string{
+	0: "GET",
+	1: "SET",
+	2: "DELETE",
+}
+
+var KVBatchRequest_Op_value = map[string]int32{
+	"GET":    0,
+	"SET":    1,
+	"DELETE": 2,
+}
+
+func (x KVBatchRequest_Op) String() string {
+	return proto.EnumName(KVBatchRequest_Op_name, int32(x))
+}
+
+func (KVBatchRequest_Op) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{33, 0}
+}
+
+type WatchEvent_Type int32
+
+const (
+	WatchEvent_SET    WatchEvent_Type = 0
+	WatchEvent_DELETE WatchEvent_Type = 1
+	WatchEvent_MOVED  WatchEvent_Type = 2
+)
+
+var WatchEvent_Type_name = map[int32]string{
+	0: "SET",
+	1: "DELETE",
+	2: "MOVED",
+}
+
+var WatchEvent_Type_value = map[string]int32{
+	"SET":    0,
+	"DELETE": 1,
+	"MOVED":  2,
+}
+
+func (x WatchEvent_Type) String() string {
+	return proto.EnumName(WatchEvent_Type_name, int32(x))
+}
+
+func (WatchEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{36, 0}
+}
+
+type Change_Type int32
+
+const (
+	Change_SET    Change_Type = 0
+	Change_DELETE Change_Type = 1
+	// TRANSFER_IN and TRANSFER_OUT record keys handed to or taken from
+	// this node as the ring changes.
+	Change_TRANSFER_IN  Change_Type = 2
+	Change_TRANSFER_OUT Change_Type = 3
+	Change_EXPIRE       Change_Type = 4
+)
+
+var Change_Type_name = map[int32]string{
+	0: "SET",
+	1: "DELETE",
+	2: "TRANSFER_IN",
+	3: "TRANSFER_OUT",
+	4: "EXPIRE",
+}
+
+var Change_Type_value = map[string]int32{
+	"SET":          0,
+	"DELETE":       1,
+	"TRANSFER_IN":  2,
+	"TRANSFER_OUT": 3,
+	"EXPIRE":       4,
+}
+
+func (x Change_Type) String() string {
+	return proto.EnumName(Change_Type_name, int32(x))
+}
+
+func (Change_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{38, 0}
+}
+
+type TxnRecord_State int32
+
+const (
+	TxnRecord_PENDING   TxnRecord_State = 0
+	TxnRecord_COMMITTED TxnRecord_State = 1
+	TxnRecord_ABORTED   TxnRecord_State = 2
+)
+
+var TxnRecord_State_name = map[int32]string{
+	0: "PENDING",
+	1: "COMMITTED",
+	2: "ABORTED",
+}
+
+var TxnRecord_State_value = map[string]int32{
+	"PENDING":   0,
+	"COMMITTED": 1,
+	"ABORTED":   2,
+}
+
+func (x TxnRecord_State) String() string {
+	return proto.EnumName(TxnRecord_State_name, int32(x))
+}
+
+func (TxnRecord_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{47, 0}
+}
+
+type LeaseRequest_Op int32
+
+const (
+	LeaseRequest_ACQUIRE LeaseRequest_Op = 0
+	LeaseRequest_RENEW   LeaseRequest_Op = 1
+	LeaseRequest_RELEASE LeaseRequest_Op = 2
+)
+
+var LeaseRequest_Op_name = map[int32]string{
+	0: "ACQUIRE",
+	1: "RENEW",
+	2: "RELEASE",
+}
+
+var LeaseRequest_Op_value = map[string]int32{
+	"ACQUIRE": 0,
+	"RENEW":   1,
+	"RELEASE": 2,
+}
+
+func (x LeaseRequest_Op) String() string {
+	return proto.EnumName(LeaseRequest_Op_name, int32(x))
+}
+
+func (LeaseRequest_Op) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{48, 0}
+}
+
 // Node contains a node ID and address.
 type Node struct {
 	Id                   []byte   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -104,7 +282,11 @@ func (m *ER) XXX_DiscardUnknown() {
 var xxx_messageInfo_ER proto.InternalMessageInfo
 
 type ID struct {
-	Id                   []byte   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// hops counts the nodes a lookup has been forwarded through.
+	Hops uint32 `protobuf:"varint,2,opt,name=hops,proto3" json:"hops,omitempty"`
+	// path lists the nodes a lookup has visited, in order.
+	Path                 []*Node  `protobuf:"bytes,3,rep,name=path,proto3" json:"path,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -142,899 +324,4212 @@ func (m *ID) GetId() []byte {
 	return nil
 }
 
-type GetRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+func (m *ID) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+func (m *ID) GetPath() []*Node {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+type FindSuccessorResponse struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// hops lists every node that handled the lookup, in order.
+	Hops                 []*Hop   `protobuf:"bytes,3,rep,name=hops,proto3" json:"hops,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetRequest) Reset()         { *m = GetRequest{} }
-func (m *GetRequest) String() string { return proto.CompactTextString(m) }
-func (*GetRequest) ProtoMessage()    {}
-func (*GetRequest) Descriptor() ([]byte, []int) {
+func (m *FindSuccessorResponse) Reset()         { *m = FindSuccessorResponse{} }
+func (m *FindSuccessorResponse) String() string { return proto.CompactTextString(m) }
+func (*FindSuccessorResponse) ProtoMessage()    {}
+func (*FindSuccessorResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{3}
 }
 
-func (m *GetRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+func (m *FindSuccessorResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindSuccessorResponse.Unmarshal(m, b)
 }
-func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+func (m *FindSuccessorResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindSuccessorResponse.Marshal(b, m, deterministic)
 }
-func (m *GetRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetRequest.Merge(m, src)
+func (m *FindSuccessorResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindSuccessorResponse.Merge(m, src)
 }
-func (m *GetRequest) XXX_Size() int {
-	return xxx_messageInfo_GetRequest.Size(m)
+func (m *FindSuccessorResponse) XXX_Size() int {
+	return xxx_messageInfo_FindSuccessorResponse.Size(m)
 }
-func (m *GetRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+func (m *FindSuccessorResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindSuccessorResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetRequest proto.InternalMessageInfo
+var xxx_messageInfo_FindSuccessorResponse proto.InternalMessageInfo
 
-func (m *GetRequest) GetKey() string {
+func (m *FindSuccessorResponse) GetNode() *Node {
 	if m != nil {
-		return m.Key
+		return m.Node
 	}
-	return ""
+	return nil
 }
 
-type GetResponse struct {
-	Value                []byte   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+func (m *FindSuccessorResponse) GetHops() []*Hop {
+	if m != nil {
+		return m.Hops
+	}
+	return nil
+}
+
+// Hop describes one step of a lookup.
+type Hop struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// finger is the finger table index the hop used to pick the next hop,
+	// or -1 if it answered from its successor pointer.
+	Finger int32 `protobuf:"varint,2,opt,name=finger,proto3" json:"finger,omitempty"`
+	// latency is how long the hop took to answer in nanoseconds, as
+	// measured by the node that contacted it. In recursive lookups the time
+	// the hop spent waiting on the next one is left out. The first hop, the
+	// node the lookup started on, counts its own time.
+	Latency              int64    `protobuf:"varint,3,opt,name=latency,proto3" json:"latency,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetResponse) Reset()         { *m = GetResponse{} }
-func (m *GetResponse) String() string { return proto.CompactTextString(m) }
-func (*GetResponse) ProtoMessage()    {}
-func (*GetResponse) Descriptor() ([]byte, []int) {
+func (m *Hop) Reset()         { *m = Hop{} }
+func (m *Hop) String() string { return proto.CompactTextString(m) }
+func (*Hop) ProtoMessage()    {}
+func (*Hop) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{4}
 }
 
-func (m *GetResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetResponse.Unmarshal(m, b)
+func (m *Hop) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Hop.Unmarshal(m, b)
 }
-func (m *GetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetResponse.Marshal(b, m, deterministic)
+func (m *Hop) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Hop.Marshal(b, m, deterministic)
 }
-func (m *GetResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetResponse.Merge(m, src)
+func (m *Hop) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Hop.Merge(m, src)
 }
-func (m *GetResponse) XXX_Size() int {
-	return xxx_messageInfo_GetResponse.Size(m)
+func (m *Hop) XXX_Size() int {
+	return xxx_messageInfo_Hop.Size(m)
 }
-func (m *GetResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetResponse.DiscardUnknown(m)
+func (m *Hop) XXX_DiscardUnknown() {
+	xxx_messageInfo_Hop.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetResponse proto.InternalMessageInfo
+var xxx_messageInfo_Hop proto.InternalMessageInfo
 
-func (m *GetResponse) GetValue() []byte {
+func (m *Hop) GetNode() *Node {
 	if m != nil {
-		return m.Value
+		return m.Node
 	}
 	return nil
 }
 
-type SetRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+func (m *Hop) GetFinger() int32 {
+	if m != nil {
+		return m.Finger
+	}
+	return 0
+}
+
+func (m *Hop) GetLatency() int64 {
+	if m != nil {
+		return m.Latency
+	}
+	return 0
+}
+
+type NeighbourRequest struct {
+	// node is the new neighbour.
+	Node *Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	// caller is the node asking for the change.
+	Caller               *Node    `protobuf:"bytes,2,opt,name=caller,proto3" json:"caller,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SetRequest) Reset()         { *m = SetRequest{} }
-func (m *SetRequest) String() string { return proto.CompactTextString(m) }
-func (*SetRequest) ProtoMessage()    {}
-func (*SetRequest) Descriptor() ([]byte, []int) {
+func (m *NeighbourRequest) Reset()         { *m = NeighbourRequest{} }
+func (m *NeighbourRequest) String() string { return proto.CompactTextString(m) }
+func (*NeighbourRequest) ProtoMessage()    {}
+func (*NeighbourRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{5}
 }
 
-func (m *SetRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SetRequest.Unmarshal(m, b)
+func (m *NeighbourRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NeighbourRequest.Unmarshal(m, b)
 }
-func (m *SetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SetRequest.Marshal(b, m, deterministic)
+func (m *NeighbourRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NeighbourRequest.Marshal(b, m, deterministic)
 }
-func (m *SetRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SetRequest.Merge(m, src)
+func (m *NeighbourRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NeighbourRequest.Merge(m, src)
 }
-func (m *SetRequest) XXX_Size() int {
-	return xxx_messageInfo_SetRequest.Size(m)
+func (m *NeighbourRequest) XXX_Size() int {
+	return xxx_messageInfo_NeighbourRequest.Size(m)
 }
-func (m *SetRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_SetRequest.DiscardUnknown(m)
+func (m *NeighbourRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NeighbourRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SetRequest proto.InternalMessageInfo
+var xxx_messageInfo_NeighbourRequest proto.InternalMessageInfo
 
-func (m *SetRequest) GetKey() string {
+func (m *NeighbourRequest) GetNode() *Node {
 	if m != nil {
-		return m.Key
+		return m.Node
 	}
-	return ""
+	return nil
 }
 
-func (m *SetRequest) GetValue() string {
+func (m *NeighbourRequest) GetCaller() *Node {
 	if m != nil {
-		return m.Value
+		return m.Caller
 	}
-	return ""
+	return nil
 }
 
-type SetResponse struct {
+type ProbeResponse struct {
+	Node        *Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Predecessor *Node `protobuf:"bytes,2,opt,name=predecessor,proto3" json:"predecessor,omitempty"`
+	Successor   *Node `protobuf:"bytes,3,opt,name=successor,proto3" json:"successor,omitempty"`
+	// leaving is set while the node hands its range over before stopping.
+	Leaving              bool     `protobuf:"varint,4,opt,name=leaving,proto3" json:"leaving,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *SetResponse) Reset()         { *m = SetResponse{} }
-func (m *SetResponse) String() string { return proto.CompactTextString(m) }
-func (*SetResponse) ProtoMessage()    {}
-func (*SetResponse) Descriptor() ([]byte, []int) {
+func (m *ProbeResponse) Reset()         { *m = ProbeResponse{} }
+func (m *ProbeResponse) String() string { return proto.CompactTextString(m) }
+func (*ProbeResponse) ProtoMessage()    {}
+func (*ProbeResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{6}
 }
 
-func (m *SetResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_SetResponse.Unmarshal(m, b)
+func (m *ProbeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProbeResponse.Unmarshal(m, b)
 }
-func (m *SetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_SetResponse.Marshal(b, m, deterministic)
+func (m *ProbeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProbeResponse.Marshal(b, m, deterministic)
 }
-func (m *SetResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_SetResponse.Merge(m, src)
+func (m *ProbeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProbeResponse.Merge(m, src)
 }
-func (m *SetResponse) XXX_Size() int {
-	return xxx_messageInfo_SetResponse.Size(m)
+func (m *ProbeResponse) XXX_Size() int {
+	return xxx_messageInfo_ProbeResponse.Size(m)
 }
-func (m *SetResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_SetResponse.DiscardUnknown(m)
+func (m *ProbeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProbeResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_SetResponse proto.InternalMessageInfo
+var xxx_messageInfo_ProbeResponse proto.InternalMessageInfo
 
-type DeleteRequest struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+func (m *ProbeResponse) GetNode() *Node {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *ProbeResponse) GetPredecessor() *Node {
+	if m != nil {
+		return m.Predecessor
+	}
+	return nil
+}
+
+func (m *ProbeResponse) GetSuccessor() *Node {
+	if m != nil {
+		return m.Successor
+	}
+	return nil
+}
+
+func (m *ProbeResponse) GetLeaving() bool {
+	if m != nil {
+		return m.Leaving
+	}
+	return false
+}
+
+type ClosestPrecedingRequest struct {
+	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// exclude lists node IDs the caller could not reach.
+	Exclude              [][]byte `protobuf:"bytes,2,rep,name=exclude,proto3" json:"exclude,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
-func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
-func (*DeleteRequest) ProtoMessage()    {}
-func (*DeleteRequest) Descriptor() ([]byte, []int) {
+func (m *ClosestPrecedingRequest) Reset()         { *m = ClosestPrecedingRequest{} }
+func (m *ClosestPrecedingRequest) String() string { return proto.CompactTextString(m) }
+func (*ClosestPrecedingRequest) ProtoMessage()    {}
+func (*ClosestPrecedingRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{7}
 }
 
-func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+func (m *ClosestPrecedingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClosestPrecedingRequest.Unmarshal(m, b)
 }
-func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+func (m *ClosestPrecedingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClosestPrecedingRequest.Marshal(b, m, deterministic)
 }
-func (m *DeleteRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DeleteRequest.Merge(m, src)
+func (m *ClosestPrecedingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClosestPrecedingRequest.Merge(m, src)
 }
-func (m *DeleteRequest) XXX_Size() int {
-	return xxx_messageInfo_DeleteRequest.Size(m)
+func (m *ClosestPrecedingRequest) XXX_Size() int {
+	return xxx_messageInfo_ClosestPrecedingRequest.Size(m)
 }
-func (m *DeleteRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+func (m *ClosestPrecedingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClosestPrecedingRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+var xxx_messageInfo_ClosestPrecedingRequest proto.InternalMessageInfo
 
-func (m *DeleteRequest) GetKey() string {
+func (m *ClosestPrecedingRequest) GetId() []byte {
 	if m != nil {
-		return m.Key
+		return m.Id
 	}
-	return ""
+	return nil
 }
 
-type DeleteResponse struct {
+func (m *ClosestPrecedingRequest) GetExclude() [][]byte {
+	if m != nil {
+		return m.Exclude
+	}
+	return nil
+}
+
+type ClosestPrecedingResponse struct {
+	Node      *Node `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
+	Successor *Node `protobuf:"bytes,2,opt,name=successor,proto3" json:"successor,omitempty"`
+	// finger is the finger table index node came from, or -1.
+	Finger               int32    `protobuf:"varint,3,opt,name=finger,proto3" json:"finger,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *DeleteResponse) Reset()         { *m = DeleteResponse{} }
-func (m *DeleteResponse) String() string { return proto.CompactTextString(m) }
-func (*DeleteResponse) ProtoMessage()    {}
-func (*DeleteResponse) Descriptor() ([]byte, []int) {
+func (m *ClosestPrecedingResponse) Reset()         { *m = ClosestPrecedingResponse{} }
+func (m *ClosestPrecedingResponse) String() string { return proto.CompactTextString(m) }
+func (*ClosestPrecedingResponse) ProtoMessage()    {}
+func (*ClosestPrecedingResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{8}
 }
 
-func (m *DeleteResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DeleteResponse.Unmarshal(m, b)
+func (m *ClosestPrecedingResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClosestPrecedingResponse.Unmarshal(m, b)
 }
-func (m *DeleteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DeleteResponse.Marshal(b, m, deterministic)
+func (m *ClosestPrecedingResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClosestPrecedingResponse.Marshal(b, m, deterministic)
 }
-func (m *DeleteResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DeleteResponse.Merge(m, src)
+func (m *ClosestPrecedingResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClosestPrecedingResponse.Merge(m, src)
 }
-func (m *DeleteResponse) XXX_Size() int {
-	return xxx_messageInfo_DeleteResponse.Size(m)
+func (m *ClosestPrecedingResponse) XXX_Size() int {
+	return xxx_messageInfo_ClosestPrecedingResponse.Size(m)
 }
-func (m *DeleteResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_DeleteResponse.DiscardUnknown(m)
+func (m *ClosestPrecedingResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClosestPrecedingResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DeleteResponse proto.InternalMessageInfo
+var xxx_messageInfo_ClosestPrecedingResponse proto.InternalMessageInfo
 
-type MultiDeleteRequest struct {
-	Keys                 []string `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+func (m *ClosestPrecedingResponse) GetNode() *Node {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *ClosestPrecedingResponse) GetSuccessor() *Node {
+	if m != nil {
+		return m.Successor
+	}
+	return nil
+}
+
+func (m *ClosestPrecedingResponse) GetFinger() int32 {
+	if m != nil {
+		return m.Finger
+	}
+	return 0
+}
+
+type GetRequest struct {
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// hops counts how many times the request has been forwarded.
+	Hops uint32 `protobuf:"varint,2,opt,name=hops,proto3" json:"hops,omitempty"`
+	// namespace the key lives in, empty for the default namespace.
+	Namespace string `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// replica reads this node's copy of the key without checking that it
+	// owns it. Used by quorum reads.
+	Replica              bool     `protobuf:"varint,4,opt,name=replica,proto3" json:"replica,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *MultiDeleteRequest) Reset()         { *m = MultiDeleteRequest{} }
-func (m *MultiDeleteRequest) String() string { return proto.CompactTextString(m) }
-func (*MultiDeleteRequest) ProtoMessage()    {}
-func (*MultiDeleteRequest) Descriptor() ([]byte, []int) {
+func (m *GetRequest) Reset()         { *m = GetRequest{} }
+func (m *GetRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()    {}
+func (*GetRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{9}
 }
 
-func (m *MultiDeleteRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_MultiDeleteRequest.Unmarshal(m, b)
+func (m *GetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
 }
-func (m *MultiDeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_MultiDeleteRequest.Marshal(b, m, deterministic)
+func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
 }
-func (m *MultiDeleteRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_MultiDeleteRequest.Merge(m, src)
+func (m *GetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRequest.Merge(m, src)
 }
-func (m *MultiDeleteRequest) XXX_Size() int {
-	return xxx_messageInfo_MultiDeleteRequest.Size(m)
+func (m *GetRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRequest.Size(m)
 }
-func (m *MultiDeleteRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_MultiDeleteRequest.DiscardUnknown(m)
+func (m *GetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_MultiDeleteRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetRequest proto.InternalMessageInfo
 
-func (m *MultiDeleteRequest) GetKeys() []string {
+func (m *GetRequest) GetKey() string {
 	if m != nil {
-		return m.Keys
+		return m.Key
 	}
-	return nil
+	return ""
 }
 
-type RequestKeysRequest struct {
-	From                 []byte   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
-	To                   []byte   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+func (m *GetRequest) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+func (m *GetRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetRequest) GetReplica() bool {
+	if m != nil {
+		return m.Replica
+	}
+	return false
+}
+
+type GetResponse struct {
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// version and expires of the value, see KV.
+	Version int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Expires int64 `protobuf:"varint,3,opt,name=expires,proto3" json:"expires,omitempty"`
+	// deleted is set, with no error, when a replica read finds a tombstone.
+	Deleted bool `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// type of the key. Counters are read as a decimal number and sets as a
+	// JSON list of members, except by replica reads.
+	Type KV_Type `protobuf:"varint,7,opt,name=type,proto3,enum=api.KV_Type" json:"type,omitempty"`
+	// In namespaces keeping siblings, siblings holds every concurrent value
+	// of the key and context its causal context, to be passed to the next
+	// write. value is only set when there is a single sibling.
+	Siblings []string `protobuf:"bytes,5,rep,name=siblings,proto3" json:"siblings,omitempty"`
+	Context  []byte   `protobuf:"bytes,6,opt,name=context,proto3" json:"context,omitempty"`
+	// flags stored with the value, see KV.
+	Flags                uint32   `protobuf:"varint,8,opt,name=flags,proto3" json:"flags,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RequestKeysRequest) Reset()         { *m = RequestKeysRequest{} }
-func (m *RequestKeysRequest) String() string { return proto.CompactTextString(m) }
-func (*RequestKeysRequest) ProtoMessage()    {}
-func (*RequestKeysRequest) Descriptor() ([]byte, []int) {
+func (m *GetResponse) Reset()         { *m = GetResponse{} }
+func (m *GetResponse) String() string { return proto.CompactTextString(m) }
+func (*GetResponse) ProtoMessage()    {}
+func (*GetResponse) Descriptor() ([]byte, []int) {
 	return fileDescriptor_00212fb1f9d3bf1c, []int{10}
 }
 
-func (m *RequestKeysRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RequestKeysRequest.Unmarshal(m, b)
+func (m *GetResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetResponse.Unmarshal(m, b)
 }
-func (m *RequestKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RequestKeysRequest.Marshal(b, m, deterministic)
+func (m *GetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetResponse.Marshal(b, m, deterministic)
 }
-func (m *RequestKeysRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RequestKeysRequest.Merge(m, src)
+func (m *GetResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetResponse.Merge(m, src)
 }
-func (m *RequestKeysRequest) XXX_Size() int {
-	return xxx_messageInfo_RequestKeysRequest.Size(m)
+func (m *GetResponse) XXX_Size() int {
+	return xxx_messageInfo_GetResponse.Size(m)
 }
-func (m *RequestKeysRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_RequestKeysRequest.DiscardUnknown(m)
+func (m *GetResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RequestKeysRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetResponse proto.InternalMessageInfo
 
-func (m *RequestKeysRequest) GetFrom() []byte {
+func (m *GetResponse) GetValue() []byte {
 	if m != nil {
-		return m.From
+		return m.Value
 	}
 	return nil
 }
 
-func (m *RequestKeysRequest) GetTo() []byte {
+func (m *GetResponse) GetVersion() int64 {
 	if m != nil {
-		return m.To
+		return m.Version
 	}
-	return nil
+	return 0
 }
 
-type KV struct {
-	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *GetResponse) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
 }
 
-func (m *KV) Reset()         { *m = KV{} }
-func (m *KV) String() string { return proto.CompactTextString(m) }
-func (*KV) ProtoMessage()    {}
-func (*KV) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{11}
+func (m *GetResponse) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
 }
 
-func (m *KV) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_KV.Unmarshal(m, b)
-}
-func (m *KV) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_KV.Marshal(b, m, deterministic)
-}
-func (m *KV) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_KV.Merge(m, src)
-}
-func (m *KV) XXX_Size() int {
-	return xxx_messageInfo_KV.Size(m)
-}
-func (m *KV) XXX_DiscardUnknown() {
-	xxx_messageInfo_KV.DiscardUnknown(m)
+func (m *GetResponse) GetType() KV_Type {
+	if m != nil {
+		return m.Type
+	}
+	return KV_STRING
 }
 
-var xxx_messageInfo_KV proto.InternalMessageInfo
+func (m *GetResponse) GetSiblings() []string {
+	if m != nil {
+		return m.Siblings
+	}
+	return nil
+}
 
-func (m *KV) GetKey() string {
+func (m *GetResponse) GetContext() []byte {
 	if m != nil {
-		return m.Key
+		return m.Context
 	}
-	return ""
+	return nil
 }
 
-func (m *KV) GetValue() string {
+func (m *GetResponse) GetFlags() uint32 {
 	if m != nil {
-		return m.Value
+		return m.Flags
 	}
-	return ""
+	return 0
 }
 
-type RequestKeysResponse struct {
-	Values               []*KV    `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+type SetRequest struct {
+	Key       string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value     string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Hops      uint32 `protobuf:"varint,3,opt,name=hops,proto3" json:"hops,omitempty"`
+	Namespace string `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// ttl in nanoseconds, 0 uses the namespace default.
+	Ttl int64 `protobuf:"varint,5,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// version, when set, is used instead of one picked by the owner. The
+	// write is skipped if the owner already holds a newer version. Used to
+	// replay hinted writes.
+	Version int64 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	// context, in namespaces keeping siblings, is the causal context of the
+	// read the write is based on. Siblings it has seen are replaced by the
+	// write; others are kept beside it.
+	Context []byte `protobuf:"bytes,7,opt,name=context,proto3" json:"context,omitempty"`
+	// flags are stored with the value, see KV.
+	Flags uint32 `protobuf:"varint,8,opt,name=flags,proto3" json:"flags,omitempty"`
+	// conditional writes only land if the version the owner holds is
+	// expected, 0 meaning the key is absent, and fail otherwise.
+	Conditional          bool     `protobuf:"varint,9,opt,name=conditional,proto3" json:"conditional,omitempty"`
+	Expected             int64    `protobuf:"varint,10,opt,name=expected,proto3" json:"expected,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RequestKeysResponse) Reset()         { *m = RequestKeysResponse{} }
-func (m *RequestKeysResponse) String() string { return proto.CompactTextString(m) }
-func (*RequestKeysResponse) ProtoMessage()    {}
-func (*RequestKeysResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_00212fb1f9d3bf1c, []int{12}
+func (m *SetRequest) Reset()         { *m = SetRequest{} }
+func (m *SetRequest) String() string { return proto.CompactTextString(m) }
+func (*SetRequest) ProtoMessage()    {}
+func (*SetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{11}
 }
 
-func (m *RequestKeysResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RequestKeysResponse.Unmarshal(m, b)
+func (m *SetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetRequest.Unmarshal(m, b)
 }
-func (m *RequestKeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RequestKeysResponse.Marshal(b, m, deterministic)
+func (m *SetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetRequest.Marshal(b, m, deterministic)
 }
-func (m *RequestKeysResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RequestKeysResponse.Merge(m, src)
+func (m *SetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetRequest.Merge(m, src)
 }
-func (m *RequestKeysResponse) XXX_Size() int {
-	return xxx_messageInfo_RequestKeysResponse.Size(m)
+func (m *SetRequest) XXX_Size() int {
+	return xxx_messageInfo_SetRequest.Size(m)
 }
-func (m *RequestKeysResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_RequestKeysResponse.DiscardUnknown(m)
+func (m *SetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RequestKeysResponse proto.InternalMessageInfo
+var xxx_messageInfo_SetRequest proto.InternalMessageInfo
 
-func (m *RequestKeysResponse) GetValues() []*KV {
+func (m *SetRequest) GetKey() string {
 	if m != nil {
-		return m.Values
+		return m.Key
 	}
-	return nil
+	return ""
 }
 
-func init() {
-	proto.RegisterType((*Node)(nil), "api.Node")
-	proto.RegisterType((*ER)(nil), "api.ER")
-	proto.RegisterType((*ID)(nil), "api.ID")
-	proto.RegisterType((*GetRequest)(nil), "api.GetRequest")
-	proto.RegisterType((*GetResponse)(nil), "api.GetResponse")
-	proto.RegisterType((*SetRequest)(nil), "api.SetRequest")
-	proto.RegisterType((*SetResponse)(nil), "api.SetResponse")
-	proto.RegisterType((*DeleteRequest)(nil), "api.DeleteRequest")
-	proto.RegisterType((*DeleteResponse)(nil), "api.DeleteResponse")
-	proto.RegisterType((*MultiDeleteRequest)(nil), "api.MultiDeleteRequest")
-	proto.RegisterType((*RequestKeysRequest)(nil), "api.RequestKeysRequest")
-	proto.RegisterType((*KV)(nil), "api.KV")
-	proto.RegisterType((*RequestKeysResponse)(nil), "api.RequestKeysResponse")
+func (m *SetRequest) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
 }
 
-func init() {
-	proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c)
+func (m *SetRequest) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
 }
 
-var fileDescriptor_00212fb1f9d3bf1c = []byte{
-	// 445 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xd1, 0x8a, 0xda, 0x40,
-	0x14, 0xc5, 0x98, 0x75, 0xf1, 0x18, 0xad, 0xdc, 0x5d, 0xa8, 0x84, 0xd2, 0xda, 0x59, 0x1f, 0x6c,
-	0x29, 0xfb, 0x60, 0x4b, 0xe9, 0x43, 0x5f, 0xca, 0xba, 0x15, 0x91, 0x2e, 0x25, 0x81, 0xc5, 0x57,
-	0xeb, 0xdc, 0x65, 0x83, 0x76, 0xc7, 0x26, 0x63, 0xc1, 0xaf, 0xe8, 0x2f, 0x97, 0x4c, 0x66, 0x9b,
-	0xc4, 0x58, 0xd9, 0xb7, 0x9b, 0x7b, 0xcf, 0x39, 0x73, 0xe6, 0xce, 0x09, 0x9a, 0x8b, 0x4d, 0x74,
-	0xb9, 0x89, 0x95, 0x56, 0x54, 0x5f, 0x6c, 0x22, 0xf1, 0x16, 0xee, 0x8d, 0x92, 0x4c, 0x1d, 0x38,
-	0x91, 0xec, 0xd5, 0xfa, 0xb5, 0xa1, 0x17, 0x38, 0x91, 0x24, 0x82, 0xbb, 0x90, 0x32, 0xee, 0x39,
-	0xfd, 0xda, 0xb0, 0x19, 0x98, 0x5a, 0xb8, 0x70, 0xae, 0x03, 0x71, 0x0e, 0x67, 0x3a, 0xde, 0xc7,
-	0x8b, 0x97, 0xc0, 0x84, 0x75, 0xc0, 0xbf, 0xb6, 0x9c, 0x68, 0xea, 0xa2, 0xbe, 0xe2, 0x9d, 0x19,
-	0x37, 0x83, 0xb4, 0x14, 0x17, 0x68, 0x99, 0x79, 0xb2, 0x51, 0x0f, 0x09, 0xd3, 0x39, 0x4e, 0x7e,
-	0x2f, 0xd6, 0x5b, 0xb6, 0x0a, 0xd9, 0x87, 0xf8, 0x00, 0x84, 0x47, 0x44, 0x72, 0x56, 0xe6, 0xca,
-	0xb2, 0xda, 0x68, 0x85, 0xb9, 0xb4, 0x78, 0x8d, 0xf6, 0x98, 0xd7, 0xac, 0xf9, 0xff, 0x66, 0xba,
-	0xe8, 0x3c, 0x42, 0x2c, 0x69, 0x08, 0xfa, 0xb6, 0x5d, 0xeb, 0xa8, 0xcc, 0x24, 0xb8, 0x2b, 0xde,
-	0x25, 0xbd, 0x5a, 0xbf, 0x9e, 0x2e, 0x21, 0xad, 0xc5, 0x27, 0x90, 0x1d, 0xcf, 0x78, 0x97, 0x14,
-	0x90, 0x77, 0xb1, 0xfa, 0x69, 0xaf, 0x63, 0xea, 0x74, 0x45, 0x5a, 0x19, 0xab, 0x5e, 0xe0, 0x68,
-	0x25, 0xde, 0xc1, 0x99, 0xdd, 0x3e, 0xf9, 0x56, 0x1f, 0x71, 0x56, 0x3a, 0xc7, 0x2e, 0xee, 0x15,
-	0x1a, 0x66, 0x9e, 0x99, 0x6a, 0x8d, 0x4e, 0x2f, 0xd3, 0x07, 0x9d, 0xdd, 0x06, 0xb6, 0x3d, 0xfa,
-	0xe3, 0xe2, 0xe4, 0xea, 0x5e, 0xc5, 0x92, 0x06, 0xe8, 0x4c, 0x58, 0x7f, 0x8f, 0x59, 0xf2, 0x92,
-	0x93, 0x44, 0xc5, 0x94, 0x81, 0xaf, 0x03, 0xbf, 0x69, 0x0a, 0xf3, 0xf0, 0x02, 0xde, 0x84, 0x75,
-	0xb8, 0x5d, 0x1e, 0xc1, 0xbc, 0x40, 0xe3, 0x46, 0xe9, 0xe8, 0x6e, 0x47, 0x79, 0xd3, 0x7f, 0x04,
-	0xd2, 0x05, 0xda, 0x5f, 0xa3, 0x07, 0xb9, 0x2f, 0x31, 0x1d, 0x17, 0x25, 0x06, 0xe8, 0x5e, 0xdd,
-	0xf3, 0x72, 0x55, 0xb5, 0x33, 0x1d, 0xe7, 0x52, 0x03, 0x74, 0xc2, 0xb2, 0xe5, 0x43, 0x07, 0x0a,
-	0x78, 0x61, 0xd1, 0xf2, 0x21, 0xcc, 0x1b, 0xb8, 0xf3, 0x09, 0x6b, 0x7a, 0x66, 0x1a, 0x79, 0x34,
-	0xfd, 0x6e, 0xde, 0xb0, 0x2b, 0x4d, 0xa1, 0xe1, 0x3f, 0x68, 0xb8, 0x0f, 0x2d, 0x64, 0x8b, 0x46,
-	0x38, 0x9d, 0x67, 0x11, 0x21, 0x32, 0xc3, 0x52, 0x5e, 0xfc, 0xb3, 0x52, 0xcf, 0x72, 0x3e, 0xc3,
-	0x9b, 0x17, 0xb2, 0x45, 0xcf, 0x0d, 0xa8, 0x9a, 0xb6, 0xc3, 0xec, 0x2f, 0xf0, 0xe6, 0x85, 0x1c,
-	0x58, 0x76, 0x35, 0x81, 0x7e, 0xaf, 0x3a, 0xc8, 0x24, 0x7e, 0x34, 0xcc, 0xef, 0xfe, 0xfe, 0x6f,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0x1b, 0x77, 0x28, 0x4a, 0xfb, 0x03, 0x00, 0x00,
+func (m *SetRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConnInterface
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion6
-
-// ChordClient is the client API for Chord service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ChordClient interface {
-	// GetPredecessor returns the node believed to be the current predecessor.
-	GetPredecessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error)
-	// GetSuccessor returns the node believed to be the current successor.
-	GetSuccessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error)
-	// Notify notifies Chord that Node thinks it is our predecessor. This has
-	// the potential to initiate the transferring of keys.
-	Notify(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error)
-	// FindSuccessor finds the node the succedes ID. May initiate RPC calls to
-	// other nodes.
-	FindSuccessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*Node, error)
-	// CheckPredecessor checkes whether predecessor has failed.
-	CheckPredecessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*ER, error)
-	// SetPredecessor sets predecessor for a node.
-	SetPredecessor(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error)
-	// SetPredecessor sets predecessor for a node.
-	SetSuccessor(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error)
-	// Get returns the value in Chord ring for the given key.
-	XGet(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
-	// Set writes a key value pair to the Chord ring.
-	XSet(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
-	// Delete returns the value in Chord ring for the given key.
-	XDelete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
-	// Multiple delete returns the value in Chord ring between the given keys.
-	XMultiDelete(ctx context.Context, in *MultiDeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
-	// RequestKeys returns the keys between given range from the Chord ring.
-	XRequestKeys(ctx context.Context, in *RequestKeysRequest, opts ...grpc.CallOption) (*RequestKeysResponse, error)
+func (m *SetRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
 }
 
-type chordClient struct {
-	cc grpc.ClientConnInterface
+func (m *SetRequest) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
 }
 
-func NewChordClient(cc grpc.ClientConnInterface) ChordClient {
-	return &chordClient{cc}
+func (m *SetRequest) GetContext() []byte {
+	if m != nil {
+		return m.Context
+	}
+	return nil
 }
 
-func (c *chordClient) GetPredecessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error) {
-	out := new(Node)
-	err := c.cc.Invoke(ctx, "/api.Chord/GetPredecessor", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *SetRequest) GetFlags() uint32 {
+	if m != nil {
+		return m.Flags
 	}
-	return out, nil
+	return 0
 }
 
-func (c *chordClient) GetSuccessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error) {
-	out := new(Node)
-	err := c.cc.Invoke(ctx, "/api.Chord/GetSuccessor", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *SetRequest) GetConditional() bool {
+	if m != nil {
+		return m.Conditional
 	}
-	return out, nil
+	return false
 }
 
-func (c *chordClient) Notify(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error) {
-	out := new(ER)
-	err := c.cc.Invoke(ctx, "/api.Chord/Notify", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *SetRequest) GetExpected() int64 {
+	if m != nil {
+		return m.Expected
 	}
-	return out, nil
+	return 0
+}
+
+type SetResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetResponse) Reset()         { *m = SetResponse{} }
+func (m *SetResponse) String() string { return proto.CompactTextString(m) }
+func (*SetResponse) ProtoMessage()    {}
+func (*SetResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{12}
+}
+
+func (m *SetResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetResponse.Unmarshal(m, b)
+}
+func (m *SetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetResponse.Marshal(b, m, deterministic)
+}
+func (m *SetResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetResponse.Merge(m, src)
+}
+func (m *SetResponse) XXX_Size() int {
+	return xxx_messageInfo_SetResponse.Size(m)
+}
+func (m *SetResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetResponse proto.InternalMessageInfo
+
+type DeleteRequest struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Hops                 uint32   `protobuf:"varint,2,opt,name=hops,proto3" json:"hops,omitempty"`
+	Namespace            string   `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()    {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{13}
+}
+
+func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+}
+func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteRequest.Merge(m, src)
+}
+func (m *DeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteRequest.Size(m)
+}
+func (m *DeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+
+func (m *DeleteRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *DeleteRequest) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+func (m *DeleteRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteResponse) Reset()         { *m = DeleteResponse{} }
+func (m *DeleteResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteResponse) ProtoMessage()    {}
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{14}
+}
+
+func (m *DeleteResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteResponse.Unmarshal(m, b)
+}
+func (m *DeleteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteResponse.Marshal(b, m, deterministic)
+}
+func (m *DeleteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteResponse.Merge(m, src)
+}
+func (m *DeleteResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteResponse.Size(m)
+}
+func (m *DeleteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteResponse proto.InternalMessageInfo
+
+type MultiDeleteRequest struct {
+	Keys                 []string `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Namespace            string   `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MultiDeleteRequest) Reset()         { *m = MultiDeleteRequest{} }
+func (m *MultiDeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*MultiDeleteRequest) ProtoMessage()    {}
+func (*MultiDeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{15}
+}
+
+func (m *MultiDeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MultiDeleteRequest.Unmarshal(m, b)
+}
+func (m *MultiDeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MultiDeleteRequest.Marshal(b, m, deterministic)
+}
+func (m *MultiDeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiDeleteRequest.Merge(m, src)
+}
+func (m *MultiDeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_MultiDeleteRequest.Size(m)
+}
+func (m *MultiDeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiDeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiDeleteRequest proto.InternalMessageInfo
+
+func (m *MultiDeleteRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *MultiDeleteRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+type RequestKeysRequest struct {
+	From                 []byte   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   []byte   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestKeysRequest) Reset()         { *m = RequestKeysRequest{} }
+func (m *RequestKeysRequest) String() string { return proto.CompactTextString(m) }
+func (*RequestKeysRequest) ProtoMessage()    {}
+func (*RequestKeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{16}
+}
+
+func (m *RequestKeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestKeysRequest.Unmarshal(m, b)
+}
+func (m *RequestKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestKeysRequest.Marshal(b, m, deterministic)
+}
+func (m *RequestKeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestKeysRequest.Merge(m, src)
+}
+func (m *RequestKeysRequest) XXX_Size() int {
+	return xxx_messageInfo_RequestKeysRequest.Size(m)
+}
+func (m *RequestKeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestKeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestKeysRequest proto.InternalMessageInfo
+
+func (m *RequestKeysRequest) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *RequestKeysRequest) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+type KV struct {
+	Key       string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value     string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Namespace string `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// expires is when the pair expires in Unix nanoseconds, 0 for never.
+	Expires int64 `protobuf:"varint,4,opt,name=expires,proto3" json:"expires,omitempty"`
+	// version orders the writes of a key, larger being newer. It is the
+	// hybrid logical clock timestamp of the owner that accepted the write,
+	// copied to replicas, which keep the newest they are sent.
+	Version int64 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	// deleted marks a tombstone: the key was deleted at version. Tombstones
+	// carry no value and are kept until the grace period runs out.
+	Deleted bool `protobuf:"varint,6,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// siblings holds the concurrent values of a key when it has more than
+	// one, in scans of namespaces keeping siblings. value is then empty.
+	Siblings []string `protobuf:"bytes,7,rep,name=siblings,proto3" json:"siblings,omitempty"`
+	Type     KV_Type  `protobuf:"varint,8,opt,name=type,proto3,enum=api.KV_Type" json:"type,omitempty"`
+	// flags are opaque to boopy and kept with the value for clients that
+	// need them, such as memcached clients.
+	Flags                uint32   `protobuf:"varint,9,opt,name=flags,proto3" json:"flags,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KV) Reset()         { *m = KV{} }
+func (m *KV) String() string { return proto.CompactTextString(m) }
+func (*KV) ProtoMessage()    {}
+func (*KV) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{17}
+}
+
+func (m *KV) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KV.Unmarshal(m, b)
+}
+func (m *KV) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KV.Marshal(b, m, deterministic)
+}
+func (m *KV) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KV.Merge(m, src)
+}
+func (m *KV) XXX_Size() int {
+	return xxx_messageInfo_KV.Size(m)
+}
+func (m *KV) XXX_DiscardUnknown() {
+	xxx_messageInfo_KV.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KV proto.InternalMessageInfo
+
+func (m *KV) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KV) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *KV) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *KV) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+func (m *KV) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *KV) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+func (m *KV) GetSiblings() []string {
+	if m != nil {
+		return m.Siblings
+	}
+	return nil
+}
+
+func (m *KV) GetType() KV_Type {
+	if m != nil {
+		return m.Type
+	}
+	return KV_STRING
+}
+
+func (m *KV) GetFlags() uint32 {
+	if m != nil {
+		return m.Flags
+	}
+	return 0
+}
+
+type RequestKeysResponse struct {
+	Values               []*KV    `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestKeysResponse) Reset()         { *m = RequestKeysResponse{} }
+func (m *RequestKeysResponse) String() string { return proto.CompactTextString(m) }
+func (*RequestKeysResponse) ProtoMessage()    {}
+func (*RequestKeysResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{18}
+}
+
+func (m *RequestKeysResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestKeysResponse.Unmarshal(m, b)
+}
+func (m *RequestKeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestKeysResponse.Marshal(b, m, deterministic)
+}
+func (m *RequestKeysResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestKeysResponse.Merge(m, src)
+}
+func (m *RequestKeysResponse) XXX_Size() int {
+	return xxx_messageInfo_RequestKeysResponse.Size(m)
+}
+func (m *RequestKeysResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestKeysResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestKeysResponse proto.InternalMessageInfo
+
+func (m *RequestKeysResponse) GetValues() []*KV {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type StoreKeysRequest struct {
+	Values []*KV `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	// transfer is set when the keys are handed over by their previous
+	// owner, rather than copied to a replica.
+	Transfer             bool     `protobuf:"varint,2,opt,name=transfer,proto3" json:"transfer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StoreKeysRequest) Reset()         { *m = StoreKeysRequest{} }
+func (m *StoreKeysRequest) String() string { return proto.CompactTextString(m) }
+func (*StoreKeysRequest) ProtoMessage()    {}
+func (*StoreKeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{19}
+}
+
+func (m *StoreKeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StoreKeysRequest.Unmarshal(m, b)
+}
+func (m *StoreKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StoreKeysRequest.Marshal(b, m, deterministic)
+}
+func (m *StoreKeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StoreKeysRequest.Merge(m, src)
+}
+func (m *StoreKeysRequest) XXX_Size() int {
+	return xxx_messageInfo_StoreKeysRequest.Size(m)
+}
+func (m *StoreKeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StoreKeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StoreKeysRequest proto.InternalMessageInfo
+
+func (m *StoreKeysRequest) GetValues() []*KV {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *StoreKeysRequest) GetTransfer() bool {
+	if m != nil {
+		return m.Transfer
+	}
+	return false
+}
+
+// Namespace holds the settings shared by every key in a namespace.
+type Namespace struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// replication_factor is the number of nodes holding each key, counting
+	// the owner. 0 is treated as 1.
+	ReplicationFactor uint32 `protobuf:"varint,2,opt,name=replication_factor,json=replicationFactor,proto3" json:"replication_factor,omitempty"`
+	// default_ttl in nanoseconds applies to writes without a ttl, 0 for none.
+	DefaultTtl int64 `protobuf:"varint,3,opt,name=default_ttl,json=defaultTtl,proto3" json:"default_ttl,omitempty"`
+	// max_keys and max_bytes limit what each node stores for the
+	// namespace, 0 for no limit. Bytes count keys and values.
+	MaxKeys  uint64 `protobuf:"varint,4,opt,name=max_keys,json=maxKeys,proto3" json:"max_keys,omitempty"`
+	MaxBytes uint64 `protobuf:"varint,5,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	// siblings keeps concurrent writes of a key side by side, ordered by
+	// vector clocks, instead of letting the last writer win.
+	Siblings             bool     `protobuf:"varint,6,opt,name=siblings,proto3" json:"siblings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Namespace) Reset()         { *m = Namespace{} }
+func (m *Namespace) String() string { return proto.CompactTextString(m) }
+func (*Namespace) ProtoMessage()    {}
+func (*Namespace) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{20}
+}
+
+func (m *Namespace) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Namespace.Unmarshal(m, b)
+}
+func (m *Namespace) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Namespace.Marshal(b, m, deterministic)
+}
+func (m *Namespace) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Namespace.Merge(m, src)
+}
+func (m *Namespace) XXX_Size() int {
+	return xxx_messageInfo_Namespace.Size(m)
+}
+func (m *Namespace) XXX_DiscardUnknown() {
+	xxx_messageInfo_Namespace.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Namespace proto.InternalMessageInfo
+
+func (m *Namespace) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Namespace) GetReplicationFactor() uint32 {
+	if m != nil {
+		return m.ReplicationFactor
+	}
+	return 0
+}
+
+func (m *Namespace) GetDefaultTtl() int64 {
+	if m != nil {
+		return m.DefaultTtl
+	}
+	return 0
+}
+
+func (m *Namespace) GetMaxKeys() uint64 {
+	if m != nil {
+		return m.MaxKeys
+	}
+	return 0
+}
+
+func (m *Namespace) GetMaxBytes() uint64 {
+	if m != nil {
+		return m.MaxBytes
+	}
+	return 0
+}
+
+func (m *Namespace) GetSiblings() bool {
+	if m != nil {
+		return m.Siblings
+	}
+	return false
+}
+
+// VectorClock counts the writes of a key accepted by each node, by node id
+// in hex.
+type VectorClock struct {
+	Counters             map[string]uint64 `protobuf:"bytes,1,rep,name=counters,proto3" json:"counters,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *VectorClock) Reset()         { *m = VectorClock{} }
+func (m *VectorClock) String() string { return proto.CompactTextString(m) }
+func (*VectorClock) ProtoMessage()    {}
+func (*VectorClock) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{21}
+}
+
+func (m *VectorClock) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VectorClock.Unmarshal(m, b)
+}
+func (m *VectorClock) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VectorClock.Marshal(b, m, deterministic)
+}
+func (m *VectorClock) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VectorClock.Merge(m, src)
+}
+func (m *VectorClock) XXX_Size() int {
+	return xxx_messageInfo_VectorClock.Size(m)
+}
+func (m *VectorClock) XXX_DiscardUnknown() {
+	xxx_messageInfo_VectorClock.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VectorClock proto.InternalMessageInfo
+
+func (m *VectorClock) GetCounters() map[string]uint64 {
+	if m != nil {
+		return m.Counters
+	}
+	return nil
+}
+
+type Sibling struct {
+	Value                string       `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Clock                *VectorClock `protobuf:"bytes,2,opt,name=clock,proto3" json:"clock,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *Sibling) Reset()         { *m = Sibling{} }
+func (m *Sibling) String() string { return proto.CompactTextString(m) }
+func (*Sibling) ProtoMessage()    {}
+func (*Sibling) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{22}
+}
+
+func (m *Sibling) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Sibling.Unmarshal(m, b)
+}
+func (m *Sibling) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Sibling.Marshal(b, m, deterministic)
+}
+func (m *Sibling) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Sibling.Merge(m, src)
+}
+func (m *Sibling) XXX_Size() int {
+	return xxx_messageInfo_Sibling.Size(m)
+}
+func (m *Sibling) XXX_DiscardUnknown() {
+	xxx_messageInfo_Sibling.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Sibling proto.InternalMessageInfo
+
+func (m *Sibling) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *Sibling) GetClock() *VectorClock {
+	if m != nil {
+		return m.Clock
+	}
+	return nil
+}
+
+// SiblingSet is how a key of a namespace keeping siblings is stored.
+type SiblingSet struct {
+	Siblings             []*Sibling `protobuf:"bytes,1,rep,name=siblings,proto3" json:"siblings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *SiblingSet) Reset()         { *m = SiblingSet{} }
+func (m *SiblingSet) String() string { return proto.CompactTextString(m) }
+func (*SiblingSet) ProtoMessage()    {}
+func (*SiblingSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{23}
+}
+
+func (m *SiblingSet) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SiblingSet.Unmarshal(m, b)
+}
+func (m *SiblingSet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SiblingSet.Marshal(b, m, deterministic)
+}
+func (m *SiblingSet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SiblingSet.Merge(m, src)
+}
+func (m *SiblingSet) XXX_Size() int {
+	return xxx_messageInfo_SiblingSet.Size(m)
+}
+func (m *SiblingSet) XXX_DiscardUnknown() {
+	xxx_messageInfo_SiblingSet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SiblingSet proto.InternalMessageInfo
+
+func (m *SiblingSet) GetSiblings() []*Sibling {
+	if m != nil {
+		return m.Siblings
+	}
+	return nil
+}
+
+// Hint is a write kept for an owner that could not be reached, as saved to
+// a node's hint file.
+type Hint struct {
+	Owner   *Node       `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	Request *SetRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	// expires is when the write's ttl runs out in unix nanoseconds, 0 if it
+	// has none.
+	Expires              int64    `protobuf:"varint,3,opt,name=expires,proto3" json:"expires,omitempty"`
+	Stored               int64    `protobuf:"varint,4,opt,name=stored,proto3" json:"stored,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Hint) Reset()         { *m = Hint{} }
+func (m *Hint) String() string { return proto.CompactTextString(m) }
+func (*Hint) ProtoMessage()    {}
+func (*Hint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{24}
+}
+
+func (m *Hint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Hint.Unmarshal(m, b)
+}
+func (m *Hint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Hint.Marshal(b, m, deterministic)
+}
+func (m *Hint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Hint.Merge(m, src)
+}
+func (m *Hint) XXX_Size() int {
+	return xxx_messageInfo_Hint.Size(m)
+}
+func (m *Hint) XXX_DiscardUnknown() {
+	xxx_messageInfo_Hint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Hint proto.InternalMessageInfo
+
+func (m *Hint) GetOwner() *Node {
+	if m != nil {
+		return m.Owner
+	}
+	return nil
+}
+
+func (m *Hint) GetRequest() *SetRequest {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *Hint) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+func (m *Hint) GetStored() int64 {
+	if m != nil {
+		return m.Stored
+	}
+	return 0
+}
+
+type HintLog struct {
+	Hints                []*Hint  `protobuf:"bytes,1,rep,name=hints,proto3" json:"hints,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HintLog) Reset()         { *m = HintLog{} }
+func (m *HintLog) String() string { return proto.CompactTextString(m) }
+func (*HintLog) ProtoMessage()    {}
+func (*HintLog) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{25}
+}
+
+func (m *HintLog) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HintLog.Unmarshal(m, b)
+}
+func (m *HintLog) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HintLog.Marshal(b, m, deterministic)
+}
+func (m *HintLog) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HintLog.Merge(m, src)
+}
+func (m *HintLog) XXX_Size() int {
+	return xxx_messageInfo_HintLog.Size(m)
+}
+func (m *HintLog) XXX_DiscardUnknown() {
+	xxx_messageInfo_HintLog.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HintLog proto.InternalMessageInfo
+
+func (m *HintLog) GetHints() []*Hint {
+	if m != nil {
+		return m.Hints
+	}
+	return nil
+}
+
+type NamespaceRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NamespaceRequest) Reset()         { *m = NamespaceRequest{} }
+func (m *NamespaceRequest) String() string { return proto.CompactTextString(m) }
+func (*NamespaceRequest) ProtoMessage()    {}
+func (*NamespaceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{26}
+}
+
+func (m *NamespaceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NamespaceRequest.Unmarshal(m, b)
+}
+func (m *NamespaceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NamespaceRequest.Marshal(b, m, deterministic)
+}
+func (m *NamespaceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NamespaceRequest.Merge(m, src)
+}
+func (m *NamespaceRequest) XXX_Size() int {
+	return xxx_messageInfo_NamespaceRequest.Size(m)
+}
+func (m *NamespaceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NamespaceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NamespaceRequest proto.InternalMessageInfo
+
+func (m *NamespaceRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type NamespaceList struct {
+	Namespaces           []*Namespace `protobuf:"bytes,1,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *NamespaceList) Reset()         { *m = NamespaceList{} }
+func (m *NamespaceList) String() string { return proto.CompactTextString(m) }
+func (*NamespaceList) ProtoMessage()    {}
+func (*NamespaceList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{27}
+}
+
+func (m *NamespaceList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NamespaceList.Unmarshal(m, b)
+}
+func (m *NamespaceList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NamespaceList.Marshal(b, m, deterministic)
+}
+func (m *NamespaceList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NamespaceList.Merge(m, src)
+}
+func (m *NamespaceList) XXX_Size() int {
+	return xxx_messageInfo_NamespaceList.Size(m)
+}
+func (m *NamespaceList) XXX_DiscardUnknown() {
+	xxx_messageInfo_NamespaceList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NamespaceList proto.InternalMessageInfo
+
+func (m *NamespaceList) GetNamespaces() []*Namespace {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// after is the ring position to continue from, exclusive. Empty starts
+	// at the beginning of the ring.
+	After []byte `protobuf:"bytes,2,opt,name=after,proto3" json:"after,omitempty"`
+	// prefix only returns keys starting with it.
+	Prefix string `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// values also returns values, otherwise only keys are sent.
+	Values bool `protobuf:"varint,4,opt,name=values,proto3" json:"values,omitempty"`
+	// limit is the most keys to return, 0 for no limit.
+	Limit                uint32   `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ScanRequest) Reset()         { *m = ScanRequest{} }
+func (m *ScanRequest) String() string { return proto.CompactTextString(m) }
+func (*ScanRequest) ProtoMessage()    {}
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{28}
+}
+
+func (m *ScanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScanRequest.Unmarshal(m, b)
+}
+func (m *ScanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScanRequest.Marshal(b, m, deterministic)
+}
+func (m *ScanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScanRequest.Merge(m, src)
+}
+func (m *ScanRequest) XXX_Size() int {
+	return xxx_messageInfo_ScanRequest.Size(m)
+}
+func (m *ScanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScanRequest proto.InternalMessageInfo
+
+func (m *ScanRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ScanRequest) GetAfter() []byte {
+	if m != nil {
+		return m.After
+	}
+	return nil
+}
+
+func (m *ScanRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *ScanRequest) GetValues() bool {
+	if m != nil {
+		return m.Values
+	}
+	return false
+}
+
+func (m *ScanRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type KVScanRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// prefix only returns keys starting with it.
+	Prefix string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// values also returns values, otherwise only keys are sent.
+	Values bool `protobuf:"varint,3,opt,name=values,proto3" json:"values,omitempty"`
+	// limit is the most keys to return, 0 for the default page size.
+	Limit uint32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// cursor is the one returned with the previous page, empty to start.
+	Cursor               string   `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KVScanRequest) Reset()         { *m = KVScanRequest{} }
+func (m *KVScanRequest) String() string { return proto.CompactTextString(m) }
+func (*KVScanRequest) ProtoMessage()    {}
+func (*KVScanRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{29}
+}
+
+func (m *KVScanRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KVScanRequest.Unmarshal(m, b)
+}
+func (m *KVScanRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KVScanRequest.Marshal(b, m, deterministic)
+}
+func (m *KVScanRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KVScanRequest.Merge(m, src)
+}
+func (m *KVScanRequest) XXX_Size() int {
+	return xxx_messageInfo_KVScanRequest.Size(m)
+}
+func (m *KVScanRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KVScanRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KVScanRequest proto.InternalMessageInfo
+
+func (m *KVScanRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *KVScanRequest) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *KVScanRequest) GetValues() bool {
+	if m != nil {
+		return m.Values
+	}
+	return false
+}
+
+func (m *KVScanRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *KVScanRequest) GetCursor() string {
+	if m != nil {
+		return m.Cursor
+	}
+	return ""
+}
+
+type KVScanResponse struct {
+	Keys []*KV `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	// cursor continues the scan, empty once the whole ring has been read.
+	Cursor               string   `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KVScanResponse) Reset()         { *m = KVScanResponse{} }
+func (m *KVScanResponse) String() string { return proto.CompactTextString(m) }
+func (*KVScanResponse) ProtoMessage()    {}
+func (*KVScanResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{30}
+}
+
+func (m *KVScanResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KVScanResponse.Unmarshal(m, b)
+}
+func (m *KVScanResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KVScanResponse.Marshal(b, m, deterministic)
+}
+func (m *KVScanResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KVScanResponse.Merge(m, src)
+}
+func (m *KVScanResponse) XXX_Size() int {
+	return xxx_messageInfo_KVScanResponse.Size(m)
+}
+func (m *KVScanResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_KVScanResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KVScanResponse proto.InternalMessageInfo
+
+func (m *KVScanResponse) GetKeys() []*KV {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *KVScanResponse) GetCursor() string {
+	if m != nil {
+		return m.Cursor
+	}
+	return ""
+}
+
+type BatchRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// pairs lists the keys, values are only used by sets.
+	Pairs []*KV `protobuf:"bytes,2,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	// ttl for sets in nanoseconds, 0 uses the namespace default.
+	Ttl                  int64    `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchRequest) Reset()         { *m = BatchRequest{} }
+func (m *BatchRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchRequest) ProtoMessage()    {}
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{31}
+}
+
+func (m *BatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchRequest.Unmarshal(m, b)
+}
+func (m *BatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRequest.Merge(m, src)
+}
+func (m *BatchRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchRequest.Size(m)
+}
+func (m *BatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchRequest proto.InternalMessageInfo
+
+func (m *BatchRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *BatchRequest) GetPairs() []*KV {
+	if m != nil {
+		return m.Pairs
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type BatchResponse struct {
+	// results are in the same order as the request pairs.
+	Results              []*KeyResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *BatchResponse) Reset()         { *m = BatchResponse{} }
+func (m *BatchResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchResponse) ProtoMessage()    {}
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{32}
+}
+
+func (m *BatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResponse.Unmarshal(m, b)
+}
+func (m *BatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResponse.Merge(m, src)
+}
+func (m *BatchResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchResponse.Size(m)
+}
+func (m *BatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResponse proto.InternalMessageInfo
+
+func (m *BatchResponse) GetResults() []*KeyResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type KVBatchRequest struct {
+	Op        KVBatchRequest_Op `protobuf:"varint,1,opt,name=op,proto3,enum=api.KVBatchRequest_Op" json:"op,omitempty"`
+	Namespace string            `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// pairs lists the keys, values are only used by sets.
+	Pairs []*KV `protobuf:"bytes,3,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	// ttl for sets in nanoseconds, 0 uses the namespace default.
+	Ttl                  int64    `protobuf:"varint,4,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KVBatchRequest) Reset()         { *m = KVBatchRequest{} }
+func (m *KVBatchRequest) String() string { return proto.CompactTextString(m) }
+func (*KVBatchRequest) ProtoMessage()    {}
+func (*KVBatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{33}
+}
+
+func (m *KVBatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KVBatchRequest.Unmarshal(m, b)
+}
+func (m *KVBatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KVBatchRequest.Marshal(b, m, deterministic)
+}
+func (m *KVBatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KVBatchRequest.Merge(m, src)
+}
+func (m *KVBatchRequest) XXX_Size() int {
+	return xxx_messageInfo_KVBatchRequest.Size(m)
+}
+func (m *KVBatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KVBatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KVBatchRequest proto.InternalMessageInfo
+
+func (m *KVBatchRequest) GetOp() KVBatchRequest_Op {
+	if m != nil {
+		return m.Op
+	}
+	return KVBatchRequest_GET
+}
+
+func (m *KVBatchRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *KVBatchRequest) GetPairs() []*KV {
+	if m != nil {
+		return m.Pairs
+	}
+	return nil
+}
+
+func (m *KVBatchRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type KeyResult struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// error is empty on success.
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// owner is set when the key belongs to another node.
+	Owner                *Node    `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyResult) Reset()         { *m = KeyResult{} }
+func (m *KeyResult) String() string { return proto.CompactTextString(m) }
+func (*KeyResult) ProtoMessage()    {}
+func (*KeyResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{34}
+}
+
+func (m *KeyResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyResult.Unmarshal(m, b)
+}
+func (m *KeyResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyResult.Marshal(b, m, deterministic)
+}
+func (m *KeyResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyResult.Merge(m, src)
+}
+func (m *KeyResult) XXX_Size() int {
+	return xxx_messageInfo_KeyResult.Size(m)
+}
+func (m *KeyResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyResult proto.InternalMessageInfo
+
+func (m *KeyResult) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyResult) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *KeyResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *KeyResult) GetOwner() *Node {
+	if m != nil {
+		return m.Owner
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Key       string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// prefix watches every key starting with key.
+	Prefix               bool     `protobuf:"varint,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{35}
+}
+
+func (m *WatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchRequest.Unmarshal(m, b)
+}
+func (m *WatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchRequest.Merge(m, src)
+}
+func (m *WatchRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchRequest.Size(m)
+}
+func (m *WatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchRequest proto.InternalMessageInfo
+
+func (m *WatchRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetPrefix() bool {
+	if m != nil {
+		return m.Prefix
+	}
+	return false
+}
+
+type WatchEvent struct {
+	Type      WatchEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=api.WatchEvent_Type" json:"type,omitempty"`
+	Namespace string          `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Key       string          `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Value     string          `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	// owner is the node keys moved to, for MOVED events.
+	Owner                *Node    `protobuf:"bytes,5,opt,name=owner,proto3" json:"owner,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchEvent) Reset()         { *m = WatchEvent{} }
+func (m *WatchEvent) String() string { return proto.CompactTextString(m) }
+func (*WatchEvent) ProtoMessage()    {}
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{36}
+}
+
+func (m *WatchEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEvent.Unmarshal(m, b)
+}
+func (m *WatchEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEvent.Marshal(b, m, deterministic)
+}
+func (m *WatchEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEvent.Merge(m, src)
+}
+func (m *WatchEvent) XXX_Size() int {
+	return xxx_messageInfo_WatchEvent.Size(m)
+}
+func (m *WatchEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEvent proto.InternalMessageInfo
+
+func (m *WatchEvent) GetType() WatchEvent_Type {
+	if m != nil {
+		return m.Type
+	}
+	return WatchEvent_SET
+}
+
+func (m *WatchEvent) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *WatchEvent) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *WatchEvent) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *WatchEvent) GetOwner() *Node {
+	if m != nil {
+		return m.Owner
+	}
+	return nil
+}
+
+type ChangesRequest struct {
+	// from is the first sequence number to send, 0 for the oldest kept.
+	From uint64 `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	// follow keeps the stream open, sending changes as they happen.
+	Follow               bool     `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ChangesRequest) Reset()         { *m = ChangesRequest{} }
+func (m *ChangesRequest) String() string { return proto.CompactTextString(m) }
+func (*ChangesRequest) ProtoMessage()    {}
+func (*ChangesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{37}
+}
+
+func (m *ChangesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ChangesRequest.Unmarshal(m, b)
+}
+func (m *ChangesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ChangesRequest.Marshal(b, m, deterministic)
+}
+func (m *ChangesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ChangesRequest.Merge(m, src)
+}
+func (m *ChangesRequest) XXX_Size() int {
+	return xxx_messageInfo_ChangesRequest.Size(m)
+}
+func (m *ChangesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ChangesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ChangesRequest proto.InternalMessageInfo
+
+func (m *ChangesRequest) GetFrom() uint64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *ChangesRequest) GetFollow() bool {
+	if m != nil {
+		return m.Follow
+	}
+	return false
+}
+
+// Change is one mutation of a node's storage.
+type Change struct {
+	// seq numbers changes on this node from 1, without gaps.
+	Seq       uint64      `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Type      Change_Type `protobuf:"varint,2,opt,name=type,proto3,enum=api.Change_Type" json:"type,omitempty"`
+	Namespace string      `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Key       string      `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	Value     string      `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	// expires is when the pair expires in Unix nanoseconds, 0 for never.
+	Expires int64 `protobuf:"varint,6,opt,name=expires,proto3" json:"expires,omitempty"`
+	// time the change was applied in Unix nanoseconds.
+	Time                 int64    `protobuf:"varint,7,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Change) Reset()         { *m = Change{} }
+func (m *Change) String() string { return proto.CompactTextString(m) }
+func (*Change) ProtoMessage()    {}
+func (*Change) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{38}
+}
+
+func (m *Change) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Change.Unmarshal(m, b)
+}
+func (m *Change) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Change.Marshal(b, m, deterministic)
+}
+func (m *Change) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Change.Merge(m, src)
+}
+func (m *Change) XXX_Size() int {
+	return xxx_messageInfo_Change.Size(m)
+}
+func (m *Change) XXX_DiscardUnknown() {
+	xxx_messageInfo_Change.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Change proto.InternalMessageInfo
+
+func (m *Change) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *Change) GetType() Change_Type {
+	if m != nil {
+		return m.Type
+	}
+	return Change_SET
+}
+
+func (m *Change) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Change) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Change) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+func (m *Change) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+func (m *Change) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+type MerkleRequest struct {
+	From []byte `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   []byte `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	// namespaces whose keys are hashed, sorted.
+	Namespaces []string `protobuf:"bytes,3,rep,name=namespaces,proto3" json:"namespaces,omitempty"`
+	// depth of the tree, which has 2^depth leaves splitting the range
+	// evenly.
+	Depth uint32 `protobuf:"varint,4,opt,name=depth,proto3" json:"depth,omitempty"`
+	// level and indices pick the tree nodes to return, level 0 being the
+	// root.
+	Level                uint32   `protobuf:"varint,5,opt,name=level,proto3" json:"level,omitempty"`
+	Indices              []uint32 `protobuf:"varint,6,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleRequest) Reset()         { *m = MerkleRequest{} }
+func (m *MerkleRequest) String() string { return proto.CompactTextString(m) }
+func (*MerkleRequest) ProtoMessage()    {}
+func (*MerkleRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{39}
+}
+
+func (m *MerkleRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleRequest.Unmarshal(m, b)
+}
+func (m *MerkleRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleRequest.Marshal(b, m, deterministic)
+}
+func (m *MerkleRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleRequest.Merge(m, src)
+}
+func (m *MerkleRequest) XXX_Size() int {
+	return xxx_messageInfo_MerkleRequest.Size(m)
+}
+func (m *MerkleRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleRequest proto.InternalMessageInfo
+
+func (m *MerkleRequest) GetFrom() []byte {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *MerkleRequest) GetTo() []byte {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *MerkleRequest) GetNamespaces() []string {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+func (m *MerkleRequest) GetDepth() uint32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *MerkleRequest) GetLevel() uint32 {
+	if m != nil {
+		return m.Level
+	}
+	return 0
+}
+
+func (m *MerkleRequest) GetIndices() []uint32 {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
+type MerkleResponse struct {
+	// hashes are in the same order as the request indices, empty for
+	// subtrees without keys.
+	Hashes               [][]byte `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleResponse) Reset()         { *m = MerkleResponse{} }
+func (m *MerkleResponse) String() string { return proto.CompactTextString(m) }
+func (*MerkleResponse) ProtoMessage()    {}
+func (*MerkleResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{40}
+}
+
+func (m *MerkleResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleResponse.Unmarshal(m, b)
+}
+func (m *MerkleResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleResponse.Marshal(b, m, deterministic)
+}
+func (m *MerkleResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleResponse.Merge(m, src)
+}
+func (m *MerkleResponse) XXX_Size() int {
+	return xxx_messageInfo_MerkleResponse.Size(m)
+}
+func (m *MerkleResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleResponse proto.InternalMessageInfo
+
+func (m *MerkleResponse) GetHashes() [][]byte {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+type UpdateRequest struct {
+	Namespace string  `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Key       string  `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Hops      uint32  `protobuf:"varint,3,opt,name=hops,proto3" json:"hops,omitempty"`
+	Type      KV_Type `protobuf:"varint,4,opt,name=type,proto3,enum=api.KV_Type" json:"type,omitempty"`
+	// delta is added to a counter.
+	Delta int64 `protobuf:"varint,5,opt,name=delta,proto3" json:"delta,omitempty"`
+	// add and remove change the members of a set; a member in both is added.
+	Add    []string `protobuf:"bytes,6,rep,name=add,proto3" json:"add,omitempty"`
+	Remove []string `protobuf:"bytes,7,rep,name=remove,proto3" json:"remove,omitempty"`
+	// ttl in nanoseconds applies when the key is created, 0 for the
+	// namespace default.
+	Ttl                  int64    `protobuf:"varint,8,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateRequest) Reset()         { *m = UpdateRequest{} }
+func (m *UpdateRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateRequest) ProtoMessage()    {}
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{41}
+}
+
+func (m *UpdateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRequest.Unmarshal(m, b)
+}
+func (m *UpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRequest.Merge(m, src)
+}
+func (m *UpdateRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateRequest.Size(m)
+}
+func (m *UpdateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRequest proto.InternalMessageInfo
+
+func (m *UpdateRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *UpdateRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *UpdateRequest) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+func (m *UpdateRequest) GetType() KV_Type {
+	if m != nil {
+		return m.Type
+	}
+	return KV_STRING
+}
+
+func (m *UpdateRequest) GetDelta() int64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+func (m *UpdateRequest) GetAdd() []string {
+	if m != nil {
+		return m.Add
+	}
+	return nil
+}
+
+func (m *UpdateRequest) GetRemove() []string {
+	if m != nil {
+		return m.Remove
+	}
+	return nil
+}
+
+func (m *UpdateRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+type UpdateResponse struct {
+	Counter              int64    `protobuf:"varint,1,opt,name=counter,proto3" json:"counter,omitempty"`
+	Members              []string `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateResponse) Reset()         { *m = UpdateResponse{} }
+func (m *UpdateResponse) String() string { return proto.CompactTextString(m) }
+func (*UpdateResponse) ProtoMessage()    {}
+func (*UpdateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{42}
+}
+
+func (m *UpdateResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateResponse.Unmarshal(m, b)
+}
+func (m *UpdateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateResponse.Marshal(b, m, deterministic)
+}
+func (m *UpdateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateResponse.Merge(m, src)
+}
+func (m *UpdateResponse) XXX_Size() int {
+	return xxx_messageInfo_UpdateResponse.Size(m)
+}
+func (m *UpdateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateResponse proto.InternalMessageInfo
+
+func (m *UpdateResponse) GetCounter() int64 {
+	if m != nil {
+		return m.Counter
+	}
+	return 0
+}
+
+func (m *UpdateResponse) GetMembers() []string {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+// PNCounter counts increments and decrements accepted by each owner, by
+// node id in hex. Its value is their difference.
+type PNCounter struct {
+	Increments           map[string]uint64 `protobuf:"bytes,1,rep,name=increments,proto3" json:"increments,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Decrements           map[string]uint64 `protobuf:"bytes,2,rep,name=decrements,proto3" json:"decrements,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *PNCounter) Reset()         { *m = PNCounter{} }
+func (m *PNCounter) String() string { return proto.CompactTextString(m) }
+func (*PNCounter) ProtoMessage()    {}
+func (*PNCounter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{43}
+}
+
+func (m *PNCounter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PNCounter.Unmarshal(m, b)
+}
+func (m *PNCounter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PNCounter.Marshal(b, m, deterministic)
+}
+func (m *PNCounter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PNCounter.Merge(m, src)
+}
+func (m *PNCounter) XXX_Size() int {
+	return xxx_messageInfo_PNCounter.Size(m)
+}
+func (m *PNCounter) XXX_DiscardUnknown() {
+	xxx_messageInfo_PNCounter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PNCounter proto.InternalMessageInfo
+
+func (m *PNCounter) GetIncrements() map[string]uint64 {
+	if m != nil {
+		return m.Increments
+	}
+	return nil
+}
+
+func (m *PNCounter) GetDecrements() map[string]uint64 {
+	if m != nil {
+		return m.Decrements
+	}
+	return nil
+}
+
+// ORSet holds, for each member, the tags of the adds not removed since,
+// and the tags removed. A member is in the set while it has a tag left.
+// Removed tags are never purged: a copy that missed a remove would
+// otherwise bring the member back. A set keeps growing with every member
+// removed, and so does each copy sent to replicas.
+type ORSet struct {
+	Adds                 map[string]*ORSetTags `protobuf:"bytes,1,rep,name=adds,proto3" json:"adds,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Removed              []string              `protobuf:"bytes,2,rep,name=removed,proto3" json:"removed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *ORSet) Reset()         { *m = ORSet{} }
+func (m *ORSet) String() string { return proto.CompactTextString(m) }
+func (*ORSet) ProtoMessage()    {}
+func (*ORSet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{44}
+}
+
+func (m *ORSet) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ORSet.Unmarshal(m, b)
+}
+func (m *ORSet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ORSet.Marshal(b, m, deterministic)
+}
+func (m *ORSet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ORSet.Merge(m, src)
+}
+func (m *ORSet) XXX_Size() int {
+	return xxx_messageInfo_ORSet.Size(m)
+}
+func (m *ORSet) XXX_DiscardUnknown() {
+	xxx_messageInfo_ORSet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ORSet proto.InternalMessageInfo
+
+func (m *ORSet) GetAdds() map[string]*ORSetTags {
+	if m != nil {
+		return m.Adds
+	}
+	return nil
+}
+
+func (m *ORSet) GetRemoved() []string {
+	if m != nil {
+		return m.Removed
+	}
+	return nil
+}
+
+type ORSetTags struct {
+	Tags                 []string `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ORSetTags) Reset()         { *m = ORSetTags{} }
+func (m *ORSetTags) String() string { return proto.CompactTextString(m) }
+func (*ORSetTags) ProtoMessage()    {}
+func (*ORSetTags) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{45}
+}
+
+func (m *ORSetTags) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ORSetTags.Unmarshal(m, b)
+}
+func (m *ORSetTags) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ORSetTags.Marshal(b, m, deterministic)
+}
+func (m *ORSetTags) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ORSetTags.Merge(m, src)
+}
+func (m *ORSetTags) XXX_Size() int {
+	return xxx_messageInfo_ORSetTags.Size(m)
+}
+func (m *ORSetTags) XXX_DiscardUnknown() {
+	xxx_messageInfo_ORSetTags.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ORSetTags proto.InternalMessageInfo
+
+func (m *ORSetTags) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+type TxnRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// writes are applied on commit; Deleted marks a delete.
+	Writes []*KV `protobuf:"bytes,2,rep,name=writes,proto3" json:"writes,omitempty"`
+	// reads hold the version each key had when the transaction read it, 0
+	// if it was absent.
+	Reads                []*KV    `protobuf:"bytes,3,rep,name=reads,proto3" json:"reads,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TxnRequest) Reset()         { *m = TxnRequest{} }
+func (m *TxnRequest) String() string { return proto.CompactTextString(m) }
+func (*TxnRequest) ProtoMessage()    {}
+func (*TxnRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{46}
+}
+
+func (m *TxnRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TxnRequest.Unmarshal(m, b)
+}
+func (m *TxnRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TxnRequest.Marshal(b, m, deterministic)
+}
+func (m *TxnRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TxnRequest.Merge(m, src)
+}
+func (m *TxnRequest) XXX_Size() int {
+	return xxx_messageInfo_TxnRequest.Size(m)
+}
+func (m *TxnRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TxnRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TxnRequest proto.InternalMessageInfo
+
+func (m *TxnRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *TxnRequest) GetWrites() []*KV {
+	if m != nil {
+		return m.Writes
+	}
+	return nil
+}
+
+func (m *TxnRequest) GetReads() []*KV {
+	if m != nil {
+		return m.Reads
+	}
+	return nil
+}
+
+type TxnRecord struct {
+	Id                   string          `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	State                TxnRecord_State `protobuf:"varint,2,opt,name=state,proto3,enum=api.TxnRecord_State" json:"state,omitempty"`
+	Participants         []*Node         `protobuf:"bytes,3,rep,name=participants,proto3" json:"participants,omitempty"`
+	Hops                 uint32          `protobuf:"varint,4,opt,name=hops,proto3" json:"hops,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *TxnRecord) Reset()         { *m = TxnRecord{} }
+func (m *TxnRecord) String() string { return proto.CompactTextString(m) }
+func (*TxnRecord) ProtoMessage()    {}
+func (*TxnRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{47}
+}
+
+func (m *TxnRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TxnRecord.Unmarshal(m, b)
+}
+func (m *TxnRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TxnRecord.Marshal(b, m, deterministic)
+}
+func (m *TxnRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TxnRecord.Merge(m, src)
+}
+func (m *TxnRecord) XXX_Size() int {
+	return xxx_messageInfo_TxnRecord.Size(m)
+}
+func (m *TxnRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_TxnRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TxnRecord proto.InternalMessageInfo
+
+func (m *TxnRecord) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *TxnRecord) GetState() TxnRecord_State {
+	if m != nil {
+		return m.State
+	}
+	return TxnRecord_PENDING
+}
+
+func (m *TxnRecord) GetParticipants() []*Node {
+	if m != nil {
+		return m.Participants
+	}
+	return nil
+}
+
+func (m *TxnRecord) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+type LeaseRequest struct {
+	Name string          `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Op   LeaseRequest_Op `protobuf:"varint,2,opt,name=op,proto3,enum=api.LeaseRequest_Op" json:"op,omitempty"`
+	// token identifies the grant renewed or released.
+	Token int64 `protobuf:"varint,3,opt,name=token,proto3" json:"token,omitempty"`
+	// ttl in nanoseconds.
+	Ttl                  int64    `protobuf:"varint,4,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	Hops                 uint32   `protobuf:"varint,5,opt,name=hops,proto3" json:"hops,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeaseRequest) Reset()         { *m = LeaseRequest{} }
+func (m *LeaseRequest) String() string { return proto.CompactTextString(m) }
+func (*LeaseRequest) ProtoMessage()    {}
+func (*LeaseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{48}
+}
+
+func (m *LeaseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeaseRequest.Unmarshal(m, b)
+}
+func (m *LeaseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeaseRequest.Marshal(b, m, deterministic)
+}
+func (m *LeaseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeaseRequest.Merge(m, src)
+}
+func (m *LeaseRequest) XXX_Size() int {
+	return xxx_messageInfo_LeaseRequest.Size(m)
+}
+func (m *LeaseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeaseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeaseRequest proto.InternalMessageInfo
+
+func (m *LeaseRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *LeaseRequest) GetOp() LeaseRequest_Op {
+	if m != nil {
+		return m.Op
+	}
+	return LeaseRequest_ACQUIRE
+}
+
+func (m *LeaseRequest) GetToken() int64 {
+	if m != nil {
+		return m.Token
+	}
+	return 0
+}
+
+func (m *LeaseRequest) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+func (m *LeaseRequest) GetHops() uint32 {
+	if m != nil {
+		return m.Hops
+	}
+	return 0
+}
+
+// Lease is a grant of a lease, and the record of the last grant kept on the
+// owner of its name.
+type Lease struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// token is the fencing token of the grant, larger than any given out
+	// before for the name.
+	Token int64 `protobuf:"varint,2,opt,name=token,proto3" json:"token,omitempty"`
+	// expires in unix nanoseconds by the owner's clock, 0 once released.
+	Expires              int64    `protobuf:"varint,3,opt,name=expires,proto3" json:"expires,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Lease) Reset()         { *m = Lease{} }
+func (m *Lease) String() string { return proto.CompactTextString(m) }
+func (*Lease) ProtoMessage()    {}
+func (*Lease) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{49}
+}
+
+func (m *Lease) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Lease.Unmarshal(m, b)
+}
+func (m *Lease) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Lease.Marshal(b, m, deterministic)
+}
+func (m *Lease) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Lease.Merge(m, src)
+}
+func (m *Lease) XXX_Size() int {
+	return xxx_messageInfo_Lease.Size(m)
+}
+func (m *Lease) XXX_DiscardUnknown() {
+	xxx_messageInfo_Lease.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Lease proto.InternalMessageInfo
+
+func (m *Lease) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Lease) GetToken() int64 {
+	if m != nil {
+		return m.Token
+	}
+	return 0
+}
+
+func (m *Lease) GetExpires() int64 {
+	if m != nil {
+		return m.Expires
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("api.KV_Type", KV_Type_name, KV_Type_value)
+	proto.RegisterEnum("api.KVBatchRequest_Op", KVBatchRequest_Op_name, KVBatchRequest_Op_value)
+	proto.RegisterEnum("api.WatchEvent_Type", WatchEvent_Type_name, WatchEvent_Type_value)
+	proto.RegisterEnum("api.Change_Type", Change_Type_name, Change_Type_value)
+	proto.RegisterEnum("api.TxnRecord_State", TxnRecord_State_name, TxnRecord_State_value)
+	proto.RegisterEnum("api.LeaseRequest_Op", LeaseRequest_Op_name, LeaseRequest_Op_value)
+	proto.RegisterType((*Node)(nil), "api.Node")
+	proto.RegisterType((*ER)(nil), "api.ER")
+	proto.RegisterType((*ID)(nil), "api.ID")
+	proto.RegisterType((*FindSuccessorResponse)(nil), "api.FindSuccessorResponse")
+	proto.RegisterType((*Hop)(nil), "api.Hop")
+	proto.RegisterType((*NeighbourRequest)(nil), "api.NeighbourRequest")
+	proto.RegisterType((*ProbeResponse)(nil), "api.ProbeResponse")
+	proto.RegisterType((*ClosestPrecedingRequest)(nil), "api.ClosestPrecedingRequest")
+	proto.RegisterType((*ClosestPrecedingResponse)(nil), "api.ClosestPrecedingResponse")
+	proto.RegisterType((*GetRequest)(nil), "api.GetRequest")
+	proto.RegisterType((*GetResponse)(nil), "api.GetResponse")
+	proto.RegisterType((*SetRequest)(nil), "api.SetRequest")
+	proto.RegisterType((*SetResponse)(nil), "api.SetResponse")
+	proto.RegisterType((*DeleteRequest)(nil), "api.DeleteRequest")
+	proto.RegisterType((*DeleteResponse)(nil), "api.DeleteResponse")
+	proto.RegisterType((*MultiDeleteRequest)(nil), "api.MultiDeleteRequest")
+	proto.RegisterType((*RequestKeysRequest)(nil), "api.RequestKeysRequest")
+	proto.RegisterType((*KV)(nil), "api.KV")
+	proto.RegisterType((*RequestKeysResponse)(nil), "api.RequestKeysResponse")
+	proto.RegisterType((*StoreKeysRequest)(nil), "api.StoreKeysRequest")
+	proto.RegisterType((*Namespace)(nil), "api.Namespace")
+	proto.RegisterType((*VectorClock)(nil), "api.VectorClock")
+	proto.RegisterMapType((map[string]uint64)(nil), "api.VectorClock.CountersEntry")
+	proto.RegisterType((*Sibling)(nil), "api.Sibling")
+	proto.RegisterType((*SiblingSet)(nil), "api.SiblingSet")
+	proto.RegisterType((*Hint)(nil), "api.Hint")
+	proto.RegisterType((*HintLog)(nil), "api.HintLog")
+	proto.RegisterType((*NamespaceRequest)(nil), "api.NamespaceRequest")
+	proto.RegisterType((*NamespaceList)(nil), "api.NamespaceList")
+	proto.RegisterType((*ScanRequest)(nil), "api.ScanRequest")
+	proto.RegisterType((*KVScanRequest)(nil), "api.KVScanRequest")
+	proto.RegisterType((*KVScanResponse)(nil), "api.KVScanResponse")
+	proto.RegisterType((*BatchRequest)(nil), "api.BatchRequest")
+	proto.RegisterType((*BatchResponse)(nil), "api.BatchResponse")
+	proto.RegisterType((*KVBatchRequest)(nil), "api.KVBatchRequest")
+	proto.RegisterType((*KeyResult)(nil), "api.KeyResult")
+	proto.RegisterType((*WatchRequest)(nil), "api.WatchRequest")
+	proto.RegisterType((*WatchEvent)(nil), "api.WatchEvent")
+	proto.RegisterType((*ChangesRequest)(nil), "api.ChangesRequest")
+	proto.RegisterType((*Change)(nil), "api.Change")
+	proto.RegisterType((*MerkleRequest)(nil), "api.MerkleRequest")
+	proto.RegisterType((*MerkleResponse)(nil), "api.MerkleResponse")
+	proto.RegisterType((*UpdateRequest)(nil), "api.UpdateRequest")
+	proto.RegisterType((*UpdateResponse)(nil), "api.UpdateResponse")
+	proto.RegisterType((*PNCounter)(nil), "api.PNCounter")
+	proto.RegisterMapType((map[string]uint64)(nil), "api.PNCounter.DecrementsEntry")
+	proto.RegisterMapType((map[string]uint64)(nil), "api.PNCounter.IncrementsEntry")
+	proto.RegisterType((*ORSet)(nil), "api.ORSet")
+	proto.RegisterMapType((map[string]*ORSetTags)(nil), "api.ORSet.AddsEntry")
+	proto.RegisterType((*ORSetTags)(nil), "api.ORSetTags")
+	proto.RegisterType((*TxnRequest)(nil), "api.TxnRequest")
+	proto.RegisterType((*TxnRecord)(nil), "api.TxnRecord")
+	proto.RegisterType((*LeaseRequest)(nil), "api.LeaseRequest")
+	proto.RegisterType((*Lease)(nil), "api.Lease")
+}
+
+func init() {
+	proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c)
+}
+
+var fileDescriptor_00212fb1f9d3bf1c = []byte{
+	// 2489 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x19, 0x4d, 0x73, 0x23, 0x47,
+	0x75, 0xe7, 0x4b, 0xd2, 0x3c, 0x49, 0xb6, 0xd2, 0x76, 0x36, 0x42, 0xf9, 0x58, 0x33, 0x65, 0x36,
+	0xda, 0x40, 0x84, 0x31, 0x90, 0x0a, 0x21, 0x40, 0x39, 0x96, 0xd6, 0x71, 0xbc, 0x2b, 0x9b, 0x96,
+	0xd6, 0x51, 0x51, 0x50, 0x5b, 0xe3, 0x99, 0xb6, 0x3d, 0xf1, 0x68, 0x66, 0x32, 0xd3, 0x76, 0x2c,
+	0x4e, 0x1c, 0x28, 0x28, 0x8a, 0x2b, 0x45, 0x55, 0x2e, 0x5c, 0x38, 0x72, 0xe0, 0x0f, 0x70, 0xa3,
+	0x28, 0x7e, 0x06, 0x7f, 0x85, 0xea, 0x8f, 0xf9, 0x92, 0xe4, 0x8f, 0xa5, 0xb8, 0xcd, 0xeb, 0x7e,
+	0xdf, 0xef, 0xf5, 0x7b, 0xaf, 0x7b, 0xc0, 0xb4, 0x23, 0xaf, 0x17, 0xc5, 0x21, 0x0d, 0x91, 0x66,
+	0x47, 0x9e, 0xf5, 0x1e, 0xe8, 0xc3, 0xd0, 0x25, 0x68, 0x05, 0x54, 0xcf, 0x6d, 0x2b, 0x1b, 0x4a,
+	0xb7, 0x81, 0x55, 0xcf, 0x45, 0x08, 0x74, 0xdb, 0x75, 0xe3, 0xb6, 0xba, 0xa1, 0x74, 0x4d, 0xcc,
+	0xbf, 0x2d, 0x1d, 0xd4, 0x01, 0xb6, 0xf6, 0x40, 0xdd, 0xef, 0x2f, 0xc3, 0x3f, 0x0f, 0xa3, 0x84,
+	0xe3, 0x37, 0x31, 0xff, 0x46, 0x6f, 0x83, 0x1e, 0xd9, 0xf4, 0xbc, 0xad, 0x6d, 0x68, 0xdd, 0xfa,
+	0xb6, 0xd9, 0x63, 0xa2, 0x99, 0x30, 0xcc, 0x97, 0xad, 0x5f, 0xc0, 0xeb, 0x4f, 0xbd, 0xc0, 0x1d,
+	0x5d, 0x3a, 0x0e, 0x49, 0x92, 0x30, 0xc6, 0x24, 0x89, 0xc2, 0x20, 0x21, 0x8c, 0x2e, 0x08, 0x5d,
+	0xc2, 0xb9, 0x97, 0xe9, 0xd8, 0x32, 0x7a, 0x4b, 0x8a, 0x12, 0x6c, 0x6b, 0x7c, 0xfb, 0xd3, 0x30,
+	0x12, 0x42, 0x3f, 0xd3, 0x6b, 0x6a, 0x4b, 0xb3, 0x8e, 0x41, 0xfb, 0x34, 0x8c, 0xee, 0xe2, 0xf4,
+	0x10, 0x2a, 0xa7, 0x5e, 0x70, 0x46, 0x84, 0x99, 0x06, 0x96, 0x10, 0x6a, 0x43, 0xd5, 0xb7, 0x29,
+	0x09, 0x9c, 0x59, 0x5b, 0xdb, 0x50, 0xba, 0x1a, 0x4e, 0x41, 0x6b, 0x0c, 0xad, 0x21, 0xf1, 0xce,
+	0xce, 0x4f, 0xc2, 0xcb, 0x18, 0x93, 0x2f, 0x2f, 0x49, 0x42, 0xef, 0x12, 0xf2, 0x4d, 0xa8, 0x38,
+	0xb6, 0xef, 0x4b, 0x21, 0x25, 0x04, 0xb9, 0x61, 0xfd, 0x45, 0x81, 0xe6, 0x51, 0x1c, 0x9e, 0x90,
+	0xfb, 0xba, 0xe0, 0xdb, 0x50, 0x8f, 0x62, 0xe2, 0x12, 0xe1, 0xb8, 0x45, 0xc6, 0xc5, 0x5d, 0xf4,
+	0x2e, 0x98, 0x49, 0xea, 0xe3, 0xb6, 0x36, 0x8f, 0x9a, 0xef, 0x71, 0xb3, 0x89, 0x7d, 0xe5, 0x05,
+	0x67, 0x6d, 0x7d, 0x43, 0xe9, 0xd6, 0x70, 0x0a, 0x5a, 0xbb, 0xf0, 0xc6, 0xae, 0x1f, 0x26, 0x24,
+	0xa1, 0x47, 0x31, 0x71, 0x88, 0xeb, 0x05, 0x67, 0xa9, 0xf5, 0xf3, 0x89, 0xd0, 0x86, 0x2a, 0xb9,
+	0x76, 0xfc, 0x4b, 0x97, 0xb4, 0xd5, 0x0d, 0xad, 0xdb, 0xc0, 0x29, 0x68, 0xfd, 0x1a, 0xda, 0x8b,
+	0x4c, 0xee, 0x67, 0x6f, 0xc9, 0x04, 0xf5, 0x16, 0x13, 0xf2, 0x88, 0x6a, 0xc5, 0x88, 0x5a, 0x5f,
+	0x00, 0xec, 0x11, 0x9a, 0xea, 0xdc, 0x02, 0xed, 0x82, 0xcc, 0xb8, 0x30, 0x13, 0xb3, 0xcf, 0xa5,
+	0xe9, 0xfb, 0x16, 0x98, 0x81, 0x3d, 0x25, 0x49, 0x64, 0x3b, 0x84, 0xb3, 0x33, 0x71, 0xbe, 0xc0,
+	0xec, 0x8c, 0x49, 0xe4, 0x7b, 0x8e, 0x9d, 0x3a, 0x4b, 0x82, 0xd6, 0x7f, 0x14, 0xa8, 0x73, 0x61,
+	0xd2, 0xb6, 0x75, 0x30, 0xae, 0x6c, 0xff, 0x92, 0x48, 0x27, 0x09, 0x80, 0xd1, 0x5f, 0x91, 0x38,
+	0xf1, 0xc2, 0x80, 0x0b, 0xd5, 0x70, 0x0a, 0x0a, 0x0f, 0x46, 0x5e, 0x4c, 0x92, 0x34, 0xfb, 0x24,
+	0xc8, 0x76, 0x5c, 0xe2, 0x13, 0x4a, 0xdc, 0x54, 0xa6, 0x04, 0xd1, 0x06, 0xe8, 0x74, 0x16, 0x91,
+	0x76, 0x75, 0x43, 0xe9, 0xae, 0x6c, 0x37, 0xb8, 0x6f, 0x0e, 0x8e, 0x7b, 0xe3, 0x59, 0x44, 0x30,
+	0xdf, 0x41, 0x1d, 0xa8, 0x25, 0xde, 0x89, 0xef, 0x05, 0x67, 0x49, 0xdb, 0xd8, 0xd0, 0xba, 0x26,
+	0xce, 0x60, 0xc6, 0xd7, 0x09, 0x03, 0x4a, 0xae, 0x69, 0xbb, 0xc2, 0x75, 0x4c, 0x41, 0xa6, 0xfb,
+	0xa9, 0x6f, 0x9f, 0x25, 0xed, 0x1a, 0x77, 0x8c, 0x00, 0xac, 0xdf, 0xa8, 0x00, 0xa3, 0xdb, 0xdc,
+	0x99, 0x99, 0x2c, 0xca, 0x87, 0x34, 0x19, 0x65, 0x07, 0xf7, 0x06, 0x27, 0xeb, 0xf3, 0x4e, 0x6e,
+	0x81, 0x46, 0xa9, 0xdf, 0x36, 0xb8, 0x1b, 0xd8, 0x67, 0xd1, 0x6d, 0x95, 0x05, 0xb7, 0xa5, 0x46,
+	0x54, 0xef, 0x61, 0x04, 0xda, 0x80, 0xba, 0x13, 0x06, 0xae, 0x47, 0xbd, 0x30, 0xb0, 0xfd, 0xb6,
+	0xc9, 0x1d, 0x5a, 0x5c, 0x62, 0x2e, 0x23, 0xd7, 0x11, 0x71, 0x98, 0xbf, 0x81, 0x0b, 0xcb, 0x60,
+	0xab, 0x09, 0xf5, 0x51, 0x1e, 0x63, 0x6b, 0x04, 0xcd, 0x3e, 0x0f, 0xc5, 0xff, 0x31, 0xc5, 0xac,
+	0x16, 0xac, 0xa4, 0x4c, 0xa5, 0x98, 0xa7, 0x80, 0x9e, 0x5f, 0xfa, 0xd4, 0x2b, 0xcb, 0x42, 0xa0,
+	0x5f, 0x90, 0x59, 0xd2, 0x56, 0x78, 0x58, 0xf9, 0x77, 0x99, 0xb3, 0x3a, 0xcf, 0xf9, 0x43, 0x40,
+	0x92, 0xf8, 0x80, 0xcc, 0x92, 0x02, 0x9f, 0xd3, 0x38, 0x9c, 0xca, 0x3c, 0xe5, 0xdf, 0xec, 0x78,
+	0xd3, 0x90, 0x33, 0x68, 0x60, 0x95, 0x86, 0xd6, 0x9f, 0x55, 0x50, 0x0f, 0x8e, 0xef, 0x1d, 0xf2,
+	0x3b, 0xcf, 0x50, 0x9a, 0xe9, 0xfa, 0x42, 0xa6, 0xa7, 0x61, 0x36, 0x16, 0xc2, 0x9c, 0x9e, 0x81,
+	0x4a, 0xf9, 0x0c, 0x14, 0x33, 0xbc, 0x3a, 0x97, 0xe1, 0xe9, 0xf9, 0xa8, 0xdd, 0x78, 0x3e, 0xb2,
+	0x24, 0x31, 0x8b, 0x99, 0xde, 0x05, 0x9d, 0xe1, 0x20, 0x80, 0xca, 0x68, 0x8c, 0xf7, 0x87, 0x7b,
+	0xad, 0x07, 0xa8, 0x0e, 0xd5, 0xdd, 0xc3, 0x17, 0xc3, 0xf1, 0x00, 0xb7, 0x14, 0x54, 0x05, 0x6d,
+	0x34, 0x18, 0xb7, 0x54, 0xeb, 0x03, 0x58, 0x2b, 0xb9, 0x54, 0x1e, 0xfe, 0x47, 0x50, 0xe1, 0x9e,
+	0x10, 0xd1, 0xa9, 0x6f, 0x57, 0xa5, 0x68, 0x2c, 0x97, 0xad, 0x43, 0x68, 0x8d, 0x68, 0x18, 0x93,
+	0x62, 0x20, 0xee, 0x22, 0x62, 0xa6, 0xd2, 0xd8, 0x0e, 0x92, 0x53, 0xd9, 0x55, 0x6a, 0x38, 0x83,
+	0xad, 0x7f, 0x2a, 0x60, 0x0e, 0x33, 0x17, 0x23, 0xd0, 0x99, 0xbf, 0x65, 0xa4, 0xf8, 0x37, 0x7a,
+	0x1f, 0x90, 0xac, 0x55, 0x2c, 0xd1, 0x5f, 0x9e, 0xda, 0x0e, 0x95, 0x65, 0xb5, 0x89, 0x5f, 0x2b,
+	0xec, 0x3c, 0xe5, 0x1b, 0xe8, 0x11, 0xd4, 0x5d, 0x72, 0x6a, 0x5f, 0xfa, 0xf4, 0x25, 0x3b, 0x8c,
+	0xa2, 0x26, 0x81, 0x5c, 0x1a, 0x53, 0x1f, 0x7d, 0x03, 0x6a, 0x53, 0xfb, 0xfa, 0x25, 0xcf, 0x41,
+	0x16, 0x47, 0x1d, 0x57, 0xa7, 0xf6, 0x35, 0x33, 0x08, 0xbd, 0x09, 0x26, 0xdb, 0x3a, 0x99, 0x51,
+	0x92, 0xf0, 0x48, 0xea, 0x98, 0xe1, 0x7e, 0x32, 0xa3, 0xc2, 0x8a, 0x2c, 0x60, 0x22, 0x96, 0x19,
+	0x6c, 0xfd, 0x4e, 0x81, 0xfa, 0x31, 0x61, 0xf2, 0x77, 0xfd, 0xd0, 0xb9, 0x40, 0x1f, 0x41, 0xcd,
+	0x09, 0x2f, 0x03, 0x4a, 0xe2, 0xd4, 0x29, 0xef, 0x70, 0xa7, 0x14, 0x70, 0x7a, 0xbb, 0x12, 0x61,
+	0x10, 0xd0, 0x78, 0x86, 0x33, 0xfc, 0xce, 0x8f, 0xa1, 0x59, 0xda, 0xba, 0x2b, 0x7b, 0x75, 0x99,
+	0xbd, 0x1f, 0xa9, 0x1f, 0x2a, 0xd6, 0x1e, 0x54, 0x47, 0x42, 0xa9, 0x72, 0x21, 0xcf, 0x52, 0xfc,
+	0x31, 0x18, 0x0e, 0x13, 0x2f, 0xfb, 0x52, 0x6b, 0x5e, 0x2d, 0x2c, 0xb6, 0xad, 0x0f, 0x00, 0x24,
+	0xa3, 0x11, 0xa1, 0xa8, 0x5b, 0xb0, 0x5d, 0xd8, 0x23, 0x92, 0x52, 0xa2, 0x14, 0x3c, 0xf1, 0x5b,
+	0x05, 0xf4, 0x4f, 0xbd, 0x80, 0x65, 0x85, 0x11, 0x7e, 0x15, 0x90, 0x78, 0xb1, 0x49, 0x8a, 0x75,
+	0xf4, 0x84, 0xb5, 0x24, 0x9e, 0x41, 0x52, 0x97, 0x55, 0xc1, 0x32, 0xab, 0xd4, 0x38, 0xdd, 0xbf,
+	0xa5, 0xc7, 0x3c, 0x84, 0x4a, 0xc2, 0xf2, 0xd1, 0x95, 0x47, 0x52, 0x42, 0xd6, 0x7b, 0x50, 0x65,
+	0x5a, 0x3c, 0x0b, 0xcf, 0x98, 0x22, 0xe7, 0x5e, 0x40, 0x53, 0xc5, 0x85, 0x22, 0x6c, 0x13, 0x8b,
+	0x75, 0xeb, 0x31, 0xb4, 0xb2, 0x0c, 0x2c, 0x14, 0x97, 0xf9, 0x44, 0xb4, 0x7e, 0x06, 0xcd, 0x0c,
+	0xef, 0x99, 0x97, 0x50, 0xd4, 0x03, 0xc8, 0xaa, 0x43, 0xca, 0x7e, 0x45, 0xd8, 0x99, 0xf1, 0x2b,
+	0x60, 0x58, 0xbf, 0x57, 0xa0, 0x3e, 0x72, 0xec, 0x20, 0x15, 0x52, 0x2a, 0x37, 0xca, 0x7c, 0xb9,
+	0x59, 0x07, 0xc3, 0x3e, 0xa5, 0xf2, 0xc8, 0x34, 0xb0, 0x00, 0x98, 0xc1, 0x51, 0x4c, 0x4e, 0xbd,
+	0x6b, 0x59, 0x9f, 0x24, 0xc4, 0xd6, 0xe5, 0x21, 0x14, 0xbd, 0x56, 0x42, 0x8c, 0x8b, 0xef, 0x4d,
+	0x3d, 0xca, 0xd3, 0xb9, 0x89, 0x05, 0x60, 0xfd, 0x51, 0x81, 0xe6, 0xc1, 0xf1, 0xfd, 0x75, 0xc9,
+	0xa5, 0xaa, 0x37, 0x48, 0xd5, 0x96, 0x4b, 0xd5, 0x0b, 0x52, 0x19, 0xb6, 0x73, 0x19, 0xb3, 0xa1,
+	0xc8, 0x10, 0x5c, 0x04, 0x64, 0x0d, 0x60, 0x25, 0x55, 0x46, 0xd6, 0xa1, 0x37, 0x0b, 0x3d, 0xa2,
+	0x50, 0x50, 0xf8, 0x62, 0x81, 0x8d, 0x5a, 0x62, 0xf3, 0x2b, 0x68, 0x7c, 0x62, 0x53, 0xe7, 0xfc,
+	0x7e, 0x26, 0xbd, 0x0d, 0x46, 0x64, 0x7b, 0x71, 0xd2, 0x56, 0xcb, 0x32, 0xc4, 0x6a, 0xda, 0xcb,
+	0xb5, 0xac, 0x97, 0x5b, 0x3f, 0x82, 0xa6, 0x64, 0x2f, 0x95, 0xec, 0xb2, 0x04, 0x4e, 0x2e, 0x7d,
+	0x5a, 0x8e, 0xfd, 0x01, 0x99, 0x61, 0xbe, 0x8c, 0xd3, 0x6d, 0xeb, 0x6f, 0x0a, 0xb3, 0xb0, 0xa4,
+	0xdc, 0x63, 0x50, 0xc3, 0x88, 0x6b, 0xb5, 0xb2, 0xfd, 0x50, 0xca, 0x2e, 0x22, 0xf4, 0x0e, 0x23,
+	0xac, 0x86, 0xd1, 0xed, 0x9d, 0x31, 0x37, 0x42, 0xbb, 0xcd, 0x08, 0x3d, 0x37, 0xc2, 0x02, 0xf5,
+	0x30, 0x62, 0x6d, 0x60, 0x6f, 0x30, 0x6e, 0x3d, 0x48, 0xfb, 0x81, 0xc2, 0x3a, 0x46, 0x7f, 0xf0,
+	0x6c, 0x30, 0x1e, 0xb4, 0x54, 0xeb, 0x0b, 0x30, 0x33, 0x1b, 0xee, 0x2a, 0x3e, 0xd9, 0x80, 0xb8,
+	0x0e, 0x06, 0x89, 0x63, 0x39, 0xb2, 0x9b, 0x58, 0x00, 0x79, 0x11, 0xd0, 0x97, 0x17, 0x01, 0xeb,
+	0x18, 0x1a, 0x9f, 0xdf, 0x3f, 0x66, 0x52, 0x19, 0x35, 0x57, 0xa6, 0x7c, 0x1c, 0x6a, 0x69, 0x62,
+	0x5a, 0xff, 0x52, 0x00, 0x38, 0xe3, 0xc1, 0x15, 0x09, 0x58, 0xfd, 0x12, 0x0d, 0x55, 0xf8, 0x7b,
+	0x9d, 0xab, 0x91, 0x6f, 0x17, 0x1b, 0xeb, 0xed, 0xfe, 0x96, 0x0a, 0x68, 0x4b, 0xbc, 0xa1, 0x17,
+	0xab, 0x6c, 0x66, 0xb7, 0x71, 0x83, 0xdd, 0x8f, 0x65, 0xa7, 0x96, 0x01, 0x78, 0x50, 0x08, 0x80,
+	0x82, 0x4c, 0x30, 0x9e, 0x1f, 0x1e, 0x0f, 0xfa, 0x2d, 0xd5, 0xfa, 0x18, 0x56, 0x76, 0xcf, 0xed,
+	0xe0, 0x8c, 0x2c, 0x1d, 0x7b, 0x74, 0x39, 0xf6, 0xb0, 0x7b, 0x44, 0xe8, 0xfb, 0xe1, 0x57, 0xb2,
+	0xbd, 0x4a, 0x88, 0x4d, 0xbe, 0x15, 0x41, 0xce, 0x34, 0x4f, 0xc8, 0x97, 0x92, 0x8a, 0x7d, 0xa2,
+	0x4d, 0xe9, 0x13, 0x95, 0xfb, 0x44, 0x34, 0x02, 0x81, 0x7c, 0xa3, 0x3f, 0xb4, 0x1b, 0xfc, 0xa1,
+	0x2f, 0xf1, 0x87, 0x51, 0xf4, 0x47, 0xa1, 0x80, 0x57, 0xca, 0x05, 0x1c, 0x81, 0x4e, 0xbd, 0xa9,
+	0xb8, 0x0a, 0x68, 0x98, 0x7f, 0x5b, 0x9f, 0xdd, 0xe6, 0x9c, 0x55, 0xa8, 0x8f, 0xf1, 0xce, 0x70,
+	0xf4, 0x74, 0x80, 0x5f, 0xee, 0x0f, 0x5b, 0x2a, 0x6a, 0x41, 0x23, 0x5b, 0x38, 0x7c, 0x31, 0x6e,
+	0x69, 0x0c, 0x7d, 0x30, 0x39, 0xda, 0xc7, 0x83, 0x96, 0x6e, 0x7d, 0xad, 0x40, 0xf3, 0x39, 0x89,
+	0x2f, 0x7c, 0xf2, 0x0a, 0x73, 0x23, 0x7a, 0xa7, 0x54, 0xd9, 0x35, 0x3e, 0x9e, 0x15, 0x56, 0x98,
+	0x95, 0x2e, 0x89, 0xe8, 0x79, 0x5a, 0xdf, 0x38, 0xc0, 0x56, 0x7d, 0x72, 0x45, 0xfc, 0xac, 0xd6,
+	0x32, 0x80, 0xd9, 0xee, 0x05, 0xae, 0xe7, 0x70, 0xdb, 0xb5, 0x6e, 0x13, 0xa7, 0xa0, 0xd5, 0x85,
+	0x95, 0x54, 0x35, 0x59, 0x52, 0x1e, 0x42, 0xe5, 0xdc, 0x4e, 0xce, 0x65, 0x37, 0x69, 0x60, 0x09,
+	0x59, 0xff, 0x56, 0xa0, 0xf9, 0x22, 0x72, 0xed, 0x7c, 0x8a, 0x7e, 0xd5, 0x83, 0xb2, 0xec, 0x36,
+	0x93, 0x8e, 0x99, 0xfa, 0x6d, 0x63, 0xa6, 0x4b, 0x7c, 0x6a, 0xcb, 0xb1, 0x56, 0x00, 0x8c, 0xbb,
+	0xed, 0xba, 0xdc, 0x1a, 0x13, 0xb3, 0x4f, 0xa6, 0x77, 0x4c, 0xa6, 0xe1, 0x15, 0x91, 0xa3, 0xac,
+	0x84, 0xd2, 0x02, 0x54, 0xcb, 0x0b, 0x50, 0x1f, 0x56, 0x52, 0x43, 0xa4, 0xcd, 0xfc, 0x26, 0xc4,
+	0xe7, 0x1d, 0x6e, 0x87, 0x86, 0x53, 0x90, 0xed, 0x4c, 0xc9, 0xf4, 0x84, 0xc8, 0x22, 0x6d, 0xe2,
+	0x14, 0xb4, 0xfe, 0xa0, 0x82, 0x79, 0x34, 0x94, 0x63, 0x12, 0xfa, 0x29, 0x80, 0x17, 0x38, 0x31,
+	0x99, 0x92, 0x80, 0x96, 0xe7, 0xad, 0x0c, 0xa7, 0xb7, 0x9f, 0x21, 0x88, 0x79, 0xab, 0x40, 0xc1,
+	0xe8, 0x5d, 0x92, 0xd1, 0xab, 0x4b, 0xe9, 0xfb, 0x64, 0x8e, 0x3e, 0xa7, 0xe8, 0xfc, 0x04, 0x56,
+	0xe7, 0xd8, 0xbf, 0xca, 0xcc, 0xc6, 0xc8, 0xfb, 0xe4, 0x7f, 0x26, 0xb7, 0xfe, 0xa4, 0x80, 0x71,
+	0x88, 0xc5, 0x94, 0xc6, 0x5e, 0xbe, 0x52, 0x0f, 0x88, 0x2a, 0xc7, 0x77, 0x7a, 0x3b, 0xae, 0x2b,
+	0xf5, 0xe6, 0x18, 0xe2, 0x39, 0x80, 0x45, 0xc8, 0x4d, 0x3d, 0x2b, 0xc1, 0xce, 0x1e, 0x98, 0x19,
+	0xf2, 0x12, 0x35, 0x36, 0x8b, 0x6a, 0xa4, 0x1d, 0x8f, 0xcb, 0x18, 0xdb, 0x67, 0x49, 0x51, 0xad,
+	0x47, 0x60, 0x66, 0xeb, 0xfc, 0x94, 0xdb, 0x67, 0xd9, 0x9d, 0x8f, 0x7d, 0x5b, 0xbf, 0x04, 0x18,
+	0x5f, 0x07, 0x8b, 0x0f, 0x33, 0x26, 0x7f, 0x98, 0x79, 0x04, 0x95, 0xaf, 0x62, 0x8f, 0x92, 0x85,
+	0xfe, 0x2c, 0x97, 0x59, 0xeb, 0x8b, 0x89, 0xed, 0x2e, 0xb6, 0x3e, 0xbe, 0x6a, 0xfd, 0x43, 0x01,
+	0x93, 0xb3, 0x77, 0xc2, 0xd8, 0x5d, 0xe0, 0xfe, 0x1e, 0x18, 0x09, 0xb5, 0x69, 0x5a, 0xfc, 0x84,
+	0xab, 0x32, 0xf4, 0xde, 0x88, 0xed, 0x61, 0x81, 0x82, 0xde, 0x87, 0x46, 0x64, 0xc7, 0xd4, 0x73,
+	0xbc, 0xc8, 0x0e, 0x68, 0x2a, 0xaf, 0x50, 0xd2, 0x4b, 0xdb, 0xd9, 0x41, 0xd3, 0xf3, 0x83, 0x66,
+	0x6d, 0x81, 0xc1, 0x59, 0xb2, 0xcb, 0xd8, 0xd1, 0x60, 0xd8, 0x17, 0x37, 0xb3, 0x26, 0x98, 0xbb,
+	0x87, 0xcf, 0x9f, 0xef, 0x8f, 0xc7, 0x83, 0x7e, 0x4b, 0x61, 0x7b, 0x3b, 0x9f, 0x1c, 0xe2, 0x31,
+	0xaf, 0xfb, 0x7f, 0x57, 0xa0, 0xf1, 0x8c, 0xd8, 0xc9, 0x6d, 0x03, 0x29, 0xda, 0xe4, 0x33, 0x44,
+	0xd1, 0x84, 0x22, 0x49, 0x3a, 0x41, 0xac, 0x83, 0x41, 0xc3, 0x0b, 0x12, 0xc8, 0x59, 0x46, 0x00,
+	0x8b, 0xa3, 0x41, 0xa6, 0xb8, 0x51, 0x50, 0xfc, 0x09, 0x1f, 0x17, 0x98, 0x66, 0xbb, 0x3f, 0x7f,
+	0xc1, 0x0a, 0xea, 0x03, 0xd6, 0x9c, 0xf0, 0x60, 0x38, 0xf8, 0x5c, 0x68, 0x8c, 0x07, 0xcf, 0x06,
+	0x3b, 0x23, 0x36, 0x35, 0x1c, 0x80, 0xc1, 0xa5, 0x2f, 0xd5, 0x34, 0xd3, 0x41, 0x2d, 0xea, 0x70,
+	0xe3, 0x58, 0xbf, 0xfd, 0x75, 0x1d, 0x8c, 0xdd, 0x73, 0x16, 0xb9, 0x4d, 0x58, 0xd9, 0x23, 0xf4,
+	0xa8, 0xf0, 0x40, 0x28, 0x22, 0x3d, 0xc0, 0x9d, 0x3c, 0x04, 0xc8, 0x82, 0xc6, 0x1e, 0xa1, 0xd9,
+	0xdb, 0xec, 0x52, 0x9c, 0xb7, 0xa0, 0x32, 0x0c, 0xa9, 0x77, 0x3a, 0x43, 0xf9, 0x62, 0x27, 0x45,
+	0x44, 0x3f, 0x80, 0x66, 0xe9, 0x79, 0x57, 0xb2, 0xd8, 0xef, 0x77, 0x3a, 0xfc, 0x63, 0xf9, 0xdb,
+	0xef, 0x08, 0xd6, 0xe7, 0x1f, 0x09, 0x85, 0x2c, 0xd1, 0x4d, 0x97, 0x3f, 0x42, 0x76, 0xde, 0xbe,
+	0x61, 0x57, 0x32, 0xdd, 0x84, 0xd6, 0xee, 0x39, 0x71, 0x2e, 0x16, 0x8d, 0xde, 0xef, 0xe7, 0x0a,
+	0x6f, 0xc1, 0xca, 0xa8, 0xec, 0x98, 0xd7, 0x85, 0x59, 0x73, 0x0f, 0xbe, 0x39, 0x45, 0x0f, 0x1a,
+	0xa3, 0xa2, 0x93, 0xee, 0xc2, 0xdf, 0x04, 0x83, 0x3f, 0xf3, 0xe6, 0xde, 0x44, 0xa2, 0x28, 0x96,
+	0xde, 0x7e, 0x9f, 0x80, 0x3e, 0xd9, 0x23, 0x14, 0x89, 0xdb, 0x5b, 0xfe, 0x6c, 0xd9, 0x69, 0xe5,
+	0x0b, 0x05, 0xd4, 0x51, 0x86, 0x3a, 0x9a, 0x47, 0x2d, 0xbc, 0x50, 0xa1, 0x6d, 0xa8, 0x4e, 0xc4,
+	0xb3, 0x11, 0x12, 0x42, 0x4b, 0x6f, 0x48, 0x9d, 0xb5, 0xd2, 0x9a, 0xa4, 0xf9, 0x18, 0x1a, 0x93,
+	0xc2, 0x7b, 0x13, 0x7a, 0x83, 0x23, 0x2d, 0xbe, 0x40, 0x2d, 0xa7, 0xde, 0x81, 0xc6, 0xa4, 0xf0,
+	0x24, 0x22, 0xa9, 0x17, 0xdf, 0x9d, 0x3a, 0xed, 0xc5, 0x0d, 0xc9, 0xe2, 0x3b, 0x00, 0x93, 0xec,
+	0x75, 0x44, 0xba, 0x77, 0xfe, 0xb5, 0xa4, 0x14, 0xc0, 0x49, 0x3f, 0x0e, 0xa3, 0xfc, 0xf5, 0xe3,
+	0xf5, 0xb9, 0xbb, 0xe3, 0x62, 0x00, 0x57, 0x27, 0xec, 0xe2, 0x39, 0xcc, 0x07, 0x91, 0xb9, 0xd0,
+	0x94, 0xef, 0xa7, 0xdb, 0x60, 0x4e, 0xf8, 0x9d, 0x82, 0xc5, 0xe7, 0x35, 0x8e, 0x50, 0xbc, 0x62,
+	0x74, 0x50, 0x71, 0x29, 0x73, 0xbc, 0xa4, 0x19, 0xdd, 0x9f, 0xe6, 0x87, 0xd0, 0x10, 0x34, 0xd2,
+	0xf1, 0xf7, 0x24, 0xeb, 0x41, 0x65, 0xc2, 0x87, 0x70, 0x49, 0x50, 0xbc, 0x08, 0x74, 0x56, 0xe7,
+	0x66, 0xf4, 0x2d, 0x85, 0xe5, 0xe3, 0x84, 0xdd, 0x12, 0x91, 0x4c, 0x97, 0xfc, 0xf6, 0xda, 0x49,
+	0xab, 0xff, 0x96, 0x82, 0x7a, 0x50, 0x9b, 0xc8, 0x91, 0x19, 0xad, 0x15, 0x86, 0xda, 0x2c, 0x00,
+	0xf5, 0xc2, 0xe2, 0x96, 0xc2, 0x33, 0x4d, 0x4c, 0x61, 0x32, 0xd3, 0x4a, 0xd3, 0x62, 0x67, 0xad,
+	0xb4, 0x56, 0xc8, 0x4e, 0x31, 0xc5, 0x48, 0x9a, 0xd2, 0x6c, 0xd6, 0x59, 0x2b, 0xad, 0x65, 0xa7,
+	0xba, 0x36, 0x39, 0x8a, 0x49, 0x64, 0xc7, 0x04, 0xad, 0xe6, 0xfd, 0x66, 0x2e, 0xc4, 0x8f, 0x01,
+	0x26, 0xbb, 0xe1, 0x74, 0xea, 0xd1, 0xf1, 0x75, 0x70, 0x0b, 0xde, 0xb7, 0xc0, 0x9c, 0xec, 0x9c,
+	0x84, 0xf1, 0x1d, 0x68, 0x4f, 0xf8, 0x31, 0x72, 0x3c, 0xf6, 0xcb, 0xac, 0xdc, 0xe3, 0x3a, 0x73,
+	0x30, 0x7a, 0x17, 0x2a, 0x13, 0x51, 0xc0, 0x5f, 0x5b, 0x68, 0x25, 0x1d, 0xc8, 0x97, 0xb6, 0xff,
+	0xaa, 0x42, 0xed, 0x80, 0xcc, 0x8e, 0xf9, 0x60, 0xdf, 0x05, 0xed, 0x9e, 0x87, 0xbf, 0x0b, 0xda,
+	0x3d, 0xcf, 0xfe, 0xf7, 0xa0, 0xf2, 0xaa, 0x47, 0x7f, 0x0b, 0x0c, 0x9e, 0x5b, 0x68, 0x6d, 0xc9,
+	0x55, 0x7a, 0x69, 0xf2, 0x7d, 0x17, 0x74, 0x9e, 0x4b, 0x48, 0x12, 0x14, 0xb3, 0x69, 0xad, 0xb4,
+	0x26, 0x09, 0xde, 0x07, 0xe3, 0x15, 0x92, 0xf5, 0xa4, 0xc2, 0xff, 0x5a, 0x7e, 0xff, 0xbf, 0x03,
+	0x00, 0x90, 0xf6, 0x3e, 0x20, 0xc2, 0x1c, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ChordClient is the client API for Chord service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ChordClient interface {
+	// GetPredecessor returns the node believed to be the current predecessor.
+	GetPredecessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error)
+	// GetSuccessor returns the node believed to be the current successor.
+	GetSuccessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error)
+	// Notify notifies Chord that Node thinks it is our predecessor. This has
+	// the potential to initiate the transferring of keys. The claimed node is
+	// probed and must answer with the same ID and name us as its successor.
+	Notify(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error)
+	// FindSuccessor finds the node the succedes ID. May initiate RPC calls to
+	// other nodes. Fails with RESOURCE_EXHAUSTED past the hop limit and with
+	// ABORTED when the lookup loops; both carry the traversed path as details.
+	FindSuccessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*FindSuccessorResponse, error)
+	// ClosestPrecedingNode returns the closest finger preceding ID along with
+	// the node's successor, without forwarding. Used by iterative lookups.
+	ClosestPrecedingNode(ctx context.Context, in *ClosestPrecedingRequest, opts ...grpc.CallOption) (*ClosestPrecedingResponse, error)
+	// CheckPredecessor checkes whether predecessor has failed.
+	CheckPredecessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*ER, error)
+	// SetPredecessor sets predecessor for a node. Only honoured when the
+	// caller is the current predecessor and is leaving the ring.
+	SetPredecessor(ctx context.Context, in *NeighbourRequest, opts ...grpc.CallOption) (*ER, error)
+	// SetSuccessor sets successor for a node. Only honoured when the caller
+	// is the current successor and is leaving the ring.
+	SetSuccessor(ctx context.Context, in *NeighbourRequest, opts ...grpc.CallOption) (*ER, error)
+	// Probe returns the node's identity and view of its neighbours. Used to
+	// check that an ID really lives at an address before trusting it.
+	Probe(ctx context.Context, in *ER, opts ...grpc.CallOption) (*ProbeResponse, error)
+	// Get returns the value in Chord ring for the given key.
+	XGet(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Set writes a key value pair to the Chord ring.
+	XSet(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
+	// Delete returns the value in Chord ring for the given key.
+	XDelete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Multiple delete returns the value in Chord ring between the given keys.
+	XMultiDelete(ctx context.Context, in *MultiDeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// RequestKeys returns the keys between given range from the Chord ring.
+	XRequestKeys(ctx context.Context, in *RequestKeysRequest, opts ...grpc.CallOption) (*RequestKeysResponse, error)
+	// StoreKeys writes the given pairs without checking ownership. Used to
+	// place replicas and to hand keys over when a node leaves.
+	XStoreKeys(ctx context.Context, in *StoreKeysRequest, opts ...grpc.CallOption) (*ER, error)
+	// DropNamespace removes every key this node holds in the namespace.
+	XDropNamespace(ctx context.Context, in *NamespaceRequest, opts ...grpc.CallOption) (*ER, error)
+	// ListNamespaces returns the namespace definitions this node holds.
+	XListNamespaces(ctx context.Context, in *ER, opts ...grpc.CallOption) (*NamespaceList, error)
+	// Batch RPCs handle many keys of one namespace at once. Each key gets
+	// its own result; keys owned by another node come back with the owner
+	// instead of being forwarded.
+	XBatchGet(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	XBatchSet(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	XBatchDelete(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// Watch streams changes made on this node to a key, or to every key
+	// with a prefix. Key watches fail with FAILED_PRECONDITION carrying the
+	// owner Node if the key is not ours. When keys move to another node a
+	// MOVED event names it; key watches end after it.
+	XWatch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Chord_XWatchClient, error)
+	// Scan streams the keys this node owns after a ring position, in ring
+	// order. Fails with FAILED_PRECONDITION carrying the owner Node when the
+	// position just after req.after is not ours.
+	XScan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (Chord_XScanClient, error)
+	// Changes streams this node's change log from req.from, oldest first.
+	// Fails with OUT_OF_RANGE when changes from req.from have already been
+	// dropped from the log. Following streams keep sending new changes.
+	XChanges(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (Chord_XChangesClient, error)
+	// Merkle returns hashes from this node's Merkle tree over the keys of
+	// some namespaces in (from, to]. Used by owners to find the ranges
+	// where a replica has drifted.
+	XMerkle(ctx context.Context, in *MerkleRequest, opts ...grpc.CallOption) (*MerkleResponse, error)
+	// Update changes a counter or set key on its owner and returns its new
+	// value. Fails if the key holds a value of another type.
+	XUpdate(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+	// Prepare locks the keys of a transaction held by this node and checks
+	// that the keys it read are unchanged. Fails if another transaction
+	// holds one of the keys or a read is stale.
+	XPrepare(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*ER, error)
+	// CommitTxn applies the writes of a prepared transaction and releases
+	// its locks; AbortTxn only releases them. Both ignore unknown ids.
+	XCommitTxn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*ER, error)
+	XAbortTxn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*ER, error)
+	// Decide records the outcome of a transaction on the owner of its
+	// coordinator record, unless one was already recorded, and returns the
+	// record as stored.
+	XDecide(ctx context.Context, in *TxnRecord, opts ...grpc.CallOption) (*TxnRecord, error)
+	// Lease acquires, renews or releases a lease on the owner of its name
+	// and returns it as granted.
+	XLease(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*Lease, error)
+}
+
+type chordClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChordClient(cc grpc.ClientConnInterface) ChordClient {
+	return &chordClient{cc}
+}
+
+func (c *chordClient) GetPredecessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error) {
+	out := new(Node)
+	err := c.cc.Invoke(ctx, "/api.Chord/GetPredecessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetSuccessor(ctx context.Context, in *ER, opts ...grpc.CallOption) (*Node, error) {
+	out := new(Node)
+	err := c.cc.Invoke(ctx, "/api.Chord/GetSuccessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Notify(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/Notify", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) FindSuccessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*FindSuccessorResponse, error) {
+	out := new(FindSuccessorResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/FindSuccessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) ClosestPrecedingNode(ctx context.Context, in *ClosestPrecedingRequest, opts ...grpc.CallOption) (*ClosestPrecedingResponse, error) {
+	out := new(ClosestPrecedingResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/ClosestPrecedingNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) CheckPredecessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/CheckPredecessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) SetPredecessor(ctx context.Context, in *NeighbourRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/SetPredecessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) SetSuccessor(ctx context.Context, in *NeighbourRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/SetSuccessor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Probe(ctx context.Context, in *ER, opts ...grpc.CallOption) (*ProbeResponse, error) {
+	out := new(ProbeResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/Probe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XGet(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XSet(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
+	out := new(SetResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XDelete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XMultiDelete(ctx context.Context, in *MultiDeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XMultiDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XRequestKeys(ctx context.Context, in *RequestKeysRequest, opts ...grpc.CallOption) (*RequestKeysResponse, error) {
+	out := new(RequestKeysResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XRequestKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XStoreKeys(ctx context.Context, in *StoreKeysRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/XStoreKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XDropNamespace(ctx context.Context, in *NamespaceRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/XDropNamespace", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XListNamespaces(ctx context.Context, in *ER, opts ...grpc.CallOption) (*NamespaceList, error) {
+	out := new(NamespaceList)
+	err := c.cc.Invoke(ctx, "/api.Chord/XListNamespaces", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XBatchGet(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XBatchGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XBatchSet(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XBatchSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XBatchDelete(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XBatchDelete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XWatch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Chord_XWatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Chord_serviceDesc.Streams[0], "/api.Chord/XWatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordXWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_XWatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type chordXWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordXWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) XScan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (Chord_XScanClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Chord_serviceDesc.Streams[1], "/api.Chord/XScan", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordXScanClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_XScanClient interface {
+	Recv() (*KV, error)
+	grpc.ClientStream
+}
+
+type chordXScanClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordXScanClient) Recv() (*KV, error) {
+	m := new(KV)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) XChanges(ctx context.Context, in *ChangesRequest, opts ...grpc.CallOption) (Chord_XChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Chord_serviceDesc.Streams[2], "/api.Chord/XChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordXChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_XChangesClient interface {
+	Recv() (*Change, error)
+	grpc.ClientStream
+}
+
+type chordXChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordXChangesClient) Recv() (*Change, error) {
+	m := new(Change)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) XMerkle(ctx context.Context, in *MerkleRequest, opts ...grpc.CallOption) (*MerkleResponse, error) {
+	out := new(MerkleResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XMerkle", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XUpdate(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error) {
+	out := new(UpdateResponse)
+	err := c.cc.Invoke(ctx, "/api.Chord/XUpdate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XPrepare(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/XPrepare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XCommitTxn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/XCommitTxn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XAbortTxn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*ER, error) {
+	out := new(ER)
+	err := c.cc.Invoke(ctx, "/api.Chord/XAbortTxn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XDecide(ctx context.Context, in *TxnRecord, opts ...grpc.CallOption) (*TxnRecord, error) {
+	out := new(TxnRecord)
+	err := c.cc.Invoke(ctx, "/api.Chord/XDecide", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) XLease(ctx context.Context, in *LeaseRequest, opts ...grpc.CallOption) (*Lease, error) {
+	out := new(Lease)
+	err := c.cc.Invoke(ctx, "/api.Chord/XLease", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChordServer is the server API for Chord service.
+type ChordServer interface {
+	// GetPredecessor returns the node believed to be the current predecessor.
+	GetPredecessor(context.Context, *ER) (*Node, error)
+	// GetSuccessor returns the node believed to be the current successor.
+	GetSuccessor(context.Context, *ER) (*Node, error)
+	// Notify notifies Chord that Node thinks it is our predecessor. This has
+	// the potential to initiate the transferring of keys. The claimed node is
+	// probed and must answer with the same ID and name us as its successor.
+	Notify(context.Context, *Node) (*ER, error)
+	// FindSuccessor finds the node the succedes ID. May initiate RPC calls to
+	// other nodes. Fails with RESOURCE_EXHAUSTED past the hop limit and with
+	// ABORTED when the lookup loops; both carry the traversed path as details.
+	FindSuccessor(context.Context, *ID) (*FindSuccessorResponse, error)
+	// ClosestPrecedingNode returns the closest finger preceding ID along with
+	// the node's successor, without forwarding. Used by iterative lookups.
+	ClosestPrecedingNode(context.Context, *ClosestPrecedingRequest) (*ClosestPrecedingResponse, error)
+	// CheckPredecessor checkes whether predecessor has failed.
+	CheckPredecessor(context.Context, *ID) (*ER, error)
+	// SetPredecessor sets predecessor for a node. Only honoured when the
+	// caller is the current predecessor and is leaving the ring.
+	SetPredecessor(context.Context, *NeighbourRequest) (*ER, error)
+	// SetSuccessor sets successor for a node. Only honoured when the caller
+	// is the current successor and is leaving the ring.
+	SetSuccessor(context.Context, *NeighbourRequest) (*ER, error)
+	// Probe returns the node's identity and view of its neighbours. Used to
+	// check that an ID really lives at an address before trusting it.
+	Probe(context.Context, *ER) (*ProbeResponse, error)
+	// Get returns the value in Chord ring for the given key.
+	XGet(context.Context, *GetRequest) (*GetResponse, error)
+	// Set writes a key value pair to the Chord ring.
+	XSet(context.Context, *SetRequest) (*SetResponse, error)
+	// Delete returns the value in Chord ring for the given key.
+	XDelete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Multiple delete returns the value in Chord ring between the given keys.
+	XMultiDelete(context.Context, *MultiDeleteRequest) (*DeleteResponse, error)
+	// RequestKeys returns the keys between given range from the Chord ring.
+	XRequestKeys(context.Context, *RequestKeysRequest) (*RequestKeysResponse, error)
+	// StoreKeys writes the given pairs without checking ownership. Used to
+	// place replicas and to hand keys over when a node leaves.
+	XStoreKeys(context.Context, *StoreKeysRequest) (*ER, error)
+	// DropNamespace removes every key this node holds in the namespace.
+	XDropNamespace(context.Context, *NamespaceRequest) (*ER, error)
+	// ListNamespaces returns the namespace definitions this node holds.
+	XListNamespaces(context.Context, *ER) (*NamespaceList, error)
+	// Batch RPCs handle many keys of one namespace at once. Each key gets
+	// its own result; keys owned by another node come back with the owner
+	// instead of being forwarded.
+	XBatchGet(context.Context, *BatchRequest) (*BatchResponse, error)
+	XBatchSet(context.Context, *BatchRequest) (*BatchResponse, error)
+	XBatchDelete(context.Context, *BatchRequest) (*BatchResponse, error)
+	// Watch streams changes made on this node to a key, or to every key
+	// with a prefix. Key watches fail with FAILED_PRECONDITION carrying the
+	// owner Node if the key is not ours. When keys move to another node a
+	// MOVED event names it; key watches end after it.
+	XWatch(*WatchRequest, Chord_XWatchServer) error
+	// Scan streams the keys this node owns after a ring position, in ring
+	// order. Fails with FAILED_PRECONDITION carrying the owner Node when the
+	// position just after req.after is not ours.
+	XScan(*ScanRequest, Chord_XScanServer) error
+	// Changes streams this node's change log from req.from, oldest first.
+	// Fails with OUT_OF_RANGE when changes from req.from have already been
+	// dropped from the log. Following streams keep sending new changes.
+	XChanges(*ChangesRequest, Chord_XChangesServer) error
+	// Merkle returns hashes from this node's Merkle tree over the keys of
+	// some namespaces in (from, to]. Used by owners to find the ranges
+	// where a replica has drifted.
+	XMerkle(context.Context, *MerkleRequest) (*MerkleResponse, error)
+	// Update changes a counter or set key on its owner and returns its new
+	// value. Fails if the key holds a value of another type.
+	XUpdate(context.Context, *UpdateRequest) (*UpdateResponse, error)
+	// Prepare locks the keys of a transaction held by this node and checks
+	// that the keys it read are unchanged. Fails if another transaction
+	// holds one of the keys or a read is stale.
+	XPrepare(context.Context, *TxnRequest) (*ER, error)
+	// CommitTxn applies the writes of a prepared transaction and releases
+	// its locks; AbortTxn only releases them. Both ignore unknown ids.
+	XCommitTxn(context.Context, *TxnRequest) (*ER, error)
+	XAbortTxn(context.Context, *TxnRequest) (*ER, error)
+	// Decide records the outcome of a transaction on the owner of its
+	// coordinator record, unless one was already recorded, and returns the
+	// record as stored.
+	XDecide(context.Context, *TxnRecord) (*TxnRecord, error)
+	// Lease acquires, renews or releases a lease on the owner of its name
+	// and returns it as granted.
+	XLease(context.Context, *LeaseRequest) (*Lease, error)
+}
+
+// UnimplementedChordServer can be embedded to have forward compatible implementations.
+type UnimplementedChordServer struct {
+}
+
+func (*UnimplementedChordServer) GetPredecessor(ctx context.Context, req *ER) (*Node, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPredecessor not implemented")
+}
+func (*UnimplementedChordServer) GetSuccessor(ctx context.Context, req *ER) (*Node, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSuccessor not implemented")
+}
+func (*UnimplementedChordServer) Notify(ctx context.Context, req *Node) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Notify not implemented")
+}
+func (*UnimplementedChordServer) FindSuccessor(ctx context.Context, req *ID) (*FindSuccessorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSuccessor not implemented")
+}
+func (*UnimplementedChordServer) ClosestPrecedingNode(ctx context.Context, req *ClosestPrecedingRequest) (*ClosestPrecedingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClosestPrecedingNode not implemented")
+}
+func (*UnimplementedChordServer) CheckPredecessor(ctx context.Context, req *ID) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckPredecessor not implemented")
+}
+func (*UnimplementedChordServer) SetPredecessor(ctx context.Context, req *NeighbourRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPredecessor not implemented")
+}
+func (*UnimplementedChordServer) SetSuccessor(ctx context.Context, req *NeighbourRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSuccessor not implemented")
+}
+func (*UnimplementedChordServer) Probe(ctx context.Context, req *ER) (*ProbeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Probe not implemented")
+}
+func (*UnimplementedChordServer) XGet(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XGet not implemented")
+}
+func (*UnimplementedChordServer) XSet(ctx context.Context, req *SetRequest) (*SetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XSet not implemented")
+}
+func (*UnimplementedChordServer) XDelete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XDelete not implemented")
+}
+func (*UnimplementedChordServer) XMultiDelete(ctx context.Context, req *MultiDeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XMultiDelete not implemented")
+}
+func (*UnimplementedChordServer) XRequestKeys(ctx context.Context, req *RequestKeysRequest) (*RequestKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XRequestKeys not implemented")
+}
+func (*UnimplementedChordServer) XStoreKeys(ctx context.Context, req *StoreKeysRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XStoreKeys not implemented")
+}
+func (*UnimplementedChordServer) XDropNamespace(ctx context.Context, req *NamespaceRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XDropNamespace not implemented")
+}
+func (*UnimplementedChordServer) XListNamespaces(ctx context.Context, req *ER) (*NamespaceList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XListNamespaces not implemented")
+}
+func (*UnimplementedChordServer) XBatchGet(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XBatchGet not implemented")
+}
+func (*UnimplementedChordServer) XBatchSet(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XBatchSet not implemented")
+}
+func (*UnimplementedChordServer) XBatchDelete(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XBatchDelete not implemented")
+}
+func (*UnimplementedChordServer) XWatch(req *WatchRequest, srv Chord_XWatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method XWatch not implemented")
+}
+func (*UnimplementedChordServer) XScan(req *ScanRequest, srv Chord_XScanServer) error {
+	return status.Errorf(codes.Unimplemented, "method XScan not implemented")
+}
+func (*UnimplementedChordServer) XChanges(req *ChangesRequest, srv Chord_XChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method XChanges not implemented")
+}
+func (*UnimplementedChordServer) XMerkle(ctx context.Context, req *MerkleRequest) (*MerkleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XMerkle not implemented")
+}
+func (*UnimplementedChordServer) XUpdate(ctx context.Context, req *UpdateRequest) (*UpdateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XUpdate not implemented")
+}
+func (*UnimplementedChordServer) XPrepare(ctx context.Context, req *TxnRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XPrepare not implemented")
+}
+func (*UnimplementedChordServer) XCommitTxn(ctx context.Context, req *TxnRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XCommitTxn not implemented")
+}
+func (*UnimplementedChordServer) XAbortTxn(ctx context.Context, req *TxnRequest) (*ER, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XAbortTxn not implemented")
+}
+func (*UnimplementedChordServer) XDecide(ctx context.Context, req *TxnRecord) (*TxnRecord, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XDecide not implemented")
+}
+func (*UnimplementedChordServer) XLease(ctx context.Context, req *LeaseRequest) (*Lease, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method XLease not implemented")
+}
+
+func RegisterChordServer(s *grpc.Server, srv ChordServer) {
+	s.RegisterService(&_Chord_serviceDesc, srv)
+}
+
+func _Chord_GetPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ER)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetPredecessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/GetPredecessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetPredecessor(ctx, req.(*ER))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ER)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetSuccessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/GetSuccessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetSuccessor(ctx, req.(*ER))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_Notify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Node)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Notify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/Notify",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Notify(ctx, req.(*Node))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_FindSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).FindSuccessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/FindSuccessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).FindSuccessor(ctx, req.(*ID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_ClosestPrecedingNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClosestPrecedingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).ClosestPrecedingNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/ClosestPrecedingNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).ClosestPrecedingNode(ctx, req.(*ClosestPrecedingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_CheckPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).CheckPredecessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/CheckPredecessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).CheckPredecessor(ctx, req.(*ID))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) FindSuccessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*Node, error) {
-	out := new(Node)
-	err := c.cc.Invoke(ctx, "/api.Chord/FindSuccessor", in, out, opts...)
-	if err != nil {
+func _Chord_SetPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NeighbourRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).SetPredecessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/SetPredecessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).SetPredecessor(ctx, req.(*NeighbourRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) CheckPredecessor(ctx context.Context, in *ID, opts ...grpc.CallOption) (*ER, error) {
-	out := new(ER)
-	err := c.cc.Invoke(ctx, "/api.Chord/CheckPredecessor", in, out, opts...)
-	if err != nil {
+func _Chord_SetSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NeighbourRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).SetSuccessor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/SetSuccessor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).SetSuccessor(ctx, req.(*NeighbourRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) SetPredecessor(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error) {
-	out := new(ER)
-	err := c.cc.Invoke(ctx, "/api.Chord/SetPredecessor", in, out, opts...)
-	if err != nil {
+func _Chord_Probe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ER)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).Probe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/Probe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Probe(ctx, req.(*ER))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) SetSuccessor(ctx context.Context, in *Node, opts ...grpc.CallOption) (*ER, error) {
-	out := new(ER)
-	err := c.cc.Invoke(ctx, "/api.Chord/SetSuccessor", in, out, opts...)
-	if err != nil {
+func _Chord_XGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).XGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/XGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XGet(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) XGet(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
-	out := new(GetResponse)
-	err := c.cc.Invoke(ctx, "/api.Chord/XGet", in, out, opts...)
-	if err != nil {
+func _Chord_XSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).XSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/XSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XSet(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) XSet(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
-	out := new(SetResponse)
-	err := c.cc.Invoke(ctx, "/api.Chord/XSet", in, out, opts...)
-	if err != nil {
+func _Chord_XDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).XDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/XDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XDelete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) XDelete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
-	out := new(DeleteResponse)
-	err := c.cc.Invoke(ctx, "/api.Chord/XDelete", in, out, opts...)
-	if err != nil {
+func _Chord_XMultiDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiDeleteRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).XMultiDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/XMultiDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XMultiDelete(ctx, req.(*MultiDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) XMultiDelete(ctx context.Context, in *MultiDeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
-	out := new(DeleteResponse)
-	err := c.cc.Invoke(ctx, "/api.Chord/XMultiDelete", in, out, opts...)
-	if err != nil {
+func _Chord_XRequestKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestKeysRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(ChordServer).XRequestKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/XRequestKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XRequestKeys(ctx, req.(*RequestKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *chordClient) XRequestKeys(ctx context.Context, in *RequestKeysRequest, opts ...grpc.CallOption) (*RequestKeysResponse, error) {
-	out := new(RequestKeysResponse)
-	err := c.cc.Invoke(ctx, "/api.Chord/XRequestKeys", in, out, opts...)
-	if err != nil {
+func _Chord_XStoreKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StoreKeysRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
-}
-
-// ChordServer is the server API for Chord service.
-type ChordServer interface {
-	// GetPredecessor returns the node believed to be the current predecessor.
-	GetPredecessor(context.Context, *ER) (*Node, error)
-	// GetSuccessor returns the node believed to be the current successor.
-	GetSuccessor(context.Context, *ER) (*Node, error)
-	// Notify notifies Chord that Node thinks it is our predecessor. This has
-	// the potential to initiate the transferring of keys.
-	Notify(context.Context, *Node) (*ER, error)
-	// FindSuccessor finds the node the succedes ID. May initiate RPC calls to
-	// other nodes.
-	FindSuccessor(context.Context, *ID) (*Node, error)
-	// CheckPredecessor checkes whether predecessor has failed.
-	CheckPredecessor(context.Context, *ID) (*ER, error)
-	// SetPredecessor sets predecessor for a node.
-	SetPredecessor(context.Context, *Node) (*ER, error)
-	// SetPredecessor sets predecessor for a node.
-	SetSuccessor(context.Context, *Node) (*ER, error)
-	// Get returns the value in Chord ring for the given key.
-	XGet(context.Context, *GetRequest) (*GetResponse, error)
-	// Set writes a key value pair to the Chord ring.
-	XSet(context.Context, *SetRequest) (*SetResponse, error)
-	// Delete returns the value in Chord ring for the given key.
-	XDelete(context.Context, *DeleteRequest) (*DeleteResponse, error)
-	// Multiple delete returns the value in Chord ring between the given keys.
-	XMultiDelete(context.Context, *MultiDeleteRequest) (*DeleteResponse, error)
-	// RequestKeys returns the keys between given range from the Chord ring.
-	XRequestKeys(context.Context, *RequestKeysRequest) (*RequestKeysResponse, error)
-}
-
-// UnimplementedChordServer can be embedded to have forward compatible implementations.
-type UnimplementedChordServer struct {
-}
-
-func (*UnimplementedChordServer) GetPredecessor(ctx context.Context, req *ER) (*Node, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetPredecessor not implemented")
-}
-func (*UnimplementedChordServer) GetSuccessor(ctx context.Context, req *ER) (*Node, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetSuccessor not implemented")
-}
-func (*UnimplementedChordServer) Notify(ctx context.Context, req *Node) (*ER, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Notify not implemented")
-}
-func (*UnimplementedChordServer) FindSuccessor(ctx context.Context, req *ID) (*Node, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method FindSuccessor not implemented")
-}
-func (*UnimplementedChordServer) CheckPredecessor(ctx context.Context, req *ID) (*ER, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method CheckPredecessor not implemented")
-}
-func (*UnimplementedChordServer) SetPredecessor(ctx context.Context, req *Node) (*ER, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SetPredecessor not implemented")
-}
-func (*UnimplementedChordServer) SetSuccessor(ctx context.Context, req *Node) (*ER, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method SetSuccessor not implemented")
-}
-func (*UnimplementedChordServer) XGet(ctx context.Context, req *GetRequest) (*GetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method XGet not implemented")
-}
-func (*UnimplementedChordServer) XSet(ctx context.Context, req *SetRequest) (*SetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method XSet not implemented")
-}
-func (*UnimplementedChordServer) XDelete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method XDelete not implemented")
-}
-func (*UnimplementedChordServer) XMultiDelete(ctx context.Context, req *MultiDeleteRequest) (*DeleteResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method XMultiDelete not implemented")
-}
-func (*UnimplementedChordServer) XRequestKeys(ctx context.Context, req *RequestKeysRequest) (*RequestKeysResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method XRequestKeys not implemented")
-}
-
-func RegisterChordServer(s *grpc.Server, srv ChordServer) {
-	s.RegisterService(&_Chord_serviceDesc, srv)
+	if interceptor == nil {
+		return srv.(ChordServer).XStoreKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Chord/XStoreKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XStoreKeys(ctx, req.(*StoreKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_GetPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ER)
+func _Chord_XDropNamespace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NamespaceRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).GetPredecessor(ctx, in)
+		return srv.(ChordServer).XDropNamespace(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/GetPredecessor",
+		FullMethod: "/api.Chord/XDropNamespace",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).GetPredecessor(ctx, req.(*ER))
+		return srv.(ChordServer).XDropNamespace(ctx, req.(*NamespaceRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_GetSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Chord_XListNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ER)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).GetSuccessor(ctx, in)
+		return srv.(ChordServer).XListNamespaces(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/GetSuccessor",
+		FullMethod: "/api.Chord/XListNamespaces",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).GetSuccessor(ctx, req.(*ER))
+		return srv.(ChordServer).XListNamespaces(ctx, req.(*ER))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_Notify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Node)
+func _Chord_XBatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).Notify(ctx, in)
+		return srv.(ChordServer).XBatchGet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/Notify",
+		FullMethod: "/api.Chord/XBatchGet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).Notify(ctx, req.(*Node))
+		return srv.(ChordServer).XBatchGet(ctx, req.(*BatchRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_FindSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ID)
+func _Chord_XBatchSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).FindSuccessor(ctx, in)
+		return srv.(ChordServer).XBatchSet(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/FindSuccessor",
+		FullMethod: "/api.Chord/XBatchSet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).FindSuccessor(ctx, req.(*ID))
+		return srv.(ChordServer).XBatchSet(ctx, req.(*BatchRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_CheckPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ID)
+func _Chord_XBatchDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).CheckPredecessor(ctx, in)
+		return srv.(ChordServer).XBatchDelete(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/CheckPredecessor",
+		FullMethod: "/api.Chord/XBatchDelete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).XBatchDelete(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_XWatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChordServer).XWatch(m, &chordXWatchServer{stream})
+}
+
+type Chord_XWatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type chordXWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordXWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_XScan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).CheckPredecessor(ctx, req.(*ID))
+	return srv.(ChordServer).XScan(m, &chordXScanServer{stream})
+}
+
+type Chord_XScanServer interface {
+	Send(*KV) error
+	grpc.ServerStream
+}
+
+type chordXScanServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordXScanServer) Send(m *KV) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_XChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
 	}
-	return interceptor(ctx, in, info, handler)
+	return srv.(ChordServer).XChanges(m, &chordXChangesServer{stream})
 }
 
-func _Chord_SetPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Node)
+type Chord_XChangesServer interface {
+	Send(*Change) error
+	grpc.ServerStream
+}
+
+type chordXChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordXChangesServer) Send(m *Change) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_XMerkle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).SetPredecessor(ctx, in)
+		return srv.(ChordServer).XMerkle(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/SetPredecessor",
+		FullMethod: "/api.Chord/XMerkle",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).SetPredecessor(ctx, req.(*Node))
+		return srv.(ChordServer).XMerkle(ctx, req.(*MerkleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_SetSuccessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Node)
+func _Chord_XUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).SetSuccessor(ctx, in)
+		return srv.(ChordServer).XUpdate(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/SetSuccessor",
+		FullMethod: "/api.Chord/XUpdate",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).SetSuccessor(ctx, req.(*Node))
+		return srv.(ChordServer).XUpdate(ctx, req.(*UpdateRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_XGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetRequest)
+func _Chord_XPrepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).XGet(ctx, in)
+		return srv.(ChordServer).XPrepare(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/XGet",
+		FullMethod: "/api.Chord/XPrepare",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).XGet(ctx, req.(*GetRequest))
+		return srv.(ChordServer).XPrepare(ctx, req.(*TxnRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_XSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SetRequest)
+func _Chord_XCommitTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).XSet(ctx, in)
+		return srv.(ChordServer).XCommitTxn(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/XSet",
+		FullMethod: "/api.Chord/XCommitTxn",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).XSet(ctx, req.(*SetRequest))
+		return srv.(ChordServer).XCommitTxn(ctx, req.(*TxnRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_XDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DeleteRequest)
+func _Chord_XAbortTxn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).XDelete(ctx, in)
+		return srv.(ChordServer).XAbortTxn(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/XDelete",
+		FullMethod: "/api.Chord/XAbortTxn",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).XDelete(ctx, req.(*DeleteRequest))
+		return srv.(ChordServer).XAbortTxn(ctx, req.(*TxnRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_XMultiDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MultiDeleteRequest)
+func _Chord_XDecide_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRecord)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).XMultiDelete(ctx, in)
+		return srv.(ChordServer).XDecide(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/XMultiDelete",
+		FullMethod: "/api.Chord/XDecide",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).XMultiDelete(ctx, req.(*MultiDeleteRequest))
+		return srv.(ChordServer).XDecide(ctx, req.(*TxnRecord))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Chord_XRequestKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RequestKeysRequest)
+func _Chord_XLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ChordServer).XRequestKeys(ctx, in)
+		return srv.(ChordServer).XLease(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Chord/XRequestKeys",
+		FullMethod: "/api.Chord/XLease",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ChordServer).XRequestKeys(ctx, req.(*RequestKeysRequest))
+		return srv.(ChordServer).XLease(ctx, req.(*LeaseRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -1059,6 +4554,10 @@ var _Chord_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FindSuccessor",
 			Handler:    _Chord_FindSuccessor_Handler,
 		},
+		{
+			MethodName: "ClosestPrecedingNode",
+			Handler:    _Chord_ClosestPrecedingNode_Handler,
+		},
 		{
 			MethodName: "CheckPredecessor",
 			Handler:    _Chord_CheckPredecessor_Handler,
@@ -1071,6 +4570,10 @@ var _Chord_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SetSuccessor",
 			Handler:    _Chord_SetSuccessor_Handler,
 		},
+		{
+			MethodName: "Probe",
+			Handler:    _Chord_Probe_Handler,
+		},
 		{
 			MethodName: "XGet",
 			Handler:    _Chord_XGet_Handler,
@@ -1091,7 +4594,371 @@ var _Chord_serviceDesc = grpc.ServiceDesc{
 			MethodName: "XRequestKeys",
 			Handler:    _Chord_XRequestKeys_Handler,
 		},
+		{
+			MethodName: "XStoreKeys",
+			Handler:    _Chord_XStoreKeys_Handler,
+		},
+		{
+			MethodName: "XDropNamespace",
+			Handler:    _Chord_XDropNamespace_Handler,
+		},
+		{
+			MethodName: "XListNamespaces",
+			Handler:    _Chord_XListNamespaces_Handler,
+		},
+		{
+			MethodName: "XBatchGet",
+			Handler:    _Chord_XBatchGet_Handler,
+		},
+		{
+			MethodName: "XBatchSet",
+			Handler:    _Chord_XBatchSet_Handler,
+		},
+		{
+			MethodName: "XBatchDelete",
+			Handler:    _Chord_XBatchDelete_Handler,
+		},
+		{
+			MethodName: "XMerkle",
+			Handler:    _Chord_XMerkle_Handler,
+		},
+		{
+			MethodName: "XUpdate",
+			Handler:    _Chord_XUpdate_Handler,
+		},
+		{
+			MethodName: "XPrepare",
+			Handler:    _Chord_XPrepare_Handler,
+		},
+		{
+			MethodName: "XCommitTxn",
+			Handler:    _Chord_XCommitTxn_Handler,
+		},
+		{
+			MethodName: "XAbortTxn",
+			Handler:    _Chord_XAbortTxn_Handler,
+		},
+		{
+			MethodName: "XDecide",
+			Handler:    _Chord_XDecide_Handler,
+		},
+		{
+			MethodName: "XLease",
+			Handler:    _Chord_XLease_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "XWatch",
+			Handler:       _Chord_XWatch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "XScan",
+			Handler:       _Chord_XScan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "XChanges",
+			Handler:       _Chord_XChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api.proto",
+}
+
+// KeyValueClient is the client API for KeyValue service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type KeyValueClient interface {
+	// Get reads a key. Only namespace and key of the request are used.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Set writes a key. hops and version of the request are ignored.
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
+	// Delete removes a key.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Batch gets, sets or deletes many keys of a namespace. Results are in
+	// the order of the request pairs and never carry an owner.
+	Batch(ctx context.Context, in *KVBatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+	// Scan returns one page of keys in ring order and the cursor of the next.
+	Scan(ctx context.Context, in *KVScanRequest, opts ...grpc.CallOption) (*KVScanResponse, error)
+	// Watch streams changes to a key, or to every key with a prefix, across
+	// the ring. Watches follow keys as they move, so MOVED is never sent.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (KeyValue_WatchClient, error)
+}
+
+type keyValueClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKeyValueClient(cc grpc.ClientConnInterface) KeyValueClient {
+	return &keyValueClient{cc}
+}
+
+func (c *keyValueClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, "/api.KeyValue/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
+	out := new(SetResponse)
+	err := c.cc.Invoke(ctx, "/api.KeyValue/Set", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, "/api.KeyValue/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueClient) Batch(ctx context.Context, in *KVBatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/api.KeyValue/Batch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueClient) Scan(ctx context.Context, in *KVScanRequest, opts ...grpc.CallOption) (*KVScanResponse, error) {
+	out := new(KVScanResponse)
+	err := c.cc.Invoke(ctx, "/api.KeyValue/Scan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyValueClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (KeyValue_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_KeyValue_serviceDesc.Streams[0], "/api.KeyValue/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keyValueWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KeyValue_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type keyValueWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *keyValueWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// KeyValueServer is the server API for KeyValue service.
+type KeyValueServer interface {
+	// Get reads a key. Only namespace and key of the request are used.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Set writes a key. hops and version of the request are ignored.
+	Set(context.Context, *SetRequest) (*SetResponse, error)
+	// Delete removes a key.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Batch gets, sets or deletes many keys of a namespace. Results are in
+	// the order of the request pairs and never carry an owner.
+	Batch(context.Context, *KVBatchRequest) (*BatchResponse, error)
+	// Scan returns one page of keys in ring order and the cursor of the next.
+	Scan(context.Context, *KVScanRequest) (*KVScanResponse, error)
+	// Watch streams changes to a key, or to every key with a prefix, across
+	// the ring. Watches follow keys as they move, so MOVED is never sent.
+	Watch(*WatchRequest, KeyValue_WatchServer) error
+}
+
+// UnimplementedKeyValueServer can be embedded to have forward compatible implementations.
+type UnimplementedKeyValueServer struct {
+}
+
+func (*UnimplementedKeyValueServer) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (*UnimplementedKeyValueServer) Set(ctx context.Context, req *SetRequest) (*SetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (*UnimplementedKeyValueServer) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (*UnimplementedKeyValueServer) Batch(ctx context.Context, req *KVBatchRequest) (*BatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Batch not implemented")
+}
+func (*UnimplementedKeyValueServer) Scan(ctx context.Context, req *KVScanRequest) (*KVScanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Scan not implemented")
+}
+func (*UnimplementedKeyValueServer) Watch(req *WatchRequest, srv KeyValue_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+
+func RegisterKeyValueServer(s *grpc.Server, srv KeyValueServer) {
+	s.RegisterService(&_KeyValue_serviceDesc, srv)
+}
+
+func _KeyValue_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.KeyValue/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValue_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.KeyValue/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValue_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.KeyValue/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValue_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.KeyValue/Batch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueServer).Batch(ctx, req.(*KVBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValue_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyValueServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.KeyValue/Scan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyValueServer).Scan(ctx, req.(*KVScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyValue_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeyValueServer).Watch(m, &keyValueWatchServer{stream})
+}
+
+type KeyValue_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type keyValueWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *keyValueWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _KeyValue_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "api.KeyValue",
+	HandlerType: (*KeyValueServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _KeyValue_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _KeyValue_Set_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _KeyValue_Delete_Handler,
+		},
+		{
+			MethodName: "Batch",
+			Handler:    _KeyValue_Batch_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _KeyValue_Scan_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _KeyValue_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
@@ -0,0 +1,353 @@
+package boopy
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ERR_BATCH_MISMATCH = errors.New("batch response does not match request")
+
+// batchRangeCacheSize bounds the owner ranges remembered while grouping the
+// keys of one batch.
+const batchRangeCacheSize = 64
+
+// BatchResult is the outcome for one key of MultiGet, MultiSet or
+// MultiDelete.
+type BatchResult struct {
+	Key   string
+	Value []byte // only set by MultiGet
+	Err   error
+}
+
+// MultiGet reads many keys of a namespace, sending one request per owner.
+// Results are in the order of keys.
+func (n *Node) MultiGet(ns string, keys []string) []BatchResult {
+	return n.batch(ns, keyPairs(keys), 0, n.batchGetRPC)
+}
+
+// MultiSet writes many pairs of a namespace, sending one request per owner.
+// Only Key and Value of each pair are used. A zero ttl uses the namespace
+// default.
+func (n *Node) MultiSet(ns string, pairs []*api.KV, ttl time.Duration) []BatchResult {
+	return n.batch(ns, pairs, ttl, n.batchSetRPC)
+}
+
+// MultiDelete removes many keys of a namespace, sending one request per
+// owner.
+func (n *Node) MultiDelete(ns string, keys []string) []BatchResult {
+	return n.batch(ns, keyPairs(keys), 0, n.batchDeleteRPC)
+}
+
+func keyPairs(keys []string) []*api.KV {
+	pairs := make([]*api.KV, len(keys))
+	for i, key := range keys {
+		pairs[i] = &api.KV{Key: key}
+	}
+	return pairs
+}
+
+type batchCall func(*api.Node, *api.BatchRequest) (*api.BatchResponse, error)
+
+// batch groups pairs by owner and runs call once per owner in parallel.
+// Keys whose owner turned out to be wrong are retried against the owner
+// named in the reply, up to MaxRedirects times.
+func (n *Node) batch(ns string, pairs []*api.KV, ttl time.Duration, call batchCall) []BatchResult {
+	results := make([]BatchResult, len(pairs))
+	owners := make([]*api.Node, len(pairs))
+	ranges := newLocationCache(batchRangeCacheSize, 0)
+
+	pending := make([]int, 0, len(pairs))
+	for i, kv := range pairs {
+		results[i].Key = kv.Key
+		id, err := n.hashKey(namespacedKey(ns, kv.Key))
+		if err == nil {
+			if owners[i] = ranges.get(id); owners[i] == nil {
+				owners[i], err = n.lookupOwner(id, ranges)
+			}
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	for round := 0; len(pending) > 0; round++ {
+		if round > n.cnf.MaxRedirects {
+			for _, i := range pending {
+				results[i].Err = ERR_TOO_MANY_REDIRECTS
+			}
+			break
+		}
+		pending = n.batchRound(ns, pairs, ttl, call, owners, results, pending)
+	}
+	return results
+}
+
+// batchRound sends the pending keys to their owners and returns the keys
+// that were redirected to another owner.
+func (n *Node) batchRound(
+	ns string, pairs []*api.KV, ttl time.Duration, call batchCall,
+	owners []*api.Node, results []BatchResult, pending []int,
+) []int {
+	groups := make(map[string][]int)
+	nodes := make(map[string]*api.Node)
+	for _, i := range pending {
+		groups[owners[i].Addr] = append(groups[owners[i].Addr], i)
+		nodes[owners[i].Addr] = owners[i]
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mtx        sync.Mutex
+		redirected []int
+	)
+	for addr, idx := range groups {
+		wg.Add(1)
+		go func(node *api.Node, idx []int) {
+			defer wg.Done()
+			req := &api.BatchRequest{Namespace: ns, Ttl: int64(ttl), Pairs: make([]*api.KV, len(idx))}
+			for j, i := range idx {
+				req.Pairs[j] = &api.KV{Key: pairs[i].Key, Value: pairs[i].Value}
+			}
+
+			res, err := call(node, req)
+			if err == nil && len(res.Results) != len(idx) {
+				err = ERR_BATCH_MISMATCH
+			}
+			if err != nil {
+				if status.Code(err) == codes.Unavailable {
+					n.forgetLocation(node)
+				}
+				for _, i := range idx {
+					results[i].Err = err
+				}
+				return
+			}
+
+			for j, i := range idx {
+				result := res.Results[j]
+				if result.Owner != nil {
+					mtx.Lock()
+					owners[i] = result.Owner
+					redirected = append(redirected, i)
+					mtx.Unlock()
+					continue
+				}
+				results[i].Value = result.Value
+				results[i].Err = batchError(result.Error)
+			}
+		}(nodes[addr], idx)
+	}
+	wg.Wait()
+	return redirected
+}
+
+// batchErrors are the errors a batch result may carry that callers compare
+// against.
+var batchErrors = []error{
+	ERR_KEY_NOT_FOUND,
+	ERR_QUOTA_EXCEEDED,
+	ERR_NAMESPACE_NOT_FOUND,
+	ERR_KEY_LOCKED,
+}
+
+// batchError turns the error text of a KeyResult back into an error.
+func batchError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	for _, err := range batchErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (n *Node) batchGetRPC(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.XBatchGet(context.Background(), req)
+	}
+	return n.transport.BatchGet(node, req)
+}
+
+func (n *Node) batchSetRPC(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.XBatchSet(context.Background(), req)
+	}
+	return n.transport.BatchSet(node, req)
+}
+
+func (n *Node) batchDeleteRPC(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.XBatchDelete(context.Background(), req)
+	}
+	return n.transport.BatchDelete(node, req)
+}
+
+// batchResults starts the response to a batch, filling in the owner of
+// every key that is not ours. Keys left without an owner or error are ours.
+func (n *Node) batchResults(req *api.BatchRequest) *api.BatchResponse {
+	res := &api.BatchResponse{Results: make([]*api.KeyResult, len(req.Pairs))}
+	ranges := newLocationCache(batchRangeCacheSize, 0)
+	for i, kv := range req.Pairs {
+		result := &api.KeyResult{Key: kv.Key}
+		res.Results[i] = result
+
+		id, err := n.hashKey(namespacedKey(req.Namespace, kv.Key))
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		if n.owns(id) {
+			continue
+		}
+		owner := ranges.get(id)
+		if owner == nil {
+			if owner, err = n.lookupOwner(id, ranges); err != nil {
+				result.Error = err.Error()
+				continue
+			}
+		}
+		if !bytesEqual(owner.Id, n.Id) {
+			result.Owner = owner
+		}
+	}
+	return res
+}
+
+func ours(result *api.KeyResult) bool {
+	return result.Owner == nil && result.Error == ""
+}
+
+func (n *Node) XBatchGet(ctx context.Context, req *api.BatchRequest) (*api.BatchResponse, error) {
+	res := n.batchResults(req)
+	settings, err := n.namespaceSettings(req.Namespace)
+	if err != nil {
+		settings = &api.Namespace{Name: req.Namespace}
+	}
+
+	n.stMtx.RLock()
+	defer n.stMtx.RUnlock()
+	for i, kv := range req.Pairs {
+		result := res.Results[i]
+		if !ours(result) {
+			continue
+		}
+		stored, err := n.storage.Lookup(req.Namespace, kv.Key)
+		if err == nil && stored.Deleted {
+			err = ERR_KEY_NOT_FOUND
+		}
+		if err == nil {
+			var value string
+			value, err = readValue(settings, stored)
+			result.Value = []byte(value)
+		}
+		result.Error = errorString(err)
+	}
+	return res, nil
+}
+
+func (n *Node) XBatchSet(ctx context.Context, req *api.BatchRequest) (*api.BatchResponse, error) {
+	res := n.batchResults(req)
+	settings, err := n.namespaceSettings(req.Namespace)
+	if err != nil {
+		for _, result := range res.Results {
+			if ours(result) {
+				result.Error = err.Error()
+			}
+		}
+		return res, nil
+	}
+	expires := expiry(settings, req.Ttl)
+
+	stored := make([]*api.KV, 0, len(req.Pairs))
+	n.stMtx.Lock()
+	for i, kv := range req.Pairs {
+		result := res.Results[i]
+		if !ours(result) {
+			continue
+		}
+		version := n.nextVersion(req.Namespace, kv.Key)
+		value := kv.Value
+		err := n.txns.check(req.Namespace, kv.Key)
+		if err == nil && settings.Siblings {
+			value, err = n.siblingWrite(req.Namespace, kv.Key, kv.Value, nil)
+		}
+		if err == nil {
+			err = n.checkQuota(settings, kv.Key, value)
+		}
+		if err == nil {
+			err = n.storage.Set(req.Namespace, kv.Key, value, expires, version)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		pair := &api.KV{Namespace: req.Namespace, Key: kv.Key, Value: kv.Value, Version: version}
+		if !expires.IsZero() {
+			pair.Expires = expires.UnixNano()
+		}
+		n.record(api.Change_SET, pair)
+		n.publishSet(req.Namespace, kv.Key, kv.Value)
+		pair.Value = value // replicas get every sibling
+		stored = append(stored, pair)
+	}
+	n.stMtx.Unlock()
+
+	n.replicate(settings, stored...)
+	return res, nil
+}
+
+func (n *Node) XBatchDelete(ctx context.Context, req *api.BatchRequest) (*api.BatchResponse, error) {
+	res := n.batchResults(req)
+	settings, err := n.namespaceSettings(req.Namespace)
+	if err != nil {
+		for _, result := range res.Results {
+			if ours(result) {
+				result.Error = err.Error()
+			}
+		}
+		return res, nil
+	}
+
+	deleted := make([]*api.KV, 0, len(req.Pairs))
+	n.stMtx.Lock()
+	for i, kv := range req.Pairs {
+		result := res.Results[i]
+		if !ours(result) {
+			continue
+		}
+		stone := &api.KV{Namespace: req.Namespace, Key: kv.Key, Deleted: true}
+		stone.Version = n.nextVersion(req.Namespace, kv.Key)
+		err := n.txns.check(req.Namespace, kv.Key)
+		if err == nil {
+			err = n.storage.Delete(req.Namespace, kv.Key, stone.Version)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		n.record(api.Change_DELETE, stone)
+		n.publishDelete(req.Namespace, kv.Key)
+		deleted = append(deleted, stone)
+	}
+	n.stMtx.Unlock()
+
+	n.replicate(settings, deleted...)
+	return res, nil
+}
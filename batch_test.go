@@ -0,0 +1,119 @@
+package boopy
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+// countingTransport counts batch RPCs sent to other nodes.
+type countingTransport struct {
+	Transport
+	batches int32
+}
+
+func (ct *countingTransport) BatchSet(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	atomic.AddInt32(&ct.batches, 1)
+	return ct.Transport.BatchSet(node, req)
+}
+
+func TestNode_Multi(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	counting := &countingTransport{Transport: nodes[0].transport}
+	nodes[0].transport = counting
+
+	pairs := make([]*api.KV, 100)
+	keys := make([]string, 0, 101)
+	for i := range pairs {
+		pairs[i] = &api.KV{Key: fmt.Sprintf("key%d", i), Value: fmt.Sprintf("value%d", i)}
+		keys = append(keys, pairs[i].Key)
+	}
+
+	for _, result := range nodes[0].MultiSet("", pairs, 0) {
+		if result.Err != nil {
+			t.Errorf("MultiSet() %q error = %v", result.Key, result.Err)
+		}
+	}
+	// One request per remote owner, the local share is written directly.
+	if got := atomic.LoadInt32(&counting.batches); got != 2 {
+		t.Errorf("MultiSet() sent %d batch RPCs, want 2", got)
+	}
+
+	keys = append(keys, "missing")
+	results := nodes[1].MultiGet("", keys)
+	for i, result := range results[:100] {
+		if result.Key != keys[i] || result.Err != nil || string(result.Value) != pairs[i].Value {
+			t.Errorf("MultiGet() [%d] = %q, %q, %v, want %q", i, result.Key, result.Value, result.Err, pairs[i].Value)
+		}
+	}
+	if err := results[100].Err; err != ERR_KEY_NOT_FOUND {
+		t.Errorf("MultiGet(missing) error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+
+	for _, result := range nodes[2].MultiDelete("", keys[:50]) {
+		if result.Err != nil {
+			t.Errorf("MultiDelete() %q error = %v", result.Key, result.Err)
+		}
+	}
+	for i, result := range nodes[0].MultiGet("", keys[:100]) {
+		if deleted := i < 50; deleted != (result.Err == ERR_KEY_NOT_FOUND) {
+			t.Errorf("MultiGet(%q) after delete error = %v", result.Key, result.Err)
+		}
+	}
+}
+
+func TestNode_MultiSet_partial(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "small", MaxKeys: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each node accepts one key of the namespace, the rest fail.
+	pairs := make([]*api.KV, 10)
+	for i := range pairs {
+		pairs[i] = &api.KV{Key: fmt.Sprintf("key%d", i), Value: "v"}
+	}
+	failed := 0
+	for _, result := range nodes[1].MultiSet("small", pairs, 0) {
+		if result.Err == ERR_QUOTA_EXCEEDED {
+			failed++
+		} else if result.Err != nil {
+			t.Errorf("MultiSet() %q error = %v", result.Key, result.Err)
+		}
+	}
+	if failed < 8 || failed == len(pairs) {
+		t.Errorf("MultiSet() %d of %d keys over quota", failed, len(pairs))
+	}
+
+	for _, result := range nodes[0].MultiSet("missing", pairs[:2], 0) {
+		if result.Err != ERR_NAMESPACE_NOT_FOUND {
+			t.Errorf("MultiSet(missing) %q error = %v", result.Key, result.Err)
+		}
+	}
+}
+
+func TestNode_XBatchGet_owners(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+
+	req := &api.BatchRequest{}
+	for i := 0; i < 20; i++ {
+		req.Pairs = append(req.Pairs, &api.KV{Key: fmt.Sprintf("key%d", i)})
+	}
+	res, err := nodes[0].XBatchGet(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range res.Results {
+		owner := ringOwner(nodes, req.Pairs[i].Key)
+		if bytesEqual(owner.Id, nodes[0].Id) {
+			if result.Owner != nil || result.Error != ERR_KEY_NOT_FOUND.Error() {
+				t.Errorf("XBatchGet(%q) = %v, want not found locally", result.Key, result)
+			}
+		} else if result.Owner == nil || !bytesEqual(result.Owner.Id, owner.Id) {
+			t.Errorf("XBatchGet(%q) owner = %v, want %v", result.Key, result.Owner, owner)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package boopy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+)
+
+// locationEntry records that node owns the hash range (from, node.Id].
+type locationEntry struct {
+	from    []byte
+	node    *api.Node
+	expires time.Time // zero if the entry never expires
+}
+
+// locationCache remembers which node owns which range of the keyspace, so
+// repeated lookups for hot keys skip FindSuccessor. Entries are evicted
+// oldest first once size is reached, and dropped once older than ttl:
+// requests forwarded to the owner succeed, so a stale entry is not
+// otherwise noticed.
+type locationCache struct {
+	mtx     sync.Mutex
+	entries []*locationEntry
+	size    int
+	ttl     time.Duration
+}
+
+// newLocationCache returns a cache of size entries kept for ttl, or until
+// evicted if ttl is 0.
+func newLocationCache(size int, ttl time.Duration) *locationCache {
+	return &locationCache{
+		entries: make([]*locationEntry, 0, size),
+		size:    size,
+		ttl:     ttl,
+	}
+}
+
+// get returns the cached owner of id, or nil.
+func (c *locationCache) get(id []byte) *api.Node {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i, entry := range c.entries {
+		if !keyBetwIncludeRight(id, entry.from, entry.node.Id) {
+			continue
+		}
+		if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			return nil
+		}
+		return entry.node
+	}
+	return nil
+}
+
+// put records that node owns (from, node.Id], replacing what was known
+// about node before.
+func (c *locationCache) put(from []byte, node *api.Node) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.remove(node)
+	if len(c.entries) >= c.size {
+		c.entries = c.entries[1:]
+	}
+	entry := &locationEntry{from: from, node: node}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.entries = append(c.entries, entry)
+}
+
+// invalidate drops the range cached for node. Returns whether there was one.
+func (c *locationCache) invalidate(node *api.Node) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.remove(node)
+}
+
+func (c *locationCache) remove(node *api.Node) bool {
+	for i, entry := range c.entries {
+		if bytesEqual(entry.node.Id, node.Id) {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// clear drops every entry.
+func (c *locationCache) clear() {
+	c.mtx.Lock()
+	c.entries = c.entries[:0]
+	c.mtx.Unlock()
+}
@@ -0,0 +1,139 @@
+package boopy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+)
+
+func Test_locationCache(t *testing.T) {
+	low := &api.Node{Id: []byte{64}, Addr: "low"}
+	high := &api.Node{Id: []byte{192}, Addr: "high"}
+
+	c := newLocationCache(2, 0)
+	c.put([]byte{192}, low) // wraps around: (192, 64]
+	c.put([]byte{64}, high)
+
+	tests := []struct {
+		name string
+		id   []byte
+		want *api.Node
+	}{
+		{"wrapped", []byte{10}, low},
+		{"wrapped upper", []byte{200}, low},
+		{"inclusive", []byte{64}, low},
+		{"middle", []byte{128}, high},
+		{"exclusive", []byte{65}, high},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.get(tt.id); got != tt.want {
+				t.Errorf("locationCache.get(%v) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+
+	if !c.invalidate(low) || c.get([]byte{10}) != nil {
+		t.Errorf("locationCache.invalidate() kept entry for %v", low)
+	}
+	if c.invalidate(low) {
+		t.Errorf("locationCache.invalidate() removed an entry twice")
+	}
+
+	// Eviction drops the oldest entry.
+	c.put([]byte{192}, low)
+	c.put([]byte{0}, &api.Node{Id: []byte{32}, Addr: "other"})
+	if c.get([]byte{128}) != nil {
+		t.Errorf("locationCache.put() did not evict the oldest entry")
+	}
+
+	expiring := newLocationCache(2, 10*time.Millisecond)
+	expiring.put([]byte{64}, high)
+	if expiring.get([]byte{128}) != high {
+		t.Fatalf("locationCache.get() missed a fresh entry")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if expiring.get([]byte{128}) != nil || len(expiring.entries) != 0 {
+		t.Errorf("locationCache.get() returned an expired entry")
+	}
+}
+
+func TestNode_locationCache(t *testing.T) {
+	nodes := newTestRing(t, 3, func(cnf *Config) {
+		cnf.LocationCacheSize = 8
+	})
+	client := nodes[0]
+
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := client.Set(key, key); err != nil {
+			t.Fatalf("Set(%q) error = %v", key, err)
+		}
+		if got, err := client.Get(key); err != nil || string(got) != key {
+			t.Fatalf("Get(%q) = %q, %v", key, got, err)
+		}
+	}
+	stats := client.Metrics()
+	if stats["location_cache_hits"] == 0 {
+		t.Errorf("Metrics() = %v, want cache hits", stats)
+	}
+
+	// Point the cache at the wrong node; the redirect must evict it.
+	key := "stale"
+	owner := ringOwner(nodes, key)
+	var wrong *api.Node
+	for _, node := range nodes {
+		if !bytesEqual(node.Id, owner.Id) {
+			wrong = node.Node
+		}
+	}
+	client.locations.clear()
+	client.locations.put(wrong.Id, wrong) // claims the whole ring
+	for _, node := range nodes {
+		node.cnf.MaxForwardHops = 0
+	}
+	before := client.Metrics()["location_cache_invalidations"]
+	if err := client.Set(key, "v"); err != nil {
+		t.Fatalf("Set(%q) error = %v", key, err)
+	}
+	if client.Metrics()["location_cache_invalidations"] <= before {
+		t.Errorf("redirect did not invalidate the cached owner")
+	}
+	if got := client.locations.get(GetHashID(key)); got != nil && bytesEqual(got.Id, wrong.Id) {
+		t.Errorf("cache still maps %q to %s", key, wrong.Addr)
+	}
+}
+
+func TestNode_locationCacheForwarded(t *testing.T) {
+	nodes := newTestRing(t, 3, func(cnf *Config) {
+		cnf.LocationCacheSize = 8
+		cnf.LocationCacheTTL = 50 * time.Millisecond
+	})
+	client := nodes[0]
+	key := "stale"
+	owner := ringOwner(nodes, key)
+	var wrong *api.Node
+	for _, node := range nodes {
+		if !bytesEqual(node.Id, owner.Id) {
+			wrong = node.Node
+		}
+	}
+
+	// Forwarded requests succeed, so only the ttl drops the stale entry.
+	client.locations.put(wrong.Id, wrong) // claims the whole ring
+	if err := client.Set(key, "v"); err != nil {
+		t.Fatalf("Set(%q) error = %v", key, err)
+	}
+	if got := client.locations.get(GetHashID(key)); got == nil || !bytesEqual(got.Id, wrong.Id) {
+		t.Fatalf("cache maps %q to %v before the ttl, want %s", key, got, wrong.Addr)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got, err := client.Get(key); err != nil || string(got) != "v" {
+		t.Fatalf("Get(%q) = %q, %v", key, got, err)
+	}
+	if got := client.locations.get(GetHashID(key)); got == nil || !bytesEqual(got.Id, owner.Id) {
+		t.Errorf("cache maps %q to %v after the ttl, want %s", key, got, owner.Addr)
+	}
+}
@@ -0,0 +1,149 @@
+package boopy
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ERR_CHANGES_TRUNCATED = errors.New("changes have been dropped from the log")
+
+// changeBatch is the most changes read from the log under one lock.
+const changeBatch = 256
+
+// changeLog keeps the latest storage mutations of a node in a ring buffer,
+// numbered from 1 in the order they were applied.
+type changeLog struct {
+	mtx     sync.Mutex
+	entries []*api.Change
+	next    uint64        // sequence number of the next change
+	added   chan struct{} // closed and replaced whenever changes are added
+}
+
+func newChangeLog(size int) *changeLog {
+	return &changeLog{
+		entries: make([]*api.Change, size),
+		next:    1,
+		added:   make(chan struct{}),
+	}
+}
+
+func (l *changeLog) append(changes ...*api.Change) {
+	if len(l.entries) == 0 || len(changes) == 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for _, c := range changes {
+		c.Seq = l.next
+		c.Time = now
+		l.entries[(c.Seq-1)%uint64(len(l.entries))] = c
+		l.next++
+	}
+	close(l.added)
+	l.added = make(chan struct{})
+}
+
+// oldest returns the sequence number of the oldest change kept.
+func (l *changeLog) oldest() uint64 {
+	if size := uint64(len(l.entries)); l.next > size {
+		return l.next - size
+	}
+	return 1
+}
+
+// since returns up to limit changes starting at sequence number from, 0 for
+// the oldest kept, and a channel closed once more changes are added.
+func (l *changeLog) since(from uint64, limit int) ([]*api.Change, <-chan struct{}, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if from == 0 {
+		from = l.oldest()
+	}
+	if from < l.oldest() {
+		return nil, nil, ERR_CHANGES_TRUNCATED
+	}
+
+	var changes []*api.Change
+	for seq := from; seq < l.next && len(changes) < limit; seq++ {
+		changes = append(changes, l.entries[(seq-1)%uint64(len(l.entries))])
+	}
+	return changes, l.added, nil
+}
+
+// record adds changes to the node's log. Must be called with stMtx held so
+// the log follows the order changes were applied to storage.
+func (n *Node) record(typ api.Change_Type, changes ...*api.KV) {
+	if len(changes) == 0 {
+		return
+	}
+	entries := make([]*api.Change, len(changes))
+	for i, kv := range changes {
+		value := kv.Value
+		if kv.Type != api.KV_STRING {
+			value, _ = renderValue(kv) // counters and sets are logged as read
+		}
+		entries[i] = &api.Change{
+			Type:      typ,
+			Namespace: kv.Namespace,
+			Key:       kv.Key,
+			Value:     value,
+			Expires:   kv.Expires,
+		}
+	}
+	n.changes.append(entries...)
+}
+
+// recordKeys records changes to keys of one namespace, without values.
+func (n *Node) recordKeys(typ api.Change_Type, ns string, keys ...string) {
+	kvs := make([]*api.KV, len(keys))
+	for i, key := range keys {
+		kvs[i] = &api.KV{Namespace: ns, Key: key}
+	}
+	n.record(typ, kvs...)
+}
+
+// Changes returns up to limit changes from this node's log, starting at
+// sequence number from, 0 for the oldest kept.
+func (n *Node) Changes(from uint64, limit int) ([]*api.Change, error) {
+	changes, _, err := n.changes.since(from, limit)
+	return changes, err
+}
+
+func (n *Node) XChanges(req *api.ChangesRequest, stream api.Chord_XChangesServer) error {
+	next := req.From
+	for {
+		changes, added, err := n.changes.since(next, changeBatch)
+		if err == ERR_CHANGES_TRUNCATED {
+			return status.Error(codes.OutOfRange, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if err := stream.Send(c); err != nil {
+				return err
+			}
+			next = c.Seq + 1
+		}
+		if len(changes) > 0 {
+			continue
+		}
+		if !req.Follow {
+			return nil
+		}
+
+		select {
+		case <-added:
+		case <-stream.Context().Done():
+			return nil
+		case <-n.shutdownCh:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package boopy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_changeLog(t *testing.T) {
+	log := newChangeLog(3)
+	for i := 0; i < 5; i++ {
+		log.append(&api.Change{Key: fmt.Sprintf("key%d", i)})
+	}
+
+	tests := []struct {
+		name    string
+		from    uint64
+		limit   int
+		want    []uint64
+		wantErr error
+	}{
+		{"oldest kept", 0, 10, []uint64{3, 4, 5}, nil},
+		{"dropped", 2, 10, nil, ERR_CHANGES_TRUNCATED},
+		{"middle", 4, 10, []uint64{4, 5}, nil},
+		{"limit", 3, 2, []uint64{3, 4}, nil},
+		{"future", 6, 10, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := log.since(tt.from, tt.limit)
+			if err != tt.wantErr {
+				t.Fatalf("since() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("since() returned %d changes, want %d", len(got), len(tt.want))
+			}
+			for i, c := range got {
+				if c.Seq != tt.want[i] || c.Key != fmt.Sprintf("key%d", c.Seq-1) {
+					t.Errorf("since()[%d] = %d %s, want %d", i, c.Seq, c.Key, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		log := newChangeLog(0)
+		log.append(&api.Change{Key: "key"})
+		if got, _, err := log.since(0, 10); err != nil || len(got) != 0 {
+			t.Errorf("since() = %v, %v, want nothing", got, err)
+		}
+	})
+}
+
+// changeTypes lists the types of changes to key in order.
+func changeTypes(changes []*api.Change, key string) []api.Change_Type {
+	var types []api.Change_Type
+	for _, c := range changes {
+		if c.Key == key {
+			types = append(types, c.Type)
+		}
+	}
+	return types
+}
+
+func TestNode_Changes(t *testing.T) {
+	nodes := newTestRing(t, 1, nil)
+	node := nodes[0]
+	for i := 0; i < 20; i++ {
+		if err := node.Set(fmt.Sprintf("key%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := node.Delete("key0"); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := node.Changes(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 21 {
+		t.Fatalf("Changes() returned %d changes, want 21", len(changes))
+	}
+	for i, c := range changes {
+		if c.Seq != uint64(i+1) || c.Time == 0 {
+			t.Errorf("Changes()[%d] seq = %d time = %d", i, c.Seq, c.Time)
+		}
+	}
+	if got := changeTypes(changes, "key0"); len(got) != 2 || got[0] != api.Change_SET || got[1] != api.Change_DELETE {
+		t.Errorf("key0 changes = %v, want SET DELETE", got)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		client, err := node.transport.(*GrpcTransport).getConn(node.Node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		changes, _ := node.Changes(0, 1000)
+		from := changes[len(changes)-1].Seq - 1
+		stream, err := client.XChanges(ctx, &api.ChangesRequest{From: from, Follow: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := node.Set("late", "v"); err != nil {
+			t.Fatal(err)
+		}
+		for seq := from; seq <= from+2; seq++ {
+			c, err := stream.Recv()
+			if err != nil || c.Seq != seq {
+				t.Fatalf("Recv() = %v, %v, want seq %d", c, err, seq)
+			}
+		}
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		logged, _ := node.Changes(0, 1000)
+		next := logged[len(logged)-1].Seq + 1
+		joined, err := NewNode(testConfig(t, "2"), node.Node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			joined.transport.Stop()
+			close(joined.shutdownCh)
+		})
+		for round := 0; round < 4; round++ {
+			node.stabilize()
+			joined.stabilize()
+		}
+
+		in, _ := joined.Changes(0, 100)
+		out, _ := node.Changes(next, 100)
+		if len(in) == 0 || len(in) != len(out) {
+			t.Fatalf("transferred %d keys in, %d out", len(in), len(out))
+		}
+		for _, c := range in {
+			if got := changeTypes(out, c.Key); c.Type != api.Change_TRANSFER_IN || len(got) != 1 || got[0] != api.Change_TRANSFER_OUT {
+				t.Errorf("%s moved as %v, out %v", c.Key, c.Type, got)
+			}
+		}
+	})
+}
+
+func TestNode_XChanges_truncated(t *testing.T) {
+	nodes := newTestRing(t, 1, func(cnf *Config) {
+		cnf.ChangeLogSize = 2
+	})
+	for i := 0; i < 3; i++ {
+		if err := nodes[0].Set(fmt.Sprintf("key%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client, err := nodes[0].transport.(*GrpcTransport).getConn(nodes[0].Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		from uint64
+		want codes.Code
+	}{
+		{1, codes.OutOfRange},
+		{2, codes.OK},
+	}
+	for _, tt := range tests {
+		stream, err := client.XChanges(context.Background(), &api.ChangesRequest{From: tt.from})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != tt.want {
+			t.Errorf("XChanges(%d) error = %v, want %v", tt.from, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+// Package client talks to a boopy ring over the KeyValue gRPC service. Any
+// node of the ring can be dialed; it routes requests to the owners of their
+// keys.
+package client
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ERR_KEY_NOT_FOUND    = errors.New("key not found")
+	ERR_VERSION_MISMATCH = errors.New("key changed since the version given")
+	ERR_SIBLINGS         = errors.New("key has concurrent values, read them with Lookup")
+)
+
+// DefaultTimeout bounds each call made by a Client from Dial.
+const DefaultTimeout = 5 * time.Second
+
+// Client is a connection to one node of a ring.
+type Client struct {
+	Timeout time.Duration // per unary call, 0 for no limit
+
+	conn *grpc.ClientConn
+	kv   api.KeyValueClient
+}
+
+// Dial connects to the KeyValue address of a node. Without options the
+// connection is plaintext, as the service is unless given a certificate.
+func Dial(addr string, opts ...grpc.DialOption) (*Client, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithInsecure()}
+	}
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Timeout: DefaultTimeout, conn: conn, kv: api.NewKeyValueClient(conn)}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), c.Timeout)
+}
+
+// clientError turns the status codes of the KeyValue service back into the
+// errors callers compare against.
+func clientError(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return ERR_KEY_NOT_FOUND
+	case codes.Aborted:
+		return ERR_VERSION_MISMATCH
+	}
+	return err
+}
+
+// Get reads a key of a namespace, failing with ERR_SIBLINGS if it has
+// concurrent values.
+func (c *Client) Get(ns, key string) ([]byte, error) {
+	res, err := c.Lookup(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Siblings) > 1 {
+		return nil, ERR_SIBLINGS
+	}
+	return res.Value, nil
+}
+
+// Lookup reads a key with its version, expiry, flags and siblings.
+func (c *Client) Lookup(ns, key string) (*api.GetResponse, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+	res, err := c.kv.Get(ctx, &api.GetRequest{Namespace: ns, Key: key})
+	return res, clientError(err)
+}
+
+// Set writes a key of a namespace. A zero ttl uses the namespace default.
+func (c *Client) Set(ns, key, value string, ttl time.Duration) error {
+	return c.Write(&api.SetRequest{Namespace: ns, Key: key, Value: value, Ttl: int64(ttl)})
+}
+
+// CompareAndSet writes a key only if its version is still version, as
+// returned by Lookup, or if it is absent when version is 0. It fails with
+// ERR_VERSION_MISMATCH otherwise.
+func (c *Client) CompareAndSet(ns, key, value string, ttl time.Duration, version int64) error {
+	return c.Write(&api.SetRequest{
+		Namespace: ns, Key: key, Value: value, Ttl: int64(ttl),
+		Conditional: true, Expected: version,
+	})
+}
+
+// Write sends a write with every option of SetRequest, such as the causal
+// context of a sibling read or flags.
+func (c *Client) Write(req *api.SetRequest) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	_, err := c.kv.Set(ctx, req)
+	return clientError(err)
+}
+
+// Delete removes a key of a namespace.
+func (c *Client) Delete(ns, key string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+	_, err := c.kv.Delete(ctx, &api.DeleteRequest{Namespace: ns, Key: key})
+	return clientError(err)
+}
+
+// Result is the outcome for one key of MultiGet, MultiSet or MultiDelete.
+type Result struct {
+	Key   string
+	Value []byte // only set by MultiGet
+	Err   error
+}
+
+// MultiGet reads many keys of a namespace. Results are in the order of keys.
+func (c *Client) MultiGet(ns string, keys []string) ([]Result, error) {
+	return c.batch(api.KVBatchRequest_GET, ns, keyPairs(keys), 0)
+}
+
+// MultiSet writes many pairs of a namespace. Only Key and Value of each pair
+// are used. A zero ttl uses the namespace default.
+func (c *Client) MultiSet(ns string, pairs []*api.KV, ttl time.Duration) ([]Result, error) {
+	return c.batch(api.KVBatchRequest_SET, ns, pairs, ttl)
+}
+
+// MultiDelete removes many keys of a namespace.
+func (c *Client) MultiDelete(ns string, keys []string) ([]Result, error) {
+	return c.batch(api.KVBatchRequest_DELETE, ns, keyPairs(keys), 0)
+}
+
+func keyPairs(keys []string) []*api.KV {
+	pairs := make([]*api.KV, len(keys))
+	for i, key := range keys {
+		pairs[i] = &api.KV{Key: key}
+	}
+	return pairs
+}
+
+func (c *Client) batch(op api.KVBatchRequest_Op, ns string, pairs []*api.KV, ttl time.Duration) ([]Result, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+	res, err := c.kv.Batch(ctx, &api.KVBatchRequest{Op: op, Namespace: ns, Pairs: pairs, Ttl: int64(ttl)})
+	if err != nil {
+		return nil, clientError(err)
+	}
+	results := make([]Result, len(res.Results))
+	for i, result := range res.Results {
+		results[i] = Result{Key: result.Key, Value: result.Value}
+		switch result.Error {
+		case "":
+		case ERR_KEY_NOT_FOUND.Error():
+			results[i].Err = ERR_KEY_NOT_FOUND
+		default:
+			results[i].Err = errors.New(result.Error)
+		}
+	}
+	return results, nil
+}
+
+// Scan returns one page of keys in ring order and the cursor for the next
+// page, which is empty once the whole ring has been read.
+func (c *Client) Scan(req *api.KVScanRequest) ([]*api.KV, string, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+	res, err := c.kv.Scan(ctx, req)
+	if err != nil {
+		return nil, "", clientError(err)
+	}
+	return res.Keys, res.Cursor, nil
+}
+
+// Watcher delivers the changes to watched keys.
+type Watcher struct {
+	stream api.KeyValue_WatchClient
+	cancel context.CancelFunc
+	events chan *api.WatchEvent
+	err    error
+}
+
+// Watch starts watching key in a namespace, or every key starting with key
+// when prefix is set. The node dialed follows the keys around the ring.
+func (c *Client) Watch(ns, key string, prefix bool) (*Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := c.kv.Watch(ctx, &api.WatchRequest{Namespace: ns, Key: key, Prefix: prefix})
+	if err != nil {
+		cancel()
+		return nil, clientError(err)
+	}
+	w := &Watcher{stream: stream, cancel: cancel, events: make(chan *api.WatchEvent)}
+	go w.run()
+	return w, nil
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// watch ends, after which Err tells why.
+func (w *Watcher) Events() <-chan *api.WatchEvent {
+	return w.events
+}
+
+// Err returns the error that ended the watch, nil if it was closed.
+func (w *Watcher) Err() error {
+	return w.err
+}
+
+// Close stops the watch.
+func (w *Watcher) Close() {
+	w.cancel()
+}
+
+func (w *Watcher) run() {
+	defer close(w.events)
+	for {
+		ev, err := w.stream.Recv()
+		if err != nil {
+			if err != io.EOF && status.Code(err) != codes.Canceled {
+				w.err = err
+			}
+			return
+		}
+		select {
+		case w.events <- ev:
+		case <-w.stream.Context().Done():
+			return
+		}
+	}
+}
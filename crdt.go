@@ -0,0 +1,427 @@
+package boopy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+var ERR_WRONG_TYPE = errors.New("key holds a value of another type")
+
+// Counters and sets are stored as state that replicas merge rather than
+// replace, so copies that took different updates while apart converge once
+// they meet again through replication, handoff or anti-entropy. Updates are
+// applied by the owner, which tags them with its node id.
+
+// encodeState marshals a counter or set with map keys sorted, so that equal
+// states are stored, and hashed by anti-entropy, alike.
+func encodeState(state proto.Message) (string, error) {
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(state); err != nil {
+		return "", err
+	}
+	return string(buf.Bytes()), nil
+}
+
+func decodeCounter(value string) (*api.PNCounter, error) {
+	counter := new(api.PNCounter)
+	if err := proto.Unmarshal([]byte(value), counter); err != nil {
+		return nil, err
+	}
+	if counter.Increments == nil {
+		counter.Increments = make(map[string]uint64)
+	}
+	if counter.Decrements == nil {
+		counter.Decrements = make(map[string]uint64)
+	}
+	return counter, nil
+}
+
+func counterValue(counter *api.PNCounter) int64 {
+	var value int64
+	for _, count := range counter.Increments {
+		value += int64(count)
+	}
+	for _, count := range counter.Decrements {
+		value -= int64(count)
+	}
+	return value
+}
+
+// mergeCounters keeps the largest count seen from each node.
+func mergeCounters(a, b *api.PNCounter) {
+	for node, count := range b.Increments {
+		if count > a.Increments[node] {
+			a.Increments[node] = count
+		}
+	}
+	for node, count := range b.Decrements {
+		if count > a.Decrements[node] {
+			a.Decrements[node] = count
+		}
+	}
+}
+
+func decodeSet(value string) (*api.ORSet, error) {
+	set := new(api.ORSet)
+	if err := proto.Unmarshal([]byte(value), set); err != nil {
+		return nil, err
+	}
+	if set.Adds == nil {
+		set.Adds = make(map[string]*api.ORSetTags)
+	}
+	return set, nil
+}
+
+func setMembers(set *api.ORSet) []string {
+	members := make([]string, 0, len(set.Adds))
+	for member := range set.Adds {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// addMember adds member to set with a tag no other add uses.
+func addMember(set *api.ORSet, member, tag string) {
+	tags, ok := set.Adds[member]
+	if !ok {
+		tags = new(api.ORSetTags)
+		set.Adds[member] = tags
+	}
+	tags.Tags = insertSorted(tags.Tags, tag)
+}
+
+// removeMember removes the adds of member seen so far. Adds made
+// concurrently elsewhere carry other tags and survive the merge. The tags
+// removed are kept for good, see api.ORSet.
+func removeMember(set *api.ORSet, member string) {
+	for _, tag := range set.Adds[member].GetTags() {
+		set.Removed = insertSorted(set.Removed, tag)
+	}
+	delete(set.Adds, member)
+}
+
+// mergeSets adds to a the adds and removes of b.
+func mergeSets(a, b *api.ORSet) {
+	for _, tag := range b.Removed {
+		a.Removed = insertSorted(a.Removed, tag)
+	}
+	for member, tags := range b.Adds {
+		for _, tag := range tags.Tags {
+			addMember(a, member, tag)
+		}
+	}
+	for member, tags := range a.Adds {
+		live := tags.Tags[:0]
+		for _, tag := range tags.Tags {
+			if i := sort.SearchStrings(a.Removed, tag); i == len(a.Removed) || a.Removed[i] != tag {
+				live = append(live, tag)
+			}
+		}
+		if tags.Tags = live; len(live) == 0 {
+			delete(a.Adds, member)
+		}
+	}
+}
+
+// insertSorted adds s to the sorted slice ss unless it is there already.
+func insertSorted(ss []string, s string) []string {
+	i := sort.SearchStrings(ss, s)
+	if i < len(ss) && ss[i] == s {
+		return ss
+	}
+	ss = append(ss, "")
+	copy(ss[i+1:], ss[i:])
+	ss[i] = s
+	return ss
+}
+
+// mergeStates merges two stored states of a counter or set.
+func mergeStates(typ api.KV_Type, a, b string) (string, error) {
+	switch typ {
+	case api.KV_COUNTER:
+		left, err := decodeCounter(a)
+		if err != nil {
+			return "", err
+		}
+		right, err := decodeCounter(b)
+		if err != nil {
+			return "", err
+		}
+		mergeCounters(left, right)
+		return encodeState(left)
+	case api.KV_SET:
+		left, err := decodeSet(a)
+		if err != nil {
+			return "", err
+		}
+		right, err := decodeSet(b)
+		if err != nil {
+			return "", err
+		}
+		mergeSets(left, right)
+		return encodeState(left)
+	}
+	return "", ERR_WRONG_TYPE
+}
+
+// renderValue returns a stored value as reads see it: counters as a decimal
+// number and sets as a JSON list of members.
+func renderValue(kv *api.KV) (string, error) {
+	switch kv.Type {
+	case api.KV_COUNTER:
+		counter, err := decodeCounter(kv.Value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(counterValue(counter), 10), nil
+	case api.KV_SET:
+		set, err := decodeSet(kv.Value)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(setMembers(set))
+		return string(data), err
+	}
+	return kv.Value, nil
+}
+
+// readValue returns a stored value as callers expecting a single value
+// read it.
+func readValue(settings *api.Namespace, kv *api.KV) (string, error) {
+	if kv.Type != api.KV_STRING {
+		return renderValue(kv)
+	}
+	return singleValue(settings, kv.Value)
+}
+
+// mergeStored merges a counter or set sent by another node into our copy.
+// The merged state takes the newer version, or a newer one still if it
+// differs from both, so that anti-entropy carries it back. Must be called
+// with stMtx held.
+func (n *Node) mergeStored(old, kv *api.KV) (*api.KV, error) {
+	value, err := mergeStates(kv.Type, old.Value, kv.Value)
+	if err != nil {
+		return nil, err
+	}
+	merged := *kv
+	merged.Value = value
+	if old.Version > kv.Version {
+		merged.Version, merged.Expires = old.Version, old.Expires
+	}
+	if value != old.Value && value != kv.Value {
+		merged.Version = n.clock.update(merged.Version)
+	}
+	if value == old.Value && merged.Version == old.Version {
+		return nil, nil // nothing new
+	}
+	return &merged, n.storage.Put(&merged)
+}
+
+// applyUpdate applies req to our copy of a counter or set and returns the
+// stored pair. Must be called with stMtx held.
+func (n *Node) applyUpdate(settings *api.Namespace, req *api.UpdateRequest) (*api.KV, *api.UpdateResponse, error) {
+	if err := n.txns.check(req.Namespace, req.Key); err != nil {
+		return nil, nil, err
+	}
+	kv := &api.KV{Namespace: req.Namespace, Key: req.Key, Type: req.Type}
+	if old, err := n.storage.Lookup(req.Namespace, req.Key); err == nil && !old.Deleted {
+		if old.Type != req.Type {
+			return nil, nil, ERR_WRONG_TYPE
+		}
+		kv.Value, kv.Expires = old.Value, old.Expires
+	} else if expires := expiry(settings, req.Ttl); !expires.IsZero() {
+		kv.Expires = expires.UnixNano()
+	}
+
+	node := hex.EncodeToString(n.Id)
+	res := new(api.UpdateResponse)
+	var err error
+	switch req.Type {
+	case api.KV_COUNTER:
+		var counter *api.PNCounter
+		if counter, err = decodeCounter(kv.Value); err != nil {
+			return nil, nil, err
+		}
+		if req.Delta >= 0 {
+			counter.Increments[node] += uint64(req.Delta)
+		} else {
+			counter.Decrements[node] += uint64(-req.Delta)
+		}
+		res.Counter = counterValue(counter)
+		kv.Value, err = encodeState(counter)
+	case api.KV_SET:
+		var set *api.ORSet
+		if set, err = decodeSet(kv.Value); err != nil {
+			return nil, nil, err
+		}
+		for _, member := range req.Remove {
+			removeMember(set, member)
+		}
+		for _, member := range req.Add {
+			addMember(set, member, node+":"+strconv.FormatInt(n.clock.now(), 16))
+		}
+		res.Members = setMembers(set)
+		kv.Value, err = encodeState(set)
+	default:
+		return nil, nil, ERR_WRONG_TYPE
+	}
+	if err == nil {
+		err = n.checkQuota(settings, req.Key, kv.Value)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	kv.Version = n.nextVersion(req.Namespace, req.Key)
+	return kv, res, n.storage.Put(kv)
+}
+
+func (n *Node) XUpdate(ctx context.Context, req *api.UpdateRequest) (*api.UpdateResponse, error) {
+	owner, err := n.misrouted(namespacedKey(req.Namespace, req.Key), req.Hops)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		forward := *req
+		forward.Hops++
+		return n.transport.Update(owner, &forward)
+	}
+
+	settings, err := n.namespaceSettings(req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	n.stMtx.Lock()
+	kv, res, err := n.applyUpdate(settings, req)
+	if err == nil {
+		n.record(api.Change_SET, kv)
+		if value, err := renderValue(kv); err == nil {
+			n.publishSet(req.Namespace, req.Key, value)
+		}
+	}
+	n.stMtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	n.replicate(settings, kv)
+	return res, nil
+}
+
+func (n *Node) update(req *api.UpdateRequest) (*api.UpdateResponse, error) {
+	node, err := n.locate(namespacedKey(req.Namespace, req.Key))
+	if err != nil {
+		return nil, err
+	}
+	var res *api.UpdateResponse
+	err = n.followRedirects(node, func(node *api.Node) error {
+		res, err = n.transport.Update(node, req)
+		return err
+	})
+	if err != nil && status.Convert(err).Message() == ERR_WRONG_TYPE.Error() {
+		return nil, ERR_WRONG_TYPE
+	}
+	return res, err
+}
+
+// read reads a counter or set, failing with ERR_WRONG_TYPE if key holds
+// something else.
+func (n *Node) read(ns, key string, typ api.KV_Type) ([]byte, error) {
+	res, err := n.lookup(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	if res.Type != typ {
+		return nil, ERR_WRONG_TYPE
+	}
+	return res.Value, nil
+}
+
+// Increment adds delta, which may be negative, to a counter and returns its
+// new value. Missing keys count from 0.
+func (n *Node) Increment(key string, delta int64) (int64, error) {
+	return n.IncrementIn("", key, delta, 0)
+}
+
+// IncrementIn is Increment for a namespace. A ttl, or the namespace
+// default, applies when the counter is created.
+func (n *Node) IncrementIn(ns, key string, delta int64, ttl time.Duration) (int64, error) {
+	res, err := n.update(&api.UpdateRequest{
+		Namespace: ns, Key: key, Type: api.KV_COUNTER, Delta: delta, Ttl: int64(ttl),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.Counter, nil
+}
+
+// Counter reads a counter written with Increment.
+func (n *Node) Counter(key string) (int64, error) {
+	return n.CounterIn("", key)
+}
+
+func (n *Node) CounterIn(ns, key string) (int64, error) {
+	val, err := n.read(ns, key, api.KV_COUNTER)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(val), 10, 64)
+}
+
+// Add adds members to a set and returns its members. Members added and
+// removed concurrently on different nodes stay in the set.
+func (n *Node) Add(key string, members ...string) ([]string, error) {
+	return n.AddIn("", key, 0, members...)
+}
+
+// AddIn is Add for a namespace. A ttl, or the namespace default, applies
+// when the set is created.
+func (n *Node) AddIn(ns, key string, ttl time.Duration, members ...string) ([]string, error) {
+	res, err := n.update(&api.UpdateRequest{
+		Namespace: ns, Key: key, Type: api.KV_SET, Add: members, Ttl: int64(ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Members, nil
+}
+
+// Remove removes members from a set and returns its members.
+func (n *Node) Remove(key string, members ...string) ([]string, error) {
+	return n.RemoveIn("", key, members...)
+}
+
+func (n *Node) RemoveIn(ns, key string, members ...string) ([]string, error) {
+	res, err := n.update(&api.UpdateRequest{
+		Namespace: ns, Key: key, Type: api.KV_SET, Remove: members,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Members, nil
+}
+
+// Members reads the members of a set written with Add, sorted.
+func (n *Node) Members(key string) ([]string, error) {
+	return n.MembersIn("", key)
+}
+
+func (n *Node) MembersIn(ns, key string) ([]string, error) {
+	val, err := n.read(ns, key, api.KV_SET)
+	if err != nil {
+		return nil, err
+	}
+	var members []string
+	err = json.Unmarshal(val, &members)
+	return members, err
+}
@@ -0,0 +1,150 @@
+package boopy
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jseam2/boopy/api"
+)
+
+func Test_mergeSets(t *testing.T) {
+	base := &api.ORSet{Adds: map[string]*api.ORSetTags{"x": {Tags: []string{"a1"}}}}
+	clone := func() *api.ORSet {
+		set, _ := decodeSet(mustEncode(t, base))
+		return set
+	}
+
+	tests := []struct {
+		name        string
+		left, right func(*api.ORSet)
+		want        []string
+	}{
+		{"add on both", func(s *api.ORSet) { addMember(s, "y", "a2") }, func(s *api.ORSet) { addMember(s, "z", "b1") }, []string{"x", "y", "z"}},
+		{"remove on one", func(s *api.ORSet) {}, func(s *api.ORSet) { removeMember(s, "x") }, []string{}},
+		{"add beats concurrent remove", func(s *api.ORSet) { addMember(s, "x", "a2") }, func(s *api.ORSet) { removeMember(s, "x") }, []string{"x"}},
+		{"remove after add", func(s *api.ORSet) { removeMember(s, "x"); addMember(s, "x", "a2"); removeMember(s, "x") }, func(s *api.ORSet) {}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := clone(), clone()
+			tt.left(left)
+			tt.right(right)
+			forward, backward := clone(), clone()
+			mergeSets(forward, left)
+			mergeSets(forward, right)
+			mergeSets(backward, right)
+			mergeSets(backward, left)
+			if got := setMembers(forward); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged members = %v, want %v", got, tt.want)
+			}
+			if mustEncode(t, forward) != mustEncode(t, backward) {
+				t.Errorf("merge depends on order: %v and %v", forward, backward)
+			}
+		})
+	}
+}
+
+func mustEncode(t *testing.T, set *api.ORSet) string {
+	t.Helper()
+	value, err := encodeState(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func Test_mergeCounters(t *testing.T) {
+	a := &api.PNCounter{Increments: map[string]uint64{"n1": 5, "n2": 1}, Decrements: map[string]uint64{}}
+	b := &api.PNCounter{Increments: map[string]uint64{"n1": 3, "n2": 4}, Decrements: map[string]uint64{"n1": 2}}
+	mergeCounters(a, b)
+	if got := counterValue(a); got != 7 {
+		t.Errorf("merged counter = %d, want 7", got)
+	}
+}
+
+func TestNode_Increment(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "limits", ReplicationFactor: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			if _, err := node.IncrementIn("limits", "user1", 2, 0); err != nil {
+				t.Error(err)
+			}
+		}(nodes[i%3])
+	}
+	wg.Wait()
+	if got, err := nodes[1].IncrementIn("limits", "user1", -10, 0); err != nil || got != 50 {
+		t.Errorf("IncrementIn() = %d, %v, want 50", got, err)
+	}
+	if got, err := nodes[2].CounterIn("limits", "user1"); err != nil || got != 50 {
+		t.Errorf("CounterIn() = %d, %v, want 50", got, err)
+	}
+	if val, err := nodes[0].GetQuorum("limits", "user1"); err != nil || string(val) != "50" {
+		t.Errorf("GetQuorum() = %q, %v, want 50", val, err)
+	}
+
+	if err := nodes[0].Set("plain", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes[1].Increment("plain", 1); err != ERR_WRONG_TYPE {
+		t.Errorf("Increment() of a string error = %v, want %v", err, ERR_WRONG_TYPE)
+	}
+	if _, err := nodes[1].MembersIn("limits", "user1"); err != ERR_WRONG_TYPE {
+		t.Errorf("MembersIn() of a counter error = %v, want %v", err, ERR_WRONG_TYPE)
+	}
+}
+
+func TestNode_setsConverge(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "tags", ReplicationFactor: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes[0].AddIn("tags", "post", 0, "go", "rust"); err != nil {
+		t.Fatal(err)
+	}
+	if members, err := nodes[1].RemoveIn("tags", "post", "rust"); err != nil || !reflect.DeepEqual(members, []string{"go"}) {
+		t.Fatalf("RemoveIn() = %v, %v, want [go]", members, err)
+	}
+
+	// A replica took an add the owner never saw, at the same version.
+	owner := ringOwner(nodes, namespacedKey("tags", "post"))
+	for _, node := range nodes {
+		if bytesEqual(node.Id, owner.Id) {
+			continue
+		}
+		node.stMtx.Lock()
+		kv, _ := node.storage.Lookup("tags", "post")
+		set, _ := decodeSet(kv.Value)
+		addMember(set, "zig", fmt.Sprintf("%x:1", node.Id))
+		kv.Value = mustEncode(t, set)
+		node.storage.Put(kv)
+		node.stMtx.Unlock()
+		break
+	}
+
+	for round := 0; round < 2; round++ {
+		for _, node := range nodes {
+			node.antiEntropy()
+		}
+	}
+	for _, node := range nodes {
+		node.stMtx.RLock()
+		kv, err := node.storage.Lookup("tags", "post")
+		node.stMtx.RUnlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, _ := decodeSet(kv.Value)
+		if got := setMembers(set); !reflect.DeepEqual(got, []string{"go", "zig"}) {
+			t.Errorf("%s members = %v, want [go zig]", node.Addr, got)
+		}
+	}
+}
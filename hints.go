@@ -0,0 +1,251 @@
+package boopy
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ERR_HINTS_FULL = errors.New("too many hinted writes pending")
+
+// hint is a write accepted on behalf of an owner that could not be reached.
+// It carries the version picked when it was accepted, so replaying it late
+// never overwrites a newer write.
+type hint struct {
+	owner   *api.Node
+	req     *api.SetRequest
+	expires time.Time // zero when the write had no ttl
+	stored  time.Time
+}
+
+// hintStore keeps hints until they are replayed, saved to a file so that
+// they survive a restart.
+type hintStore struct {
+	mtx   sync.Mutex
+	path  string // where hints are saved, nowhere if empty
+	hints []*hint
+}
+
+// openHintStore returns a store saving hints to path, holding those saved
+// there before.
+func openHintStore(path string) (*hintStore, error) {
+	s := &hintStore{path: path}
+	if path == "" {
+		return s, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	saved := new(api.HintLog)
+	if err := proto.Unmarshal(data, saved); err != nil {
+		return nil, err
+	}
+	for _, h := range saved.Hints {
+		loaded := &hint{owner: h.Owner, req: h.Request, stored: time.Unix(0, h.Stored)}
+		if h.Expires != 0 {
+			loaded.expires = time.Unix(0, h.Expires)
+		}
+		s.hints = append(s.hints, loaded)
+	}
+	return s, nil
+}
+
+// save writes every pending hint to the store's file, replacing it whole.
+// Must be called with mtx held.
+func (s *hintStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	saved := &api.HintLog{Hints: make([]*api.Hint, len(s.hints))}
+	for i, h := range s.hints {
+		saved.Hints[i] = &api.Hint{Owner: h.owner, Request: h.req, Stored: h.stored.UnixNano()}
+		if !h.expires.IsZero() {
+			saved.Hints[i].Expires = h.expires.UnixNano()
+		}
+	}
+	data, err := proto.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// add keeps h once it is saved.
+func (s *hintStore) add(h *hint, max int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.hints) >= max {
+		return ERR_HINTS_FULL
+	}
+	s.hints = append(s.hints, h)
+	if err := s.save(); err != nil {
+		s.hints = s.hints[:len(s.hints)-1]
+		return err
+	}
+	return nil
+}
+
+// pending returns every hint kept.
+func (s *hintStore) pending() []*hint {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]*hint(nil), s.hints...)
+}
+
+// remove drops hints that were replayed or given up on.
+func (s *hintStore) remove(done []*hint) error {
+	if len(done) == 0 {
+		return nil
+	}
+	drop := make(map[*hint]bool, len(done))
+	for _, h := range done {
+		drop[h] = true
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	kept := s.hints[:0]
+	for _, h := range s.hints {
+		if !drop[h] {
+			kept = append(kept, h)
+		}
+	}
+	s.hints = kept
+	return s.save()
+}
+
+func (s *hintStore) len() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.hints)
+}
+
+// unreachable reports whether err means the node called could not be
+// reached, rather than that it refused the request. Local failures, like a
+// peer presenting the wrong certificate or our transport shutting down, are
+// not: the owner would only fail the same way when the hint is replayed.
+func unreachable(err error) bool {
+	if conn, ok := err.(interface{ Origin() error }); ok {
+		err = conn.Origin() // dial failures wrapped by grpc
+	}
+	if _, ok := err.(net.Error); ok || err == context.DeadlineExceeded {
+		return true
+	}
+	st, ok := status.FromError(err)
+	return ok && (st.Code() == codes.Unavailable || st.Code() == codes.DeadlineExceeded)
+}
+
+// hintWrite keeps a write for owner, which could not be reached, to be
+// replayed later. It returns cause if the hint cannot be kept and saved.
+func (n *Node) hintWrite(owner *api.Node, ns, key, value string, ctx []byte, ttl time.Duration, cause error) error {
+	if n.cnf.MaxHints <= 0 || n.cnf.HintFile == "" {
+		return cause
+	}
+	now := time.Now()
+	h := &hint{
+		owner: owner,
+		req: &api.SetRequest{
+			Namespace: ns,
+			Key:       key,
+			Value:     value,
+			Context:   ctx,
+			Version:   n.clock.now(),
+		},
+		stored: now,
+	}
+	if ttl > 0 {
+		h.expires = now.Add(ttl)
+	}
+	if err := n.hints.add(h, n.cnf.MaxHints); err != nil {
+		if err != ERR_HINTS_FULL {
+			log.Println("error saving hint: ", key, err)
+		}
+		n.metrics.inc("hints_rejected")
+		return cause
+	}
+	log.Println("owner unreachable, keeping hint: ", owner.Addr, key, cause)
+	n.metrics.inc("hints_stored")
+	return nil
+}
+
+// replayHints sends pending hints to the current owner of each key: the
+// intended owner once it is back, or whichever node took over its range.
+// Hints for keys locked by a transaction wait for a later replay; those
+// older than HintWindow are dropped.
+func (n *Node) replayHints() {
+	hints := n.hints.pending()
+	if len(hints) == 0 {
+		return
+	}
+
+	down := make(map[string]bool)
+	var done []*hint
+	for _, h := range hints {
+		if time.Since(h.stored) > n.cnf.HintWindow {
+			n.metrics.inc("hints_dropped")
+			done = append(done, h)
+			continue
+		}
+		req := *h.req
+		if !h.expires.IsZero() {
+			req.Ttl = int64(time.Until(h.expires))
+			if req.Ttl <= 0 {
+				done = append(done, h) // expired before it could be delivered
+				continue
+			}
+		}
+
+		owner, err := n.locate(namespacedKey(req.Namespace, req.Key))
+		if err != nil || down[owner.Addr] {
+			continue
+		}
+		err = n.followRedirects(owner, func(node *api.Node) error {
+			owner = node
+			return n.transport.SetKey(node, &req)
+		})
+		switch {
+		case err == nil:
+			n.metrics.inc("hints_replayed")
+			done = append(done, h)
+		case err != ERR_TOO_MANY_REDIRECTS && unreachable(err):
+			down[owner.Addr] = true
+		case isKeyLocked(err):
+			// kept until the transaction holding the key settles
+		default:
+			log.Println("owner refused hinted write: ", owner.Addr, req.Key, err)
+			n.metrics.inc("hints_dropped")
+			done = append(done, h)
+		}
+	}
+	if err := n.hints.remove(done); err != nil {
+		log.Println("error saving hints: ", err)
+	}
+}
@@ -0,0 +1,240 @@
+package boopy
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// partitionTransport fails writes to one address while down is set.
+type partitionTransport struct {
+	Transport
+	addr string
+	down int32
+}
+
+func (pt *partitionTransport) SetKey(node *api.Node, req *api.SetRequest) error {
+	if atomic.LoadInt32(&pt.down) == 1 && node.Addr == pt.addr {
+		return status.Error(codes.Unavailable, "partitioned")
+	}
+	return pt.Transport.SetKey(node, req)
+}
+
+func Test_unreachable(t *testing.T) {
+	gt, err := NewGrpcTransport(testConfig(t, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, refused := gt.getConn(&api.Node{Addr: freeAddr(t)})
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dial", refused, true},
+		{"dial timeout", context.DeadlineExceeded, true},
+		{"unavailable", status.Error(codes.Unavailable, "gone"), true},
+		{"deadline", status.Error(codes.DeadlineExceeded, "slow"), true},
+		{"refused", status.Error(codes.Unknown, ERR_QUOTA_EXCEEDED.Error()), false},
+		{"redirect", redirectError(&api.Node{Addr: "x"}), false},
+		{"peer identity", ERR_PEER_IDENTITY, false},
+		{"shutdown", ERR_TRANSPORT_SHUTDOWN, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unreachable(tt.err); got != tt.want {
+				t.Errorf("unreachable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// hintFiles returns a function setting each config's HintFile to a new
+// file in dir.
+func hintFiles(dir string) func(*Config) {
+	return func(cnf *Config) {
+		cnf.HintFile = filepath.Join(dir, cnf.Id+".hints")
+	}
+}
+
+func TestNode_hintedHandoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boopy-hints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nodes := newTestRing(t, 3, func(cnf *Config) {
+		hintFiles(dir)(cnf)
+		cnf.MaxHints = 2
+	})
+	owner := nodes[2]
+	var keys []string
+	for i := 0; len(keys) < 3; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if bytesEqual(ringOwner(nodes, key).Id, owner.Id) {
+			keys = append(keys, key)
+		}
+	}
+	partition := &partitionTransport{Transport: nodes[0].transport, addr: owner.Addr, down: 1}
+	nodes[0].transport = partition
+
+	for _, key := range keys[:2] {
+		if err := nodes[0].Set(key, "hinted"); err != nil {
+			t.Fatalf("Set(%s) error = %v, want it hinted", key, err)
+		}
+	}
+	if err := nodes[0].Set(keys[2], "hinted"); !unreachable(err) {
+		t.Errorf("Set() past MaxHints error = %v, want the owner unreachable", err)
+	}
+	nodes[0].replayHints()
+	if got := nodes[0].hints.len(); got != 2 {
+		t.Fatalf("%d hints pending while the owner is down, want 2", got)
+	}
+
+	// A write made after the hint must survive its replay.
+	if err := nodes[1].Set(keys[1], "newer"); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&partition.down, 0)
+	nodes[0].replayHints()
+	if got := nodes[0].hints.len(); got != 0 {
+		t.Errorf("%d hints pending once the owner is back, want 0", got)
+	}
+
+	want := map[string]string{keys[0]: "hinted", keys[1]: "newer"}
+	for key, value := range want {
+		if val, err := nodes[1].Get(key); err != nil || string(val) != value {
+			t.Errorf("Get(%s) = %q, %v, want %q", key, val, err, value)
+		}
+	}
+	metrics := nodes[0].Metrics()
+	if metrics["hints_stored"] != 2 || metrics["hints_rejected"] != 1 || metrics["hints_replayed"] != 2 {
+		t.Errorf("hint metrics = %v", metrics)
+	}
+}
+
+func Test_hintStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boopy-hints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hints")
+
+	store, err := openHintStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(time.Hour)
+	kept := []*hint{
+		{owner: NewInode("1", "a:1"), req: &api.SetRequest{Key: "k1", Value: "v1", Version: 1}, stored: time.Now()},
+		{owner: NewInode("2", "a:2"), req: &api.SetRequest{Key: "k2", Value: "v2", Version: 2}, expires: expires, stored: time.Now()},
+	}
+	for _, h := range kept {
+		if err := store.add(h, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A restarted node finds the hints it acknowledged.
+	reopened, err := openHintStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reopened.pending()
+	if len(got) != 2 || got[0].req.Key != "k1" || got[1].owner.Addr != "a:2" || !got[1].expires.Equal(expires) || !got[0].expires.IsZero() {
+		t.Fatalf("reopened hints = %v, want the two added", got)
+	}
+	if err := reopened.remove(got[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if reopened, err = openHintStore(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.pending(); len(got) != 1 || got[0].req.Key != "k2" {
+		t.Errorf("hints after removing one = %v, want k2", got)
+	}
+
+	unsaved, err := openHintStore(filepath.Join(dir, "missing", "hints"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unsaved.add(kept[0], 10); err == nil || unsaved.len() != 0 {
+		t.Errorf("add() to an unwritable file = %v with %d hints, want an error and none kept", err, unsaved.len())
+	}
+}
+
+func TestNode_hintedHandoffWithoutFile(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	owner := nodes[1]
+	key := ""
+	for i := 0; key == ""; i++ {
+		if candidate := fmt.Sprintf("key%d", i); bytesEqual(ringOwner(nodes, candidate).Id, owner.Id) {
+			key = candidate
+		}
+	}
+	nodes[0].transport = &partitionTransport{Transport: nodes[0].transport, addr: owner.Addr, down: 1}
+	if err := nodes[0].Set(key, "lost"); !unreachable(err) {
+		t.Errorf("Set() without a hint file error = %v, want the owner unreachable", err)
+	}
+	if got := nodes[0].hints.len(); got != 0 {
+		t.Errorf("%d hints kept without a hint file, want 0", got)
+	}
+}
+
+func TestNode_hintReplayedAgainstLockedKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boopy-hints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nodes := newTestRing(t, 3, hintFiles(dir))
+	owner := nodes[2]
+	key := ""
+	for i := 0; key == ""; i++ {
+		if candidate := fmt.Sprintf("key%d", i); bytesEqual(ringOwner(nodes, candidate).Id, owner.Id) {
+			key = candidate
+		}
+	}
+	partition := &partitionTransport{Transport: nodes[0].transport, addr: owner.Addr, down: 1}
+	nodes[0].transport = partition
+	if err := nodes[0].Set(key, "hinted"); err != nil {
+		t.Fatalf("Set() error = %v, want it hinted", err)
+	}
+	atomic.StoreInt32(&partition.down, 0)
+
+	held := &api.TxnRequest{Id: "held", Writes: []*api.KV{{Key: key, Value: "x"}}}
+	if err := nodes[1].prepareRPC(owner.Node, held); err != nil {
+		t.Fatal(err)
+	}
+	nodes[0].replayHints()
+	if got := nodes[0].hints.len(); got != 1 {
+		t.Fatalf("%d hints pending while the key is locked, want 1", got)
+	}
+	if dropped := nodes[0].Metrics()["hints_dropped"]; dropped != 0 {
+		t.Errorf("%d hints dropped while the key is locked, want 0", dropped)
+	}
+
+	if err := nodes[1].abortTxnRPC(owner.Node, held); err != nil {
+		t.Fatal(err)
+	}
+	nodes[0].replayHints()
+	if got := nodes[0].hints.len(); got != 0 {
+		t.Errorf("%d hints pending once the key is unlocked, want 0", got)
+	}
+	if val, err := nodes[1].Get(key); err != nil || string(val) != "hinted" {
+		t.Errorf("Get() = %q, %v, want hinted", val, err)
+	}
+}
@@ -0,0 +1,133 @@
+package boopy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// hlcHeader carries the sender's clock in the metadata of every RPC, both
+// ways, so that nodes that talk to each other keep their clocks in step.
+const hlcHeader = "boopy-hlc"
+
+// hlcLogicalBits is the width of the logical counter below the wall time.
+const hlcLogicalBits = 16
+
+// Timestamp is a hybrid logical clock reading: wall time in milliseconds in
+// the high bits and a logical counter in the low 16. Timestamps compare as
+// integers, and one taken after another was seen is always larger, whatever
+// the wall clocks of the nodes involved say. Writes are versioned with the
+// timestamp of the owner that accepted them.
+type Timestamp int64
+
+// Time returns the wall time part of t.
+func (t Timestamp) Time() time.Time {
+	return time.Unix(0, int64(t>>hlcLogicalBits)*int64(time.Millisecond))
+}
+
+// Logical returns the counter that orders timestamps within a millisecond.
+func (t Timestamp) Logical() int {
+	return int(t & (1<<hlcLogicalBits - 1))
+}
+
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%s+%d", t.Time().UTC().Format(time.RFC3339Nano), t.Logical())
+}
+
+// hlc is a hybrid logical clock, see Timestamp.
+type hlc struct {
+	mtx      sync.Mutex
+	last     int64
+	wall     func() time.Time
+	maxDrift int64 // how far past the wall clock remote timestamps are followed, 0 for no limit
+}
+
+func newHLC(maxDrift time.Duration) *hlc {
+	return &hlc{wall: time.Now, maxDrift: int64(maxDrift/time.Millisecond) << hlcLogicalBits}
+}
+
+func (c *hlc) physical() int64 {
+	return c.wall().UnixNano() / int64(time.Millisecond) << hlcLogicalBits
+}
+
+// now returns a timestamp larger than any the clock has returned or seen.
+func (c *hlc) now() int64 {
+	return c.update(0)
+}
+
+// update advances the clock past remote, a timestamp read from another node
+// or stored with a value, and returns a new timestamp. Remote timestamps are
+// only followed up to maxDrift past our wall clock, so a bad clock or a
+// forged header cannot drag the ring into the future. The clock stops rather
+// than wrapping once it reaches the largest timestamp.
+func (c *hlc) update(remote int64) int64 {
+	pt := c.physical()
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.maxDrift > 0 && remote > pt+c.maxDrift {
+		remote = pt + c.maxDrift
+	}
+	if remote > c.last {
+		c.last = remote
+	}
+	if pt > c.last {
+		c.last = pt
+	} else if c.last < math.MaxInt64 {
+		c.last++
+	}
+	return c.last
+}
+
+// observe advances the clock past the timestamp found in md, if any.
+func (c *hlc) observe(md metadata.MD) {
+	for _, val := range md.Get(hlcHeader) {
+		if remote, err := strconv.ParseInt(val, 10, 64); err == nil {
+			c.update(remote)
+		}
+	}
+}
+
+func (c *hlc) header() metadata.MD {
+	return metadata.Pairs(hlcHeader, strconv.FormatInt(c.now(), 10))
+}
+
+func (c *hlc) outgoing(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, hlcHeader, strconv.FormatInt(c.now(), 10))
+}
+
+func (c *hlc) incoming(ctx context.Context) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		c.observe(md)
+	}
+}
+
+func (c *hlc) unaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	c.incoming(ctx)
+	grpc.SetHeader(ctx, c.header())
+	return handler(ctx, req)
+}
+
+func (c *hlc) streamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	c.incoming(ss.Context())
+	ss.SetHeader(c.header())
+	return handler(srv, ss)
+}
+
+func (c *hlc) unaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var header metadata.MD
+	err := invoker(c.outgoing(ctx), method, req, reply, cc, append(opts, grpc.Header(&header))...)
+	c.observe(header)
+	return err
+}
+
+// streamClientInterceptor only sends our clock: reading the reply header
+// would wait for the server to answer.
+func (c *hlc) streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(c.outgoing(ctx), desc, cc, method, opts...)
+}
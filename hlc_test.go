@@ -0,0 +1,121 @@
+package boopy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_hlc(t *testing.T) {
+	wall := time.Unix(1000, 0)
+	clock := &hlc{wall: func() time.Time { return wall }}
+	start := Timestamp(clock.now())
+	if !start.Time().Equal(wall) || start.Logical() != 0 {
+		t.Fatalf("now() = %v, want %v with no logical part", start, wall)
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		remote  Timestamp
+		want    Timestamp
+	}{
+		{"wall stalled", 0, 0, start + 1},
+		{"remote behind", 0, start - 5, start + 2},
+		{"remote ahead", 0, start + 1<<hlcLogicalBits + 3, start + 1<<hlcLogicalBits + 4},
+		{"wall moved past", 2 * time.Millisecond, 0, start + 2<<hlcLogicalBits},
+		{"wall behind", -time.Second, 0, start + 2<<hlcLogicalBits + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wall = wall.Add(tt.advance)
+			if got := Timestamp(clock.update(int64(tt.remote))); got != tt.want {
+				t.Errorf("update(%v) = %v, want %v", tt.remote, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hlcBounds(t *testing.T) {
+	wall := time.Unix(1000, 0)
+	clock := &hlc{wall: func() time.Time { return wall }, maxDrift: int64(time.Minute/time.Millisecond) << hlcLogicalBits}
+	start := Timestamp(clock.now())
+	limit := start + Timestamp(time.Minute/time.Millisecond)<<hlcLogicalBits
+
+	tests := []struct {
+		name   string
+		remote Timestamp
+		want   Timestamp
+	}{
+		{"remote within drift", start + 5, start + 6},
+		{"remote far ahead", start + Timestamp(time.Hour/time.Millisecond)<<hlcLogicalBits, limit + 1},
+		{"largest remote", math.MaxInt64, limit + 2},
+	}
+	for _, tt := range tests {
+		if got := Timestamp(clock.update(int64(tt.remote))); got != tt.want {
+			t.Errorf("%s: update(%v) = %v, want %v", tt.name, tt.remote, got, tt.want)
+		}
+	}
+
+	// Without a drift limit the clock stops at the largest timestamp.
+	unbounded := &hlc{wall: func() time.Time { return wall }}
+	for i := 0; i < 2; i++ {
+		if got := unbounded.update(math.MaxInt64); got != math.MaxInt64 {
+			t.Errorf("update(MaxInt64) = %v, want MaxInt64", got)
+		}
+	}
+}
+
+func TestNode_versionAheadOfClock(t *testing.T) {
+	nodes := newTestRing(t, 1, nil)
+	node := nodes[0]
+	future := node.clock.now() + int64(Timestamp(time.Hour/time.Millisecond)<<hlcLogicalBits)
+	node.stMtx.Lock()
+	node.storage.Set("", "key", "future", time.Time{}, future)
+	node.stMtx.Unlock()
+
+	if err := node.Set("key", "now"); err != nil {
+		t.Fatal(err)
+	}
+	val, ts, err := node.GetStamped("", "key")
+	if err != nil || string(val) != "now" || int64(ts) <= future {
+		t.Errorf("GetStamped() = %q, %v, %v, want now stamped after %v", val, ts, err, Timestamp(future))
+	}
+	if got := node.clock.now(); got >= future {
+		t.Errorf("clock moved to %v by a stored version", Timestamp(got))
+	}
+}
+
+func TestNode_clockPiggybacked(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	ahead := nodes[0].clock.update(int64(Timestamp(nodes[0].clock.now()) + Timestamp(20*time.Second/time.Millisecond)<<hlcLogicalBits))
+
+	// The request carries the caller's clock...
+	if _, err := nodes[0].getSuccessorRPC(nodes[1].Node); err != nil {
+		t.Fatal(err)
+	}
+	if got := nodes[1].clock.now(); got <= ahead {
+		t.Errorf("callee clock %v not advanced past %v", Timestamp(got), Timestamp(ahead))
+	}
+
+	// ...and the reply the callee's.
+	ahead = nodes[1].clock.update(ahead + int64(Timestamp(20*time.Second/time.Millisecond)<<hlcLogicalBits))
+	if _, err := nodes[0].getSuccessorRPC(nodes[1].Node); err != nil {
+		t.Fatal(err)
+	}
+	if got := nodes[0].clock.now(); got <= ahead {
+		t.Errorf("caller clock %v not advanced past %v", Timestamp(got), Timestamp(ahead))
+	}
+
+	// Writes are stamped past everything the owner has seen.
+	if err := nodes[0].Set("key", "v"); err != nil {
+		t.Fatal(err)
+	}
+	val, ts, err := nodes[1].GetStamped("", "key")
+	if err != nil || string(val) != "v" {
+		t.Fatalf("GetStamped() = %q, %v", val, err)
+	}
+	if int64(ts) <= ahead {
+		t.Errorf("write stamped %v, want after %v", ts, Timestamp(ahead))
+	}
+}
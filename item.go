@@ -0,0 +1,100 @@
+package boopy
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ERR_VERSION_MISMATCH     = errors.New("key changed since the version given")
+	ERR_CONDITIONAL_SIBLINGS = errors.New("conditional writes cannot be used in namespaces that keep siblings")
+)
+
+// itemRetries bounds how often updateItem retries when the key changes
+// under it.
+const itemRetries = 8
+
+// Item is a value with the metadata clients such as memcached's keep with
+// it.
+type Item struct {
+	Value   []byte
+	Flags   uint32        // opaque, stored with the value
+	Version int64         // set by GetItem, pass it to CompareAndSet
+	Expires time.Time     // set by GetItem, zero for never
+	TTL     time.Duration // on writes, 0 uses the namespace default
+}
+
+// GetItem reads a key with its flags and version.
+func (n *Node) GetItem(ns, key string) (*Item, error) {
+	res, err := n.lookup(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Siblings) > 1 {
+		return nil, ERR_SIBLINGS
+	}
+	item := &Item{Value: res.Value, Flags: res.Flags, Version: res.Version}
+	if res.Expires != 0 {
+		item.Expires = time.Unix(0, res.Expires)
+	}
+	return item, nil
+}
+
+// SetItem writes a key with its flags.
+func (n *Node) SetItem(ns, key string, item *Item) error {
+	return n.setItem(&api.SetRequest{
+		Namespace: ns, Key: key, Value: string(item.Value), Flags: item.Flags, Ttl: int64(item.TTL),
+	})
+}
+
+// CompareAndSet writes a key only if its version is still version, as read
+// by GetItem, or if it is absent when version is 0. It fails with
+// ERR_VERSION_MISMATCH otherwise.
+func (n *Node) CompareAndSet(ns, key string, item *Item, version int64) error {
+	return n.setItem(&api.SetRequest{
+		Namespace: ns, Key: key, Value: string(item.Value), Flags: item.Flags, Ttl: int64(item.TTL),
+		Conditional: true, Expected: version,
+	})
+}
+
+// updateItem rewrites a key with change applied to its current value,
+// retrying if the key changes meanwhile. It fails with ERR_KEY_NOT_FOUND if
+// the key is absent.
+func (n *Node) updateItem(ns, key string, change func(*Item) (*Item, error)) error {
+	for i := 0; i < itemRetries; i++ {
+		old, err := n.GetItem(ns, key)
+		if err != nil {
+			if isNotFound(err) {
+				return ERR_KEY_NOT_FOUND
+			}
+			return err
+		}
+		item, err := change(old)
+		if err != nil {
+			return err
+		}
+		if err = n.CompareAndSet(ns, key, item, old.Version); err != ERR_VERSION_MISMATCH {
+			return err
+		}
+	}
+	return ERR_VERSION_MISMATCH
+}
+
+// setItem sends a write to the owner of its key. Writes with flags or
+// conditions are not hinted: hints only keep plain values.
+func (n *Node) setItem(req *api.SetRequest) error {
+	node, err := n.locate(namespacedKey(req.Namespace, req.Key))
+	if err != nil {
+		return err
+	}
+	err = n.followRedirects(node, func(node *api.Node) error {
+		return n.transport.SetKey(node, req)
+	})
+	if err != nil && status.Convert(err).Message() == ERR_VERSION_MISMATCH.Error() {
+		return ERR_VERSION_MISMATCH
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package boopy
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ERR_INVALID_BATCH_OP = errors.New("unknown batch operation")
+
+// kvServer answers clients on the KeyValue service, routing every request
+// through its node like the Go API does. The Chord service is left to
+// traffic between nodes.
+type kvServer struct {
+	node *Node
+}
+
+// listenKeyValue sets up the KeyValue service on KVAddr, returning the
+// listener to serve it on. It has its own credentials, and clients do not
+// take part in the hybrid logical clock: only ring members move it.
+func (n *Node) listenKeyValue() (net.Listener, error) {
+	var opts []grpc.ServerOption
+	if n.cnf.KVTLSCertFile != "" || n.cnf.KVTLSCAFile != "" {
+		creds, err := loadKVTLS(n.cnf)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	l, err := net.Listen("tcp", n.cnf.KVAddr)
+	if err != nil {
+		return nil, err
+	}
+	n.kv = grpc.NewServer(opts...)
+	api.RegisterKeyValueServer(n.kv, &kvServer{node: n})
+	return l, nil
+}
+
+// kvInvalid are the errors reported to KeyValue clients as INVALID_ARGUMENT.
+var kvInvalid = []error{ERR_INVALID_CURSOR, ERR_CONDITIONAL_SIBLINGS, ERR_INVALID_BATCH_OP}
+
+// kvError gives err the status code KeyValue clients check for.
+func kvError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := status.Convert(err).Message()
+	switch {
+	case isNotFound(err):
+		return status.Error(codes.NotFound, msg)
+	case msg == ERR_VERSION_MISMATCH.Error():
+		return status.Error(codes.Aborted, msg)
+	}
+	for _, invalid := range kvInvalid {
+		if invalid.Error() == msg {
+			return status.Error(codes.InvalidArgument, msg)
+		}
+	}
+	return status.Convert(err).Err()
+}
+
+func (s *kvServer) Get(ctx context.Context, req *api.GetRequest) (*api.GetResponse, error) {
+	res, err := s.node.lookup(req.Namespace, req.Key)
+	if err != nil {
+		return nil, kvError(err)
+	}
+	return res, nil
+}
+
+// Set sends plain writes the way SetWithContext does, hinting them when the
+// owner is unreachable, and writes with flags or conditions like SetItem.
+func (s *kvServer) Set(ctx context.Context, req *api.SetRequest) (*api.SetResponse, error) {
+	var err error
+	if req.Flags != 0 || req.Conditional {
+		err = s.node.setItem(&api.SetRequest{
+			Namespace: req.Namespace, Key: req.Key, Value: req.Value, Ttl: req.Ttl,
+			Context: req.Context, Flags: req.Flags, Conditional: req.Conditional, Expected: req.Expected,
+		})
+	} else {
+		err = s.node.set(req.Namespace, req.Key, req.Value, req.Context, time.Duration(req.Ttl))
+	}
+	if err != nil {
+		return nil, kvError(err)
+	}
+	return emptySetResponse, nil
+}
+
+func (s *kvServer) Delete(ctx context.Context, req *api.DeleteRequest) (*api.DeleteResponse, error) {
+	if err := s.node.delete(req.Namespace, req.Key); err != nil {
+		return nil, kvError(err)
+	}
+	return emptyDeleteResponse, nil
+}
+
+func (s *kvServer) Batch(ctx context.Context, req *api.KVBatchRequest) (*api.BatchResponse, error) {
+	keys := make([]string, len(req.Pairs))
+	for i, kv := range req.Pairs {
+		keys[i] = kv.Key
+	}
+	var results []BatchResult
+	switch req.Op {
+	case api.KVBatchRequest_GET:
+		results = s.node.MultiGet(req.Namespace, keys)
+	case api.KVBatchRequest_SET:
+		results = s.node.MultiSet(req.Namespace, req.Pairs, time.Duration(req.Ttl))
+	case api.KVBatchRequest_DELETE:
+		results = s.node.MultiDelete(req.Namespace, keys)
+	default:
+		return nil, kvError(ERR_INVALID_BATCH_OP)
+	}
+
+	res := &api.BatchResponse{Results: make([]*api.KeyResult, len(results))}
+	for i, result := range results {
+		res.Results[i] = &api.KeyResult{Key: result.Key, Value: result.Value}
+		if result.Err != nil {
+			res.Results[i].Error = status.Convert(result.Err).Message()
+		}
+	}
+	return res, nil
+}
+
+func (s *kvServer) Scan(ctx context.Context, req *api.KVScanRequest) (*api.KVScanResponse, error) {
+	keys, cursor, err := s.node.Scan(ScanOptions{
+		Namespace: req.Namespace,
+		Prefix:    req.Prefix,
+		Values:    req.Values,
+		Limit:     int(req.Limit),
+		Cursor:    req.Cursor,
+	})
+	if err != nil {
+		return nil, kvError(err)
+	}
+	return &api.KVScanResponse{Keys: keys, Cursor: cursor}, nil
+}
+
+// Watch relays a Watcher, which keeps following the key as it moves, until
+// the client goes away.
+func (s *kvServer) Watch(req *api.WatchRequest, stream api.KeyValue_WatchServer) error {
+	w := s.node.Watch(req.Namespace, req.Key, req.Prefix)
+	defer w.Close()
+	for {
+		select {
+		case ev := <-w.Events():
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-s.node.shutdownCh:
+			return status.Error(codes.Unavailable, "node stopped")
+		}
+	}
+}
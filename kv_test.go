@@ -0,0 +1,277 @@
+package boopy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"github.com/jseam2/boopy/client"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newKVRing starts a test ring whose nodes serve KeyValue on addresses of
+// their own.
+func newKVRing(t *testing.T, size int) []*Node {
+	return newTestRing(t, size, func(cnf *Config) {
+		cnf.KVAddr = freeAddr(t)
+	})
+}
+
+func dialKV(t *testing.T, node *Node) *client.Client {
+	t.Helper()
+	c, err := client.Dial(node.cnf.KVAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestKeyValue(t *testing.T) {
+	nodes := newKVRing(t, 3)
+	c := dialKV(t, nodes[0])
+
+	// Keys are routed to their owners whichever node is dialed.
+	first, second := splitKeys(t, nodes)
+	keys := []string{first, second}
+	for _, key := range keys {
+		if err := c.Set("", key, "v-"+key, 0); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+	for _, key := range keys {
+		if val, err := nodes[2].Get(key); err != nil || string(val) != "v-"+key {
+			t.Errorf("Node.Get(%s) = %q, %v", key, val, err)
+		}
+		if val, err := c.Get("", key); err != nil || string(val) != "v-"+key {
+			t.Errorf("Get(%s) = %q, %v", key, val, err)
+		}
+	}
+
+	if _, err := c.Get("", "missing"); err != client.ERR_KEY_NOT_FOUND {
+		t.Errorf("Get(missing) error = %v, want ERR_KEY_NOT_FOUND", err)
+	}
+	if err := c.Delete("", keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes[1].Get(keys[0]); !isNotFound(err) {
+		t.Errorf("Node.Get() after Delete error = %v, want not found", err)
+	}
+
+	// Conditional writes compare versions.
+	if err := c.CompareAndSet("", "cas", "1", 0, 0); err != nil {
+		t.Fatalf("CompareAndSet() of an absent key error = %v", err)
+	}
+	res, err := c.Lookup("", "cas")
+	if err != nil || res.Version == 0 {
+		t.Fatalf("Lookup() = %v, %v", res, err)
+	}
+	if err := c.CompareAndSet("", "cas", "2", 0, 0); err != client.ERR_VERSION_MISMATCH {
+		t.Errorf("CompareAndSet() of a present key error = %v, want ERR_VERSION_MISMATCH", err)
+	}
+	if err := c.CompareAndSet("", "cas", "2", 0, res.Version); err != nil {
+		t.Errorf("CompareAndSet() with the current version error = %v", err)
+	}
+	if err := c.Write(&api.SetRequest{Key: "flagged", Value: "x", Flags: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := c.Lookup("", "flagged"); err != nil || res.Flags != 7 {
+		t.Errorf("Lookup(flagged) = %v, %v, want flags 7", res, err)
+	}
+}
+
+func TestKeyValue_BatchScan(t *testing.T) {
+	nodes := newKVRing(t, 3)
+	c := dialKV(t, nodes[1])
+
+	pairs := make([]*api.KV, 0, 20)
+	keys := make([]string, 0, 20)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("user:%d", i)
+		pairs = append(pairs, &api.KV{Key: key, Value: "x"})
+		keys = append(keys, key)
+	}
+	results, err := c.MultiSet("", pairs, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("MultiSet(%s) error = %v", result.Key, result.Err)
+		}
+	}
+
+	results, err = c.MultiGet("", append(keys[:2:2], "missing"))
+	if err != nil || len(results) != 3 {
+		t.Fatalf("MultiGet() = %v, %v", results, err)
+	}
+	if string(results[0].Value) != "x" || results[1].Err != nil || results[2].Err != client.ERR_KEY_NOT_FOUND {
+		t.Errorf("MultiGet() = %+v", results)
+	}
+
+	seen := make(map[string]bool)
+	req := &api.KVScanRequest{Prefix: "user:", Limit: 7}
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("Scan did not finish")
+		}
+		kvs, cursor, err := c.Scan(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, kv := range kvs {
+			seen[kv.Key] = true
+		}
+		if cursor == "" {
+			break
+		}
+		req.Cursor = cursor
+	}
+	if len(seen) != 20 {
+		t.Errorf("Scan found %d keys, want 20", len(seen))
+	}
+	if _, _, err := c.Scan(&api.KVScanRequest{Cursor: "!"}); err == nil {
+		t.Error("Scan() of a bad cursor succeeded")
+	}
+
+	results, err = c.MultiDelete("", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("", keys[5]); err != client.ERR_KEY_NOT_FOUND {
+		t.Errorf("Get() after MultiDelete error = %v, want ERR_KEY_NOT_FOUND", err)
+	}
+}
+
+func TestKeyValue_Watch(t *testing.T) {
+	nodes := newKVRing(t, 3)
+	c := dialKV(t, nodes[0])
+
+	w, err := c.Watch("", "apple", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := ringOwner(nodes, "apple")
+	for _, node := range nodes {
+		if bytesEqual(node.Id, owner.Id) {
+			waitWatches(t, node, 1)
+		}
+	}
+
+	if err := nodes[2].Set("apple", "red"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-w.Events():
+		if ev.Type != api.WatchEvent_SET || ev.Key != "apple" || ev.Value != "red" {
+			t.Errorf("event = %v, want SET apple red", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no watch event")
+	}
+
+	w.Close()
+	for range w.Events() {
+	}
+	if err := w.Err(); err != nil {
+		t.Errorf("Err() after Close = %v", err)
+	}
+	for _, node := range nodes {
+		waitWatches(t, node, 0)
+	}
+}
+
+func TestKeyValue_ownListener(t *testing.T) {
+	nodes := newKVRing(t, 2)
+
+	ring, err := client.Dial(nodes[0].Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ring.Close()
+	if _, err := ring.Get("", "key"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("Get() on the ring address error = %v, want Unimplemented", err)
+	}
+
+	// Clients do not move the clock, even by less than MaxClockDrift.
+	conn, err := grpc.Dial(nodes[0].cnf.KVAddr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ahead := nodes[0].clock.now() + int64(30*time.Second/time.Millisecond)<<hlcLogicalBits
+	ctx := metadata.AppendToOutgoingContext(context.Background(), hlcHeader, strconv.FormatInt(ahead, 10))
+	if err := nodes[0].Set("key", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := api.NewKeyValueClient(conn).Get(ctx, &api.GetRequest{Key: "key"}); err != nil {
+		t.Fatal(err)
+	}
+	if now := nodes[0].clock.now(); now >= ahead {
+		t.Errorf("clock = %d after a client sent %d, want it unmoved", now, ahead)
+	}
+}
+
+func TestKeyValue_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boopy-kv-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := newTestCA(t, dir, "clients")
+	rogueCA := newTestCA(t, dir, "rogue")
+
+	nodes := newTestRing(t, 1, func(cnf *Config) {
+		cnf.KVAddr = freeAddr(t)
+		cnf.KVTLSCertFile, cnf.KVTLSKeyFile = ca.issue(t, "kv")
+		cnf.KVTLSCAFile = ca.file("clients.crt")
+	})
+	roots, err := loadCertPool(ca.file("clients.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dial := func(issuer *testCA) (*client.Client, error) {
+		config := &tls.Config{RootCAs: roots, ServerName: "kv"}
+		if issuer != nil {
+			cert, err := tls.LoadX509KeyPair(issuer.issue(t, "app"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			config.Certificates = []tls.Certificate{cert}
+		}
+		return client.Dial(nodes[0].cnf.KVAddr, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	}
+
+	tests := []struct {
+		name   string
+		issuer *testCA
+		ok     bool
+	}{
+		{"client certificate", ca, true},
+		{"no certificate", nil, false},
+		{"untrusted certificate", rogueCA, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := dial(tt.issuer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			err = c.Set("", "key", "v", 0)
+			if (err == nil) != tt.ok {
+				t.Errorf("Set() error = %v, want success %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package boopy
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ERR_LEASE_HELD    = errors.New("lease is held by another holder")
+	ERR_LEASE_LOST    = errors.New("lease expired or was released")
+	ERR_INVALID_LEASE = errors.New("leases need a name and a positive ttl")
+)
+
+// Leases are kept by the owner of their name in the reserved leaseNamespace
+// and replicated like namespace definitions. The owner's clock decides when
+// a lease expires; holders should stop acting on it once ttl has passed
+// since they asked, whatever the returned expiry says.
+//
+// Every grant gets a fencing token: the version of the lease record, a
+// hybrid logical clock timestamp, so tokens keep growing when the lease
+// moves to another owner. Resources guarded by a lease should reject
+// requests carrying a smaller token than one already seen.
+const leaseNamespace = "_leases"
+
+var leaseSettings = &api.Namespace{Name: leaseNamespace, ReplicationFactor: 3}
+
+// Lease is a lease granted by AcquireLease or RenewLease.
+type Lease struct {
+	Name    string
+	Token   int64 // fencing token, larger than any given out before for Name
+	Expires time.Time
+}
+
+// leaseErrors are the errors XLease fails with that callers compare against.
+var leaseErrors = []error{ERR_LEASE_HELD, ERR_LEASE_LOST, ERR_INVALID_LEASE}
+
+// AcquireLease takes the lease called name for ttl, failing with
+// ERR_LEASE_HELD while someone else holds it.
+func (n *Node) AcquireLease(name string, ttl time.Duration) (*Lease, error) {
+	return n.lease(&api.LeaseRequest{Name: name, Op: api.LeaseRequest_ACQUIRE, Ttl: int64(ttl)})
+}
+
+// RenewLease extends a lease to ttl from now, keeping its token. It fails
+// with ERR_LEASE_LOST if the lease expired or was released meanwhile.
+func (n *Node) RenewLease(lease *Lease, ttl time.Duration) (*Lease, error) {
+	return n.lease(&api.LeaseRequest{Name: lease.Name, Op: api.LeaseRequest_RENEW, Token: lease.Token, Ttl: int64(ttl)})
+}
+
+// ReleaseLease gives a lease up before it expires.
+func (n *Node) ReleaseLease(lease *Lease) error {
+	_, err := n.lease(&api.LeaseRequest{Name: lease.Name, Op: api.LeaseRequest_RELEASE, Token: lease.Token})
+	return err
+}
+
+func (n *Node) lease(req *api.LeaseRequest) (*Lease, error) {
+	if req.Name == "" || (req.Op != api.LeaseRequest_RELEASE && req.Ttl <= 0) {
+		return nil, ERR_INVALID_LEASE
+	}
+	node, err := n.locate(namespacedKey(leaseNamespace, req.Name))
+	if err != nil {
+		return nil, err
+	}
+	var granted *api.Lease
+	err = n.followRedirects(node, func(node *api.Node) error {
+		granted, err = n.leaseRPC(node, req)
+		return err
+	})
+	if err != nil {
+		msg := status.Convert(err).Message()
+		for _, known := range leaseErrors {
+			if known.Error() == msg {
+				return nil, known
+			}
+		}
+		return nil, err
+	}
+	return &Lease{Name: granted.Name, Token: granted.Token, Expires: time.Unix(0, granted.Expires)}, nil
+}
+
+func (n *Node) leaseRPC(node *api.Node, req *api.LeaseRequest) (*api.Lease, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.XLease(context.Background(), req)
+	}
+	return n.transport.Lease(node, req)
+}
+
+func (n *Node) XLease(ctx context.Context, req *api.LeaseRequest) (*api.Lease, error) {
+	owner, err := n.misrouted(namespacedKey(leaseNamespace, req.Name), req.Hops)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		forward := *req
+		forward.Hops++
+		return n.transport.Lease(owner, &forward)
+	}
+
+	now := time.Now()
+	n.stMtx.Lock()
+	current := new(api.Lease)
+	if old, err := n.storage.Lookup(leaseNamespace, req.Name); err == nil && !old.Deleted {
+		if err := proto.Unmarshal([]byte(old.Value), current); err != nil {
+			n.stMtx.Unlock()
+			return nil, err
+		}
+	}
+	held := current.Expires > now.UnixNano()
+
+	kv := &api.KV{Namespace: leaseNamespace, Key: req.Name}
+	kv.Version = n.nextVersion(leaseNamespace, req.Name)
+	next := &api.Lease{Name: req.Name, Token: current.Token}
+	switch req.Op {
+	case api.LeaseRequest_ACQUIRE:
+		if held {
+			err = ERR_LEASE_HELD
+		}
+		next.Token = kv.Version
+		next.Expires = now.Add(time.Duration(req.Ttl)).UnixNano()
+	case api.LeaseRequest_RENEW:
+		if !held || req.Token != current.Token {
+			err = ERR_LEASE_LOST
+		}
+		next.Expires = now.Add(time.Duration(req.Ttl)).UnixNano()
+	case api.LeaseRequest_RELEASE:
+		if req.Token != current.Token {
+			err = ERR_LEASE_LOST
+		}
+	}
+	if err == nil {
+		var data []byte
+		if data, err = proto.Marshal(next); err == nil {
+			kv.Value = string(data)
+			err = n.storage.Set(leaseNamespace, req.Name, kv.Value, time.Time{}, kv.Version)
+		}
+	}
+	if err == nil {
+		n.record(api.Change_SET, kv)
+	}
+	n.stMtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	n.replicate(leaseSettings, kv)
+	n.metrics.inc("leases_" + strings.ToLower(req.Op.String()))
+	return next, nil
+}
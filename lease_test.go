@@ -0,0 +1,46 @@
+package boopy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNode_Lease(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+
+	leader, err := nodes[0].AcquireLease("jobs", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes[1].AcquireLease("jobs", time.Hour); err != ERR_LEASE_HELD {
+		t.Errorf("AcquireLease() of a held lease error = %v, want %v", err, ERR_LEASE_HELD)
+	}
+	renewed, err := nodes[2].RenewLease(leader, 50*time.Millisecond)
+	if err != nil || renewed.Token != leader.Token || !renewed.Expires.Before(leader.Expires) {
+		t.Fatalf("RenewLease() = %+v, %v, want token %d", renewed, err, leader.Token)
+	}
+
+	// Once it expires the lease goes to the next taker, with a larger token.
+	time.Sleep(100 * time.Millisecond)
+	next, err := nodes[1].AcquireLease("jobs", time.Hour)
+	if err != nil || next.Token <= leader.Token {
+		t.Fatalf("AcquireLease() after expiry = %+v, %v, want token over %d", next, err, leader.Token)
+	}
+	if _, err := nodes[0].RenewLease(leader, time.Hour); err != ERR_LEASE_LOST {
+		t.Errorf("RenewLease() of an expired lease error = %v, want %v", err, ERR_LEASE_LOST)
+	}
+	if err := nodes[0].ReleaseLease(leader); err != ERR_LEASE_LOST {
+		t.Errorf("ReleaseLease() of an expired lease error = %v, want %v", err, ERR_LEASE_LOST)
+	}
+
+	if err := nodes[2].ReleaseLease(next); err != nil {
+		t.Fatal(err)
+	}
+	last, err := nodes[0].AcquireLease("jobs", time.Hour)
+	if err != nil || last.Token <= next.Token {
+		t.Errorf("AcquireLease() after release = %+v, %v, want token over %d", last, err, next.Token)
+	}
+	if _, err := nodes[0].AcquireLease("jobs", 0); err != ERR_INVALID_LEASE {
+		t.Errorf("AcquireLease() without ttl error = %v, want %v", err, ERR_INVALID_LEASE)
+	}
+}
@@ -0,0 +1,171 @@
+package boopy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ERR_LOOKUP_HOPS = errors.New("lookup exceeded hop limit")
+	ERR_LOOKUP_LOOP = errors.New("lookup revisited a node")
+)
+
+// LookupError is returned when a lookup is abandoned. Path holds the nodes
+// visited before giving up, in order.
+type LookupError struct {
+	Reason error
+	Path   []*api.Node
+}
+
+func (e *LookupError) Error() string {
+	return fmt.Sprintf("%v after %d hops", e.Reason, len(e.Path))
+}
+
+// status converts the error into a gRPC status so it can be returned from
+// FindSuccessor. Each visited node is attached as a detail.
+func (e *LookupError) status() error {
+	code := codes.ResourceExhausted
+	if e.Reason == ERR_LOOKUP_LOOP {
+		code = codes.Aborted
+	}
+	st := status.New(code, e.Reason.Error())
+	for _, node := range e.Path {
+		if withNode, err := st.WithDetails(node); err == nil {
+			st = withNode
+		}
+	}
+	return st.Err()
+}
+
+// lookupErrorFromStatus reverses LookupError.status. Any other error,
+// including ResourceExhausted or Aborted statuses raised by gRPC itself or
+// other handlers, is returned untouched.
+func lookupErrorFromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var reason error
+	switch {
+	case st.Code() == codes.ResourceExhausted && st.Message() == ERR_LOOKUP_HOPS.Error():
+		reason = ERR_LOOKUP_HOPS
+	case st.Code() == codes.Aborted && st.Message() == ERR_LOOKUP_LOOP.Error():
+		reason = ERR_LOOKUP_LOOP
+	default:
+		return err
+	}
+
+	path := make([]*api.Node, 0, len(st.Details()))
+	for _, detail := range st.Details() {
+		if node, ok := detail.(*api.Node); ok {
+			path = append(path, node)
+		}
+	}
+	return &LookupError{Reason: reason, Path: path}
+}
+
+// LookupMode selects how a lookup traverses the ring.
+type LookupMode int
+
+const (
+	// LookupDefault uses the mode set in Config.
+	LookupDefault LookupMode = iota
+	// LookupRecursive has every node forward the lookup to the next hop.
+	LookupRecursive
+	// LookupIterative has the originating node contact every hop itself.
+	LookupIterative
+)
+
+// LookupResult describes where a key lives and how the lookup got there.
+type LookupResult struct {
+	Node *api.Node   // node responsible for the key
+	Path []*api.Node // nodes that handled the lookup, starting with this node
+	Hops []*api.Hop  // Path with the finger used and latency of every hop
+}
+
+// Lookup finds the node responsible for key, along with the nodes the
+// lookup traversed. mode overrides Config.LookupMode for this call.
+func (n *Node) Lookup(key string, mode LookupMode) (*LookupResult, error) {
+	id, err := n.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+	succ, hops, err := n.findSuccessorMode(id, mode)
+	if err != nil {
+		return nil, err
+	}
+	path := make([]*api.Node, 0, len(hops))
+	for _, hop := range hops {
+		path = append(path, hop.Node)
+	}
+	return &LookupResult{Node: succ, Path: path, Hops: hops}, nil
+}
+
+func (n *Node) findSuccessorMode(id []byte, mode LookupMode) (*api.Node, []*api.Hop, error) {
+	if mode == LookupDefault {
+		mode = n.cnf.LookupMode
+	}
+	if mode == LookupIterative {
+		return n.findSuccessorIterative(id)
+	}
+	return n.findSuccessorPath(&api.ID{Id: id})
+}
+
+// findSuccessorIterative resolves id by asking each hop for its closest
+// preceding node and contacting the next hop from here. A hop that cannot
+// be reached is excluded and the previous hop is asked again.
+func (n *Node) findSuccessorIterative(id []byte) (*api.Node, []*api.Hop, error) {
+	path := make([]*api.Node, 0, 8)
+	hops := make([]*api.Hop, 0, 8)
+	exclude := make([][]byte, 0)
+	curr := n.Node
+
+	for attempts := 0; ; attempts++ {
+		if attempts > n.cnf.MaxLookupHops {
+			return nil, hops, &LookupError{Reason: ERR_LOOKUP_HOPS, Path: path}
+		}
+		for _, visited := range path {
+			if bytesEqual(visited.Id, curr.Id) {
+				return nil, hops, &LookupError{Reason: ERR_LOOKUP_LOOP, Path: path}
+			}
+		}
+
+		start := time.Now()
+		res, err := n.closestPrecedingRPC(curr, id, exclude)
+		if err != nil {
+			if len(path) == 0 {
+				return nil, hops, err
+			}
+			// route around the unreachable hop
+			exclude = append(exclude, curr.Id)
+			curr = path[len(path)-1]
+			path = path[:len(path)-1]
+			hops = hops[:len(hops)-1]
+			continue
+		}
+		hop := &api.Hop{Node: curr, Finger: -1, Latency: int64(time.Since(start))}
+		path = append(path, curr)
+		hops = append(hops, hop)
+
+		succ := res.Successor
+		if succ == nil || succ.Id == nil {
+			return curr, hops, nil
+		}
+		if keyBetwIncludeRight(id, curr.Id, succ.Id) {
+			return succ, hops, nil
+		}
+
+		next := res.Node
+		if next == nil || bytesEqual(next.Id, curr.Id) {
+			return succ, hops, nil
+		}
+		hop.Finger = res.Finger
+		curr = next
+	}
+}
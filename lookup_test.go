@@ -0,0 +1,234 @@
+package boopy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_findSuccessorPath_limits(t *testing.T) {
+	cnf := BaseConfig()
+	cnf.MaxLookupHops = 2
+	self := NewInode("1", "0.0.0.0:8001")
+	other := NewInode("2", "0.0.0.0:8002")
+	n := &Node{Node: self, cnf: cnf}
+
+	tests := []struct {
+		name     string
+		req      *api.ID
+		want     error
+		wantPath int
+	}{
+		{"loop", &api.ID{Id: GetHashID("key"), Hops: 1, Path: []*api.Node{self}}, ERR_LOOKUP_LOOP, 2},
+		{"loop later", &api.ID{Id: GetHashID("key"), Hops: 2, Path: []*api.Node{self, other}}, ERR_LOOKUP_LOOP, 3},
+		{"hops", &api.ID{Id: GetHashID("key"), Hops: 3, Path: []*api.Node{other}}, ERR_LOOKUP_HOPS, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := n.findSuccessorPath(tt.req)
+			lookupErr, ok := err.(*LookupError)
+			if !ok {
+				t.Fatalf("findSuccessorPath() error = %v, want *LookupError", err)
+			}
+			if lookupErr.Reason != tt.want {
+				t.Errorf("findSuccessorPath() reason = %v, want %v", lookupErr.Reason, tt.want)
+			}
+			if len(lookupErr.Path) != tt.wantPath {
+				t.Errorf("findSuccessorPath() path = %d nodes, want %d", len(lookupErr.Path), tt.wantPath)
+			}
+		})
+	}
+}
+
+func Test_lookupErrorFromStatus(t *testing.T) {
+	path := []*api.Node{NewInode("1", "0.0.0.0:8001"), NewInode("2", "0.0.0.0:8002")}
+	tests := []struct {
+		name string
+		err  *LookupError
+	}{
+		{"hops", &LookupError{Reason: ERR_LOOKUP_HOPS, Path: path}},
+		{"loop", &LookupError{Reason: ERR_LOOKUP_LOOP, Path: path}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lookupErrorFromStatus(tt.err.status()).(*LookupError)
+			if !ok {
+				t.Fatalf("lookupErrorFromStatus() did not return *LookupError")
+			}
+			if got.Reason != tt.err.Reason {
+				t.Errorf("lookupErrorFromStatus() reason = %v, want %v", got.Reason, tt.err.Reason)
+			}
+			if len(got.Path) != len(path) {
+				t.Fatalf("lookupErrorFromStatus() path = %v, want %v", got.Path, path)
+			}
+			for i := range path {
+				if !bytesEqual(got.Path[i].Id, path[i].Id) {
+					t.Errorf("lookupErrorFromStatus() path[%d] = %v, want %v", i, got.Path[i], path[i])
+				}
+			}
+		})
+	}
+
+	untouched := []error{
+		ERR_KEY_NOT_FOUND,
+		status.Error(codes.ResourceExhausted, "grpc: received message larger than max"),
+		status.Error(codes.Aborted, ERR_TXN_CONFLICT.Error()),
+	}
+	for _, err := range untouched {
+		if got := lookupErrorFromStatus(err); got != err {
+			t.Errorf("lookupErrorFromStatus(%v) = %v, want untouched error", err, got)
+		}
+	}
+}
+
+func TestNode_Lookup_modes(t *testing.T) {
+	nodes := newTestRing(t, 5, nil)
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("key%d", i)
+		want := ringOwner(nodes, key)
+		for _, node := range nodes {
+			recursive, err := node.Lookup(key, LookupRecursive)
+			if err != nil {
+				t.Fatalf("Lookup(%q, recursive) error = %v", key, err)
+			}
+			iterative, err := node.Lookup(key, LookupIterative)
+			if err != nil {
+				t.Fatalf("Lookup(%q, iterative) error = %v", key, err)
+			}
+			if !bytesEqual(recursive.Node.Id, want.Id) || !bytesEqual(iterative.Node.Id, want.Id) {
+				t.Errorf("Lookup(%q) from %s = %s (recursive), %s (iterative), want %s",
+					key, node.Addr, recursive.Node.Addr, iterative.Node.Addr, want.Addr)
+			}
+			if len(recursive.Path) != len(iterative.Path) {
+				t.Errorf("Lookup(%q) from %s took %d hops recursive, %d iterative",
+					key, node.Addr, len(recursive.Path), len(iterative.Path))
+			}
+			for i := range recursive.Hops {
+				if i >= len(iterative.Hops) {
+					break
+				}
+				r, it := recursive.Hops[i], iterative.Hops[i]
+				if !bytesEqual(r.Node.Id, it.Node.Id) || r.Finger != it.Finger {
+					t.Errorf("Lookup(%q) hop %d = %v recursive, %v iterative", key, i, r, it)
+				}
+				if r.Latency <= 0 || it.Latency <= 0 {
+					t.Errorf("Lookup(%q) hop %d has no latency", key, i)
+				}
+			}
+		}
+	}
+}
+
+// slowTransport delays lookups sent to one address.
+type slowTransport struct {
+	Transport
+	addr  string
+	delay time.Duration
+}
+
+func (st *slowTransport) FindSuccessor(node *api.Node, id *api.ID) (*api.FindSuccessorResponse, error) {
+	if node.Addr == st.addr {
+		time.Sleep(st.delay)
+	}
+	return st.Transport.FindSuccessor(node, id)
+}
+
+func (st *slowTransport) ClosestPrecedingNode(node *api.Node, req *api.ClosestPrecedingRequest) (*api.ClosestPrecedingResponse, error) {
+	if node.Addr == st.addr {
+		time.Sleep(st.delay)
+	}
+	return st.Transport.ClosestPrecedingNode(node, req)
+}
+
+func TestNode_Lookup_hopLatency(t *testing.T) {
+	nodes := newTestRing(t, 5, nil)
+
+	var origin *Node
+	var key string
+	var path []*api.Node
+	for i := 0; i < 500 && origin == nil; i++ {
+		key = fmt.Sprintf("key%d", i)
+		for _, node := range nodes {
+			res, err := node.Lookup(key, LookupRecursive)
+			if err != nil {
+				t.Fatalf("Lookup(%q) error = %v", key, err)
+			}
+			if len(res.Path) >= 3 {
+				origin, path = node, res.Path
+				break
+			}
+		}
+	}
+	if origin == nil {
+		t.Skip("no lookup with an intermediate hop in this ring")
+	}
+
+	// Only the last hop is slow to reach, whoever contacts it.
+	const delay = 50 * time.Millisecond
+	slow := path[len(path)-1]
+	for _, node := range nodes {
+		node.transport = &slowTransport{Transport: node.transport, addr: slow.Addr, delay: delay}
+	}
+	for _, mode := range []LookupMode{LookupRecursive, LookupIterative} {
+		res, err := origin.Lookup(key, mode)
+		if err != nil {
+			t.Fatalf("Lookup(%q, %v) error = %v", key, mode, err)
+		}
+		for _, hop := range res.Hops {
+			if bytesEqual(hop.Node.Id, slow.Id) != (time.Duration(hop.Latency) >= delay) {
+				t.Errorf("Lookup(%q, %v) hop %s latency = %v, want only %s over %v",
+					key, mode, hop.Node.Addr, time.Duration(hop.Latency), slow.Addr, delay)
+			}
+		}
+	}
+}
+
+func TestNode_Lookup_iterativeRoutesAround(t *testing.T) {
+	nodes := newTestRing(t, 5, nil)
+
+	// Find a lookup that passes through an intermediate hop which is not the
+	// origin's successor, so the origin has another way forward. Then kill it.
+	var origin *Node
+	var key string
+	var dead *api.Node
+	for i := 0; i < 500 && dead == nil; i++ {
+		key = fmt.Sprintf("key%d", i)
+		for _, node := range nodes {
+			res, err := node.Lookup(key, LookupRecursive)
+			if err != nil {
+				t.Fatalf("Lookup(%q) error = %v", key, err)
+			}
+			if len(res.Path) >= 3 && !bytesEqual(node.successor.Id, res.Path[1].Id) {
+				origin, dead = node, res.Path[1]
+				break
+			}
+		}
+	}
+	if dead == nil {
+		t.Skip("no lookup with an intermediate hop in this ring")
+	}
+	want := ringOwner(nodes, key)
+	for _, node := range nodes {
+		if bytesEqual(node.Id, dead.Id) {
+			node.transport.Stop()
+		}
+	}
+
+	res, err := origin.Lookup(key, LookupIterative)
+	if err != nil {
+		t.Fatalf("Lookup(%q) error = %v", key, err)
+	}
+	if !bytesEqual(res.Node.Id, want.Id) {
+		t.Errorf("Lookup(%q) = %s, want %s", key, res.Node.Addr, want.Addr)
+	}
+	for _, hop := range res.Path {
+		if bytesEqual(hop.Id, dead.Id) {
+			t.Errorf("Lookup(%q) path includes dead node %s", key, dead.Addr)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package boopy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ERR_NON_NUMERIC = errors.New("cannot increment or decrement non-numeric value")
+
+const (
+	// maxMemcacheKey and maxMemcacheItem are memcached's limits on keys and
+	// values.
+	maxMemcacheKey  = 250
+	maxMemcacheItem = 1 << 20
+	// memcacheRelative is the largest exptime taken as seconds from now;
+	// larger ones are unix times.
+	memcacheRelative = 30 * 24 * 60 * 60
+)
+
+// memcacheServer answers memcached clients. Keys are those of one
+// namespace.
+type memcacheServer struct {
+	node *Node
+	ns   string
+}
+
+// ServeMemcache answers clients of memcached's text protocol connecting to
+// l with the keys of namespace ns, until l is closed. It supports get, gets,
+// set, add, replace, cas, delete, incr, decr and touch. cas uniques are key
+// versions, and an exptime of 0 uses the namespace default ttl.
+func (n *Node) ServeMemcache(l net.Listener, ns string) error {
+	srv := &memcacheServer{node: n, ns: ns}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go srv.serve(conn)
+	}
+}
+
+func (s *memcacheServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" {
+			w.Flush()
+			return
+		}
+		s.command(r, w, fields)
+		// Pipelined requests are answered together.
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// noreply strips a trailing noreply from args.
+func noreply(args []string) ([]string, bool) {
+	if len(args) > 0 && args[len(args)-1] == "noreply" {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+// memcacheTTL converts an exptime to a ttl. Items given a time in the past
+// are written to expire at once.
+func memcacheTTL(exptime int64) time.Duration {
+	switch {
+	case exptime == 0:
+		return 0
+	case exptime < 0:
+		return time.Nanosecond
+	case exptime > memcacheRelative:
+		if ttl := time.Until(time.Unix(exptime, 0)); ttl > 0 {
+			return ttl
+		}
+		return time.Nanosecond
+	}
+	return time.Duration(exptime) * time.Second
+}
+
+// remaining returns the ttl that keeps the expiry of item when it is
+// written back.
+func remaining(item *Item) time.Duration {
+	if item.Expires.IsZero() {
+		return 0
+	}
+	if ttl := time.Until(item.Expires); ttl > 0 {
+		return ttl
+	}
+	return time.Nanosecond
+}
+
+func validMemcacheKey(key string) bool {
+	return len(key) <= maxMemcacheKey
+}
+
+// command runs one request and writes its reply, reading the data block of
+// storage commands from r.
+func (s *memcacheServer) command(r *bufio.Reader, w *bufio.Writer, fields []string) {
+	var reply string
+	args, quiet := noreply(fields[1:])
+	switch cmd := fields[0]; cmd {
+	case "get", "gets":
+		if len(fields) < 2 {
+			reply = "ERROR"
+			break
+		}
+		s.get(w, fields[1:], cmd == "gets")
+		return
+	case "set", "add", "replace", "cas":
+		reply = s.store(r, cmd, args)
+	case "delete":
+		if len(args) == 2 && args[1] == "0" {
+			args = args[:1] // old clients send a hold time of 0
+		}
+		if len(args) != 1 || !validMemcacheKey(args[0]) {
+			reply = "CLIENT_ERROR bad command line format"
+			break
+		}
+		reply = s.delete(args[0])
+	case "incr", "decr":
+		if len(args) != 2 || !validMemcacheKey(args[0]) {
+			reply = "CLIENT_ERROR bad command line format"
+			break
+		}
+		delta, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			reply = "CLIENT_ERROR invalid numeric delta argument"
+			break
+		}
+		reply = s.incr(args[0], delta, cmd == "decr")
+	case "touch":
+		if len(args) != 2 || !validMemcacheKey(args[0]) {
+			reply = "CLIENT_ERROR bad command line format"
+			break
+		}
+		exptime, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			reply = "CLIENT_ERROR invalid exptime argument"
+			break
+		}
+		reply = s.touch(args[0], memcacheTTL(exptime))
+	case "version":
+		reply = "VERSION boopy"
+	default:
+		reply = "ERROR"
+	}
+	if !quiet || strings.HasPrefix(reply, "CLIENT_ERROR") || reply == "ERROR" {
+		fmt.Fprintf(w, "%s\r\n", reply)
+	}
+}
+
+func serverError(err error) string {
+	return "SERVER_ERROR " + strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error())
+}
+
+func (s *memcacheServer) get(w *bufio.Writer, keys []string, cas bool) {
+	for _, key := range keys {
+		if !validMemcacheKey(key) {
+			w.WriteString("CLIENT_ERROR bad command line format\r\n")
+			return
+		}
+	}
+	for _, key := range keys {
+		item, err := s.node.GetItem(s.ns, key)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(w, "%s\r\n", serverError(err))
+			return
+		}
+		if cas {
+			fmt.Fprintf(w, "VALUE %s %d %d %d\r\n", key, item.Flags, len(item.Value), item.Version)
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, item.Flags, len(item.Value))
+		}
+		w.Write(item.Value)
+		w.WriteString("\r\n")
+	}
+	w.WriteString("END\r\n")
+}
+
+// store runs set, add, replace and cas, whose arguments are
+// <key> <flags> <exptime> <bytes> [<cas unique>].
+func (s *memcacheServer) store(r *bufio.Reader, cmd string, args []string) string {
+	want := 4
+	if cmd == "cas" {
+		want = 5
+	}
+	if len(args) != want || !validMemcacheKey(args[0]) {
+		return "CLIENT_ERROR bad command line format"
+	}
+	flags, err1 := strconv.ParseUint(args[1], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[2], 10, 64)
+	size, err3 := strconv.Atoi(args[3])
+	var unique uint64
+	var err4 error
+	if cmd == "cas" {
+		unique, err4 = strconv.ParseUint(args[4], 10, 64)
+	}
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || size < 0 {
+		return "CLIENT_ERROR bad command line format"
+	}
+
+	if size > maxMemcacheItem {
+		if _, err := io.CopyN(ioutil.Discard, r, int64(size)+2); err != nil {
+			return "CLIENT_ERROR bad data chunk"
+		}
+		return "SERVER_ERROR object too large for cache"
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil || string(data[size:]) != "\r\n" {
+		if err == nil && data[size+1] != '\n' {
+			r.ReadString('\n') // the rest of an overlong block isn't a command
+		}
+		return "CLIENT_ERROR bad data chunk"
+	}
+
+	key := args[0]
+	item := &Item{Value: data[:size], Flags: uint32(flags), TTL: memcacheTTL(exptime)}
+	n := s.node
+	var err error
+	switch cmd {
+	case "set":
+		err = n.SetItem(s.ns, key, item)
+	case "add":
+		err = n.CompareAndSet(s.ns, key, item, 0)
+		if err == ERR_VERSION_MISMATCH {
+			return "NOT_STORED"
+		}
+	case "replace":
+		err = n.updateItem(s.ns, key, func(*Item) (*Item, error) { return item, nil })
+		if isNotFound(err) {
+			return "NOT_STORED"
+		}
+	case "cas":
+		if unique != 0 {
+			err = n.CompareAndSet(s.ns, key, item, int64(unique))
+		}
+		if unique == 0 || err == ERR_VERSION_MISMATCH {
+			if _, err := n.GetItem(s.ns, key); isNotFound(err) {
+				return "NOT_FOUND"
+			}
+			return "EXISTS"
+		}
+	}
+	if err != nil {
+		return serverError(err)
+	}
+	return "STORED"
+}
+
+func (s *memcacheServer) delete(key string) string {
+	if _, err := s.node.GetItem(s.ns, key); err != nil {
+		if isNotFound(err) {
+			return "NOT_FOUND"
+		}
+		return serverError(err)
+	}
+	if err := s.node.DeleteIn(s.ns, key); err != nil {
+		return serverError(err)
+	}
+	return "DELETED"
+}
+
+func (s *memcacheServer) incr(key string, delta uint64, decr bool) string {
+	var result uint64
+	err := s.node.updateItem(s.ns, key, func(old *Item) (*Item, error) {
+		current, err := strconv.ParseUint(strings.TrimSpace(string(old.Value)), 10, 64)
+		if err != nil {
+			return nil, ERR_NON_NUMERIC
+		}
+		switch {
+		case !decr:
+			result = current + delta // wraps like memcached's
+		case delta > current:
+			result = 0
+		default:
+			result = current - delta
+		}
+		value := []byte(strconv.FormatUint(result, 10))
+		return &Item{Value: value, Flags: old.Flags, TTL: remaining(old)}, nil
+	})
+	switch err {
+	case nil:
+		return strconv.FormatUint(result, 10)
+	case ERR_NON_NUMERIC:
+		return "CLIENT_ERROR " + err.Error()
+	case ERR_KEY_NOT_FOUND:
+		return "NOT_FOUND"
+	}
+	return serverError(err)
+}
+
+func (s *memcacheServer) touch(key string, ttl time.Duration) string {
+	err := s.node.updateItem(s.ns, key, func(old *Item) (*Item, error) {
+		return &Item{Value: old.Value, Flags: old.Flags, TTL: ttl}, nil
+	})
+	switch {
+	case err == nil:
+		return "TOUCHED"
+	case err == ERR_KEY_NOT_FOUND:
+		return "NOT_FOUND"
+	}
+	return serverError(err)
+}
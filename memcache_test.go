@@ -0,0 +1,114 @@
+package boopy
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memcacheClient speaks memcached's text protocol to a server.
+type memcacheClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialMemcache(t *testing.T, node *Node) *memcacheClient {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go node.ServeMemcache(l, "")
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		l.Close()
+	})
+	return &memcacheClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends req and reads reply lines up to one that ends a reply.
+func (c *memcacheClient) do(req string) []string {
+	c.t.Helper()
+	if _, err := io.WriteString(c.conn, req); err != nil {
+		c.t.Fatal(err)
+	}
+	var lines []string
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\r\n")
+		lines = append(lines, line)
+		if !strings.HasPrefix(line, "VALUE ") && (len(lines) < 2 || !strings.HasPrefix(lines[len(lines)-2], "VALUE ")) {
+			return lines
+		}
+	}
+}
+
+func TestNode_ServeMemcache(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	client := dialMemcache(t, nodes[0])
+
+	tests := []struct {
+		req  string
+		want []string
+	}{
+		{"set a 5 0 3\r\nfoo\r\n", []string{"STORED"}},
+		{"get a missing\r\n", []string{"VALUE a 5 3", "foo", "END"}},
+		{"add a 0 0 1\r\nx\r\n", []string{"NOT_STORED"}},
+		{"add b 0 0 1\r\nx\r\n", []string{"STORED"}},
+		{"replace missing 0 0 1\r\nx\r\n", []string{"NOT_STORED"}},
+		{"replace b 7 0 2\r\nyy\r\n", []string{"STORED"}},
+		{"get b\r\n", []string{"VALUE b 7 2", "yy", "END"}},
+		{"set a 0 0 3\r\ntoolong\r\n", []string{"CLIENT_ERROR bad data chunk"}},
+		{"incr a 1\r\n", []string{"CLIENT_ERROR cannot increment or decrement non-numeric value"}},
+		{"set n 0 0 2\r\n10\r\n", []string{"STORED"}},
+		{"incr n 5\r\n", []string{"15"}},
+		{"decr n 20\r\n", []string{"0"}},
+		{"incr missing 1\r\n", []string{"NOT_FOUND"}},
+		{"delete b\r\n", []string{"DELETED"}},
+		{"delete b\r\n", []string{"NOT_FOUND"}},
+		{"touch n 100\r\n", []string{"TOUCHED"}},
+		{"touch missing 100\r\n", []string{"NOT_FOUND"}},
+		{"set gone 0 -1 1\r\nx\r\n", []string{"STORED"}},
+		{"get gone\r\n", []string{"END"}},
+		{"set quiet 0 0 1 noreply\r\nq\r\nget quiet\r\n", []string{"VALUE quiet 0 1", "q", "END"}},
+		{"cas missing 0 0 1 1\r\nx\r\n", []string{"NOT_FOUND"}},
+		{"cas n 0 0 1 1\r\nx\r\n", []string{"EXISTS"}},
+		{"version\r\n", []string{"VERSION boopy"}},
+		{"flush_all\r\n", []string{"ERROR"}},
+	}
+	for _, tt := range tests {
+		if got := client.do(tt.req); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q = %q, want %q", tt.req, got, tt.want)
+		}
+	}
+
+	// cas uniques are versions, which change with every write.
+	got := client.do("gets a\r\n")
+	if len(got) != 3 {
+		t.Fatalf("gets = %q", got)
+	}
+	unique := strings.Fields(got[0])[4]
+	if got := client.do("cas a 9 0 3 " + unique + "\r\nbar\r\n"); got[0] != "STORED" {
+		t.Errorf("cas with the current unique = %q, want STORED", got)
+	}
+	if got := client.do("cas a 9 0 3 " + unique + "\r\nbaz\r\n"); got[0] != "EXISTS" {
+		t.Errorf("cas with a stale unique = %q, want EXISTS", got)
+	}
+
+	// Flags are kept with the value wherever it is read.
+	item, err := nodes[1].GetItem("", "a")
+	if err != nil || string(item.Value) != "bar" || item.Flags != 9 {
+		t.Errorf("GetItem() = %+v, %v, want bar with flags 9", item, err)
+	}
+}
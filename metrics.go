@@ -0,0 +1,39 @@
+package boopy
+
+import "sync"
+
+// metrics is a set of named counters describing what a node has been doing.
+type metrics struct {
+	mtx      sync.Mutex
+	counters map[string]uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{counters: make(map[string]uint64)}
+}
+
+func (m *metrics) add(name string, delta uint64) {
+	m.mtx.Lock()
+	m.counters[name] += delta
+	m.mtx.Unlock()
+}
+
+func (m *metrics) inc(name string) {
+	m.add(name, 1)
+}
+
+// snapshot copies the current value of every counter.
+func (m *metrics) snapshot() map[string]uint64 {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	out := make(map[string]uint64, len(m.counters))
+	for name, val := range m.counters {
+		out[name] = val
+	}
+	return out
+}
+
+// Metrics returns the current value of every counter kept by the node.
+func (n *Node) Metrics() map[string]uint64 {
+	return n.metrics.snapshot()
+}
@@ -0,0 +1,337 @@
+package boopy
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+var (
+	ERR_NAMESPACE_NOT_FOUND = errors.New("namespace not found")
+	ERR_NAMESPACE_EXISTS    = errors.New("namespace already exists")
+	ERR_INVALID_NAMESPACE   = errors.New("namespace names must be non-empty, without '/' and not start with '_'")
+	ERR_QUOTA_EXCEEDED      = errors.New("namespace quota exceeded")
+)
+
+// Namespace definitions are kept in the ring itself as JSON, one key per
+// namespace in the reserved systemNamespace, so they move with key transfers
+// like any other data.
+const systemNamespace = "_namespaces"
+
+var (
+	defaultNamespace = &api.Namespace{Name: "", ReplicationFactor: 1}
+	systemSettings   = &api.Namespace{Name: systemNamespace, ReplicationFactor: 3}
+)
+
+// namespacedKey is the key hashed to place a namespaced key on the ring.
+// Keys in the default namespace hash as before.
+func namespacedKey(ns, key string) string {
+	if ns == "" {
+		return key
+	}
+	return ns + "/" + key
+}
+
+func validNamespace(name string) bool {
+	return name != "" && !strings.Contains(name, "/") && !strings.HasPrefix(name, "_")
+}
+
+// namespaceCache keeps recently read namespace definitions so the owner of
+// a key does not look them up on every write.
+type namespaceCache struct {
+	mtx     sync.Mutex
+	entries map[string]namespaceEntry
+}
+
+type namespaceEntry struct {
+	settings *api.Namespace
+	fetched  time.Time
+}
+
+func newNamespaceCache() *namespaceCache {
+	return &namespaceCache{entries: make(map[string]namespaceEntry)}
+}
+
+func (c *namespaceCache) get(name string, maxAge time.Duration) *api.Namespace {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	entry, ok := c.entries[name]
+	if !ok || time.Since(entry.fetched) > maxAge {
+		return nil
+	}
+	return entry.settings
+}
+
+func (c *namespaceCache) put(settings *api.Namespace) {
+	c.mtx.Lock()
+	c.entries[settings.Name] = namespaceEntry{settings: settings, fetched: time.Now()}
+	c.mtx.Unlock()
+}
+
+func (c *namespaceCache) forget(name string) {
+	c.mtx.Lock()
+	delete(c.entries, name)
+	c.mtx.Unlock()
+}
+
+// namespaceSettings returns the definition of a namespace, reading it from
+// the ring unless a fresh copy is cached.
+func (n *Node) namespaceSettings(name string) (*api.Namespace, error) {
+	switch name {
+	case "":
+		return defaultNamespace, nil
+	case systemNamespace:
+		return systemSettings, nil
+	case txnNamespace:
+		return txnSettings, nil
+	case leaseNamespace:
+		return leaseSettings, nil
+	}
+	if settings := n.namespaces.get(name, n.cnf.NamespaceCacheDuration); settings != nil {
+		return settings, nil
+	}
+
+	data, err := n.get(systemNamespace, name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ERR_NAMESPACE_NOT_FOUND
+		}
+		return nil, err
+	}
+	settings := new(api.Namespace)
+	if err := json.Unmarshal(data, settings); err != nil {
+		return nil, err
+	}
+	n.namespaces.put(settings)
+	return settings, nil
+}
+
+// expiry returns when a key written with ttl expires, zero for never.
+func expiry(settings *api.Namespace, ttl int64) time.Time {
+	if ttl == 0 {
+		ttl = settings.DefaultTtl
+	}
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(ttl))
+}
+
+// settingsOf returns the settings of the namespaces of values, nil for
+// namespaces since deleted.
+func (n *Node) settingsOf(values []*api.KV) (map[string]*api.Namespace, error) {
+	settings := make(map[string]*api.Namespace)
+	for _, kv := range values {
+		if _, ok := settings[kv.Namespace]; ok {
+			continue
+		}
+		s, err := n.namespaceSettings(kv.Namespace)
+		if err != nil && err != ERR_NAMESPACE_NOT_FOUND {
+			return nil, err
+		}
+		settings[kv.Namespace] = s
+	}
+	return settings, nil
+}
+
+// checkQuota checks that writing key would keep the namespace within its
+// limits on this node. Must be called with stMtx held.
+func (n *Node) checkQuota(settings *api.Namespace, key, value string) error {
+	if settings.MaxKeys == 0 && settings.MaxBytes == 0 {
+		return nil
+	}
+	keys, size := n.storage.Usage(settings.Name)
+	if old, err := n.storage.Get(settings.Name, key); err == nil {
+		size -= len(key) + len(old)
+	} else {
+		keys++
+	}
+	size += len(key) + len(value)
+
+	if settings.MaxKeys > 0 && uint64(keys) > settings.MaxKeys {
+		return ERR_QUOTA_EXCEEDED
+	}
+	if settings.MaxBytes > 0 && uint64(size) > settings.MaxBytes {
+		return ERR_QUOTA_EXCEEDED
+	}
+	return nil
+}
+
+// replicas returns the nodes after us that hold copies of our keys in a
+// namespace, following successor pointers.
+func (n *Node) replicas(settings *api.Namespace) []*api.Node {
+	count := int(settings.ReplicationFactor) - 1
+	if count <= 0 {
+		return nil
+	}
+	n.succMtx.RLock()
+	next := n.successor
+	n.succMtx.RUnlock()
+
+	replicas := make([]*api.Node, 0, count)
+	for next != nil && len(replicas) < count && !bytesEqual(next.Id, n.Id) {
+		replicas = append(replicas, next)
+		succ, err := n.getSuccessorRPC(next)
+		if err != nil {
+			log.Println("error walking replicas: ", next.Addr, err)
+			break
+		}
+		next = succ
+	}
+	return replicas
+}
+
+// replicate copies pairs written, and tombstones left, on the owner to the
+// namespace replicas.
+func (n *Node) replicate(settings *api.Namespace, kvs ...*api.KV) {
+	if len(kvs) == 0 {
+		return
+	}
+	for _, replica := range n.replicas(settings) {
+		if err := n.storeKeysRPC(replica, kvs); err != nil {
+			log.Println("error replicating keys: ", len(kvs), replica.Addr, err)
+			n.metrics.inc("replication_failures")
+		}
+	}
+}
+
+// expireKeys drops keys whose ttl has run out.
+func (n *Node) expireKeys() {
+	n.stMtx.Lock()
+	removed := n.storage.Expire(time.Now())
+	n.record(api.Change_EXPIRE, removed...)
+	n.stMtx.Unlock()
+	if len(removed) > 0 {
+		n.metrics.add("expired_keys", uint64(len(removed)))
+	}
+}
+
+// walkRing calls fn on every node, in ring order starting with ourselves.
+func (n *Node) walkRing(fn func(*api.Node) error) error {
+	visited := make([][]byte, 0, 8)
+	node := n.Node
+	for node != nil && !containsID(visited, node.Id) {
+		if err := fn(node); err != nil {
+			return err
+		}
+		visited = append(visited, node.Id)
+
+		var err error
+		if bytesEqual(node.Id, n.Id) {
+			n.succMtx.RLock()
+			node = n.successor
+			n.succMtx.RUnlock()
+		} else if node, err = n.getSuccessorRPC(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Node) CreateNamespace(settings *api.Namespace) error {
+	if !validNamespace(settings.Name) {
+		return ERR_INVALID_NAMESPACE
+	}
+	if _, err := n.get(systemNamespace, settings.Name); err == nil {
+		return ERR_NAMESPACE_EXISTS
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return n.set(systemNamespace, settings.Name, string(data), nil, 0)
+}
+
+// DeleteNamespace removes a namespace definition and drops its keys from
+// every node in the ring.
+func (n *Node) DeleteNamespace(name string) error {
+	if !validNamespace(name) {
+		return ERR_INVALID_NAMESPACE
+	}
+	if _, err := n.namespaceSettings(name); err != nil {
+		return err
+	}
+	if err := n.delete(systemNamespace, name); err != nil {
+		return err
+	}
+	return n.walkRing(func(node *api.Node) error {
+		return n.dropNamespaceRPC(node, name)
+	})
+}
+
+// ListNamespaces collects the namespace definitions held across the ring.
+func (n *Node) ListNamespaces() ([]*api.Namespace, error) {
+	found := make(map[string]*api.Namespace)
+	err := n.walkRing(func(node *api.Node) error {
+		list, err := n.listNamespacesRPC(node)
+		for _, settings := range list {
+			found[settings.Name] = settings
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]*api.Namespace, 0, len(found))
+	for _, settings := range found {
+		namespaces = append(namespaces, settings)
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+	return namespaces, nil
+}
+
+func (n *Node) dropNamespaceRPC(node *api.Node, name string) error {
+	req := &api.NamespaceRequest{Name: name}
+	if bytesEqual(node.Id, n.Id) {
+		_, err := n.XDropNamespace(context.Background(), req)
+		return err
+	}
+	return n.transport.DropNamespace(node, req)
+}
+
+func (n *Node) listNamespacesRPC(node *api.Node) ([]*api.Namespace, error) {
+	if bytesEqual(node.Id, n.Id) {
+		res, err := n.XListNamespaces(context.Background(), emptyRequest)
+		return res.Namespaces, err
+	}
+	return n.transport.ListNamespaces(node)
+}
+
+func (n *Node) XDropNamespace(ctx context.Context, req *api.NamespaceRequest) (*api.ER, error) {
+	n.namespaces.forget(req.Name)
+	n.stMtx.Lock()
+	defer n.stMtx.Unlock()
+	if dropped, err := n.storage.List(req.Name); err == nil {
+		n.record(api.Change_DELETE, dropped...)
+	}
+	return emptyRequest, n.storage.DropNamespace(req.Name)
+}
+
+func (n *Node) XListNamespaces(ctx context.Context, r *api.ER) (*api.NamespaceList, error) {
+	n.stMtx.RLock()
+	vals, err := n.storage.List(systemNamespace)
+	n.stMtx.RUnlock()
+	if err != nil {
+		return &api.NamespaceList{}, err
+	}
+
+	list := &api.NamespaceList{}
+	for _, kv := range vals {
+		settings := new(api.Namespace)
+		if err := json.Unmarshal([]byte(kv.Value), settings); err != nil {
+			continue
+		}
+		list.Namespaces = append(list.Namespaces, settings)
+	}
+	return list, nil
+}
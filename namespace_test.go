@@ -0,0 +1,148 @@
+package boopy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+func TestNode_namespaces(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+
+	settings := &api.Namespace{Name: "photos", ReplicationFactor: 2, MaxKeys: 2}
+	if err := nodes[0].CreateNamespace(settings); err != nil {
+		t.Fatalf("CreateNamespace() error = %v", err)
+	}
+	if err := nodes[1].CreateNamespace(settings); err != ERR_NAMESPACE_EXISTS {
+		t.Errorf("CreateNamespace() twice error = %v, want %v", err, ERR_NAMESPACE_EXISTS)
+	}
+	if err := nodes[1].CreateNamespace(&api.Namespace{Name: "_system"}); err != ERR_INVALID_NAMESPACE {
+		t.Errorf("CreateNamespace(_system) error = %v, want %v", err, ERR_INVALID_NAMESPACE)
+	}
+	if err := nodes[1].SetIn("missing", "key", "v", 0); err == nil {
+		t.Errorf("SetIn() on a missing namespace succeeded")
+	}
+
+	if err := nodes[1].SetIn("photos", "key", "photo", 0); err != nil {
+		t.Fatalf("SetIn() error = %v", err)
+	}
+	if err := nodes[2].Set("key", "default"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := nodes[2].GetIn("photos", "key"); err != nil || string(got) != "photo" {
+		t.Errorf("GetIn(photos, key) = %q, %v, want photo", got, err)
+	}
+	if got, err := nodes[0].Get("key"); err != nil || string(got) != "default" {
+		t.Errorf("Get(key) = %q, %v, want default", got, err)
+	}
+
+	// Each key is held by its owner and one replica.
+	copies := func(ns, key string) int {
+		count := 0
+		for _, node := range nodes {
+			node.stMtx.RLock()
+			if _, err := node.storage.Get(ns, key); err == nil {
+				count++
+			}
+			node.stMtx.RUnlock()
+		}
+		return count
+	}
+	if got := copies("photos", "key"); got != 2 {
+		t.Errorf("photos/key stored on %d nodes, want 2", got)
+	}
+
+	list, err := nodes[2].ListNamespaces()
+	if err != nil || len(list) != 1 || list[0].Name != "photos" || list[0].MaxKeys != 2 {
+		t.Errorf("ListNamespaces() = %v, %v", list, err)
+	}
+
+	if err := nodes[0].DeleteNamespace("photos"); err != nil {
+		t.Fatalf("DeleteNamespace() error = %v", err)
+	}
+	if got := copies("photos", "key"); got != 0 {
+		t.Errorf("photos/key still stored on %d nodes after DeleteNamespace()", got)
+	}
+	if _, err := nodes[1].GetIn("photos", "key"); err == nil {
+		t.Errorf("GetIn() after DeleteNamespace() succeeded")
+	}
+	if got, err := nodes[0].Get("key"); err != nil || string(got) != "default" {
+		t.Errorf("Get(key) after DeleteNamespace() = %q, %v", got, err)
+	}
+}
+
+func TestNode_namespaceLimits(t *testing.T) {
+	node := newTestRing(t, 1, nil)[0]
+
+	err := node.CreateNamespace(&api.Namespace{
+		Name:       "sessions",
+		DefaultTtl: int64(time.Hour),
+		MaxKeys:    2,
+	})
+	if err != nil {
+		t.Fatalf("CreateNamespace() error = %v", err)
+	}
+
+	if err := node.SetIn("sessions", "a", "1", 0); err != nil {
+		t.Fatalf("SetIn(a) error = %v", err)
+	}
+	if err := node.SetIn("sessions", "b", "2", time.Millisecond); err != nil {
+		t.Fatalf("SetIn(b) error = %v", err)
+	}
+	if err := node.SetIn("sessions", "c", "3", 0); err == nil {
+		t.Errorf("SetIn(c) over MaxKeys succeeded")
+	}
+	// Overwriting an existing key does not add to the quota.
+	if err := node.SetIn("sessions", "a", "4", 0); err != nil {
+		t.Errorf("SetIn(a) overwrite error = %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := node.GetIn("sessions", "b"); err == nil {
+		t.Errorf("GetIn(b) after its ttl succeeded")
+	}
+	node.expireKeys()
+	if err := node.SetIn("sessions", "c", "3", 0); err != nil {
+		t.Errorf("SetIn(c) after b expired error = %v", err)
+	}
+
+	list, _ := node.storage.List("sessions")
+	for _, kv := range list {
+		if kv.Key == "a" && time.Until(kvExpiry(kv)) < 59*time.Minute {
+			t.Errorf("a expires at %v, want the one hour default", kvExpiry(kv))
+		}
+	}
+}
+
+func TestNode_storeKeysQuota(t *testing.T) {
+	node := newTestRing(t, 1, nil)[0]
+	if err := node.CreateNamespace(&api.Namespace{Name: "small", MaxKeys: 2}); err != nil {
+		t.Fatal(err)
+	}
+	copies := func(keys ...string) []*api.KV {
+		kvs := make([]*api.KV, len(keys))
+		for i, key := range keys {
+			kvs[i] = &api.KV{Namespace: "small", Key: key, Value: "v", Version: 1}
+		}
+		return kvs
+	}
+
+	_, err := node.XStoreKeys(context.Background(), &api.StoreKeysRequest{Values: copies("a", "b", "c")})
+	if err != ERR_QUOTA_EXCEEDED {
+		t.Errorf("XStoreKeys() over MaxKeys error = %v, want %v", err, ERR_QUOTA_EXCEEDED)
+	}
+	if keys, _ := node.storage.Usage("small"); keys != 2 {
+		t.Errorf("holds %d keys after XStoreKeys(), want 2", keys)
+	}
+
+	// A range handed over is kept whole.
+	_, err = node.XStoreKeys(context.Background(), &api.StoreKeysRequest{Values: copies("d"), Transfer: true})
+	if err != nil {
+		t.Errorf("XStoreKeys() of a handover error = %v", err)
+	}
+	if _, err := node.storage.Get("small", "d"); err != nil {
+		t.Errorf("handed over key dropped: %v", err)
+	}
+}
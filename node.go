@@ -5,27 +5,48 @@ import (
 	"hash"
 	"log"
 	"math/big"
+	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jseam2/boopy/api"
 	aurora "github.com/logrusorgru/aurora"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func BaseConfig() *Config {
 	n := &Config{
-		Hash:     sha1.New,
-		DialOpts: make([]grpc.DialOption, 0, 5),
+		Hash:           sha1.New,
+		DialOpts:       make([]grpc.DialOption, 0, 5),
+		MaxForwardHops: 2,
+		MaxRedirects:   3,
+		MaxLookupHops:  32,
+		LookupMode:     LookupRecursive,
+
+		LocationCacheTTL:       10 * time.Second,
+		NamespaceCacheDuration: 5 * time.Second,
+		ChangeLogSize:          10000,
+		AntiEntropyInterval:    30 * time.Second,
+		RepairRate:             1000,
+		MaxHints:               10000,
+		HintWindow:             3 * time.Hour,
+		TombstoneGracePeriod:   24 * time.Hour,
+		TxnLockTimeout:         10 * time.Second,
+		TxnRecordRetention:     time.Hour,
+		MaxClockDrift:          time.Minute,
 	}
 	// n.HashSize = n.Hash().Size()
 	n.HashSize = n.Hash().Size() * 8
 
+	// Transport security is added by the transport, depending on
+	// whether TLS is configured.
 	n.DialOpts = append(n.DialOpts,
 		grpc.WithBlock(),
 		grpc.WithTimeout(5*time.Second),
 		grpc.FailOnNonTempDialError(true),
-		grpc.WithInsecure(),
 	)
 	return n
 }
@@ -41,6 +62,62 @@ type Config struct {
 	HashSize           int              // number of fingers in finger table
 	MaxTimeoutDuration time.Duration
 	MaxIdleDuration    time.Duration
+
+	MaxForwardHops int // times a misrouted storage request is forwarded before redirecting the caller
+	MaxRedirects   int // times a caller follows redirects before giving up
+	MaxLookupHops  int // nodes a lookup may be forwarded through before failing
+
+	LookupMode LookupMode // how lookups started by this node traverse the ring
+
+	LocationCacheSize int           // number of owner ranges cached for Get/Set/Delete, 0 disables the cache
+	LocationCacheTTL  time.Duration // how long a cached range is used before it is looked up again, 0 for no limit
+
+	NamespaceCacheDuration time.Duration // how long namespace settings are reused before being read again
+
+	ChangeLogSize int // number of storage changes kept for XChanges readers, 0 disables the log
+
+	AntiEntropyInterval time.Duration // how often owners compare their keys with replicas, 0 disables it
+	RepairRate          int           // keys repaired per second by anti-entropy, 0 for no limit
+
+	// MaxHints is how many writes are kept for unreachable owners until
+	// they can be replayed; 0 disables hinted handoff. Hints are saved to
+	// HintFile, without which hinted handoff is off too: a hinted write is
+	// acknowledged once saved there, so it survives a restart of the node
+	// keeping it but not the loss of its disk.
+	MaxHints   int
+	HintFile   string
+	HintWindow time.Duration // how long a hinted write is kept before it is dropped
+
+	// TombstoneGracePeriod is how long deleted keys are remembered, so that
+	// replicas which missed the delete do not bring them back. It should be
+	// well over HintWindow and AntiEntropyInterval; 0 keeps tombstones forever.
+	TombstoneGracePeriod time.Duration
+
+	// MaxClockDrift is how far ahead of our wall clock the clocks of other
+	// nodes, and versions stored with values, are followed; 0 for no limit.
+	MaxClockDrift time.Duration
+
+	TxnLockTimeout     time.Duration // how long a prepared transaction locks its keys before asking its coordinator record for the outcome
+	TxnRecordRetention time.Duration // how long coordinator records are kept, well over TxnLockTimeout
+
+	// KVAddr is where the KeyValue service answers clients, apart from the
+	// Chord service nodes talk over; empty disables it. Clients connect in
+	// plaintext unless KVTLSCertFile is set, and must present a certificate
+	// signed by KVTLSCAFile when that is set too.
+	KVAddr        string
+	KVTLSCertFile string
+	KVTLSKeyFile  string
+	KVTLSCAFile   string
+
+	// Mutual TLS between nodes. Setting TLSCertFile enables it; peers must
+	// present certificates signed by TLSCAFile whose common name or DNS name
+	// is the Id (or Addr) they were started with. The client certificate
+	// defaults to the server certificate.
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCertFile string
+	TLSClientKeyFile  string
+	TLSCAFile         string
 }
 
 // Create a node entry, for storage in finger table
@@ -64,11 +141,26 @@ func NewInode(id string, addr string) *api.Node {
 func NewNode(cnf *Config, joinNode *api.Node) (*Node, error) {
 	var nodeID string
 
+	hints, err := openHintStore(cnf.HintFile)
+	if err != nil {
+		return nil, err
+	}
 	node := &Node{
 		Node:       new(api.Node),
 		shutdownCh: make(chan struct{}),
 		cnf:        cnf,
 		storage:    NewMapStore(cnf.Hash),
+		metrics:    newMetrics(),
+		namespaces: newNamespaceCache(),
+		watches:    newWatchHub(),
+		changes:    newChangeLog(cnf.ChangeLogSize),
+		merkles:    newMerkleCache(),
+		repairs:    newRateLimiter(cnf.RepairRate),
+		hints:      hints,
+		txns:       newTxnTable(),
+	}
+	if cnf.LocationCacheSize > 0 {
+		node.locations = newLocationCache(cnf.LocationCacheSize, cnf.LocationCacheTTL)
 	}
 	if cnf.Id != "" {
 		nodeID = cnf.Id
@@ -98,8 +190,16 @@ func NewNode(cnf *Config, joinNode *api.Node) (*Node, error) {
 	}
 
 	node.transport = transport
+	node.clock = transport.clock
 
 	api.RegisterChordServer(transport.server, node)
+	var kvListener net.Listener
+	if cnf.KVAddr != "" {
+		if kvListener, err = node.listenKeyValue(); err != nil {
+			node.transport.Stop()
+			return nil, err
+		}
+	}
 	node.transport.Start()
 
 	// find the closest node clockwise from the id of this node (i.e. successor node)
@@ -108,7 +208,14 @@ func NewNode(cnf *Config, joinNode *api.Node) (*Node, error) {
 
 	if nodeJoinErr != nil {
 		log.Printf("Error joining node")
-		return nil, err
+		if kvListener != nil {
+			kvListener.Close()
+		}
+		node.transport.Stop()
+		return nil, nodeJoinErr
+	}
+	if kvListener != nil {
+		go node.kv.Serve(kvListener)
 	}
 
 	// run routines
@@ -119,6 +226,16 @@ func NewNode(cnf *Config, joinNode *api.Node) (*Node, error) {
 	go node.stabilizeRoutine(1000)
 	// Check predecessor fail every 5000 ms
 	go node.checkPredecessorRoutine(2000)
+	// Drop expired keys every 1000 ms
+	go node.expireRoutine(1000)
+	// Replay hinted writes every 1000 ms
+	go node.hintRoutine(1000)
+	// Settle transactions whose locks timed out every 1000 ms
+	go node.txnRoutine(1000)
+	// Compare keys with replicas every AntiEntropyInterval
+	if cnf.AntiEntropyInterval > 0 {
+		go node.antiEntropyRoutine(int(cnf.AntiEntropyInterval / time.Millisecond))
+	}
 
 	return node, nil
 }
@@ -143,6 +260,21 @@ type Node struct {
 	transport     Transport
 	tsMtx         sync.RWMutex
 	lastStablized time.Time
+
+	locations  *locationCache // nil when the location cache is disabled
+	metrics    *metrics
+	namespaces *namespaceCache
+	watches    *watchHub
+	changes    *changeLog
+	merkles    *merkleCache
+	repairs    *rateLimiter
+	hints      *hintStore
+	txns       *txnTable // prepared transactions, guarded by stMtx
+	clock      *hlc // shared with the transport, which advances it on every RPC
+	kv         *grpc.Server // KeyValue service for clients, nil without KVAddr
+
+	leaving  int32 // set atomically once Stop starts handing over our range
+	stopOnce sync.Once
 }
 
 func (n *Node) hashKey(key string) ([]byte, error) {
@@ -158,6 +290,19 @@ func (n *Node) hashKey(key string) ([]byte, error) {
 	return val, nil
 }
 
+// owns checks if id falls in the range (predecessor, n] this node is
+// responsible for. Without a known predecessor every id is accepted.
+func (n *Node) owns(id []byte) bool {
+	n.predMtx.RLock()
+	pred := n.predecessor
+	n.predMtx.RUnlock()
+
+	if pred == nil || pred.Id == nil {
+		return true
+	}
+	return keyBetwIncludeRight(id, pred.Id, n.Id)
+}
+
 func (incomingNode *Node) join(joinNode *api.Node) error {
 	// First check if node already present in the circle
 	// Join this node to the same chord ring as parent
@@ -184,6 +329,7 @@ func (incomingNode *Node) join(joinNode *api.Node) error {
 	incomingNode.succMtx.Lock()
 	incomingNode.successor = succ
 	incomingNode.succMtx.Unlock()
+	incomingNode.neighboursChanged()
 	return nil
 }
 
@@ -196,13 +342,35 @@ func (n *Node) Find(key string) (*api.Node, error) {
 }
 
 func (n *Node) Get(key string) ([]byte, error) {
-	return n.get(key)
+	return n.get("", key)
 }
 func (n *Node) Set(key, value string) error {
-	return n.set(key, value)
+	return n.set("", key, value, nil, 0)
 }
 func (n *Node) Delete(key string) error {
-	return n.delete(key)
+	return n.delete("", key)
+}
+
+// GetIn, SetIn and DeleteIn work on keys of a namespace created with
+// CreateNamespace. A zero ttl uses the namespace default.
+func (n *Node) GetIn(ns, key string) ([]byte, error) {
+	return n.get(ns, key)
+}
+func (n *Node) SetIn(ns, key, value string, ttl time.Duration) error {
+	return n.set(ns, key, value, nil, ttl)
+}
+func (n *Node) DeleteIn(ns, key string) error {
+	return n.delete(ns, key)
+}
+
+// GetStamped reads a key of a namespace along with the timestamp of the
+// write that stored it.
+func (n *Node) GetStamped(ns, key string) ([]byte, Timestamp, error) {
+	res, err := n.lookup(ns, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Value, Timestamp(res.Version), nil
 }
 
 func (n *Node) Join(joinNode *api.Node) error {
@@ -213,8 +381,18 @@ func (n *Node) Stabilize() {
 	n.stabilize()
 }
 
+// Stop hands our range to the successor and stops serving. Calls after the
+// first do nothing.
 func (n *Node) Stop() {
+	n.stopOnce.Do(n.stop)
+}
+
+func (n *Node) stop() {
+	atomic.StoreInt32(&n.leaving, 1)
 	close(n.shutdownCh)
+	if n.kv != nil {
+		n.kv.Stop()
+	}
 
 	// Notify successor to change its predecessor pointer to our predecessor.
 	// Do nothing if we are our own successor (i.e. we are the only node in the
@@ -227,8 +405,10 @@ func (n *Node) Stop() {
 	n.predMtx.RUnlock()
 
 	if n.Node.Addr != succ.Addr && pred != nil {
-		n.transferKeysFromNode(pred, succ)
+		// Successor must take over our range before it will accept our keys.
 		predErr := n.setPredecessorRPC(succ, pred)
+		n.transferKeysFromNode(pred, succ)
+		n.watches.closeAll(succ)
 		succErr := n.setSuccessorRPC(pred, succ)
 		log.Println("stop errors: ", predErr, succErr)
 	}
@@ -241,166 +421,298 @@ func (n *Node) locate(key string) (*api.Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	succ, err := n.findSuccessor(id)
-	return succ, err
+
+	if n.locations != nil {
+		if owner := n.locations.get(id); owner != nil {
+			n.metrics.inc("location_cache_hits")
+			return owner, nil
+		}
+		n.metrics.inc("location_cache_misses")
+	}
+	return n.lookupOwner(id, n.locations)
 }
 
-func (n *Node) get(key string) ([]byte, error) {
-	node, err := n.locate(key)
+// lookupOwner finds the owner of id and remembers its range in cache, which
+// may be nil.
+func (n *Node) lookupOwner(id []byte, cache *locationCache) (*api.Node, error) {
+	succ, hops, err := n.findSuccessorMode(id, LookupDefault)
 	if err != nil {
 		return nil, err
 	}
-	val, err := n.getKeyRPC(node, key)
+	// The last hop answered from its successor pointer, so the owner is
+	// responsible for everything between the two.
+	if cache != nil && len(hops) > 0 {
+		last := hops[len(hops)-1].Node
+		if !bytesEqual(last.Id, succ.Id) {
+			cache.put(last.Id, succ)
+		}
+	}
+	return succ, nil
+}
+
+// forgetLocation drops a cached owner that turned out to be wrong.
+func (n *Node) forgetLocation(node *api.Node) {
+	if n.locations != nil && n.locations.invalidate(node) {
+		n.metrics.inc("location_cache_invalidations")
+	}
+}
+
+// neighboursChanged is called whenever the successor or predecessor
+// changes. Ranges may have moved, so cached owners can no longer be trusted.
+func (n *Node) neighboursChanged() {
+	if n.locations != nil {
+		n.locations.clear()
+		n.metrics.inc("location_cache_invalidations")
+	}
+}
+
+// followRedirects runs call against node, retrying against the owner
+// carried by a redirect error until it succeeds or MaxRedirects is reached.
+func (n *Node) followRedirects(node *api.Node, call func(*api.Node) error) error {
+	for i := 0; ; i++ {
+		err := call(node)
+		if err != nil && status.Code(err) == codes.Unavailable {
+			n.forgetLocation(node)
+		}
+		owner := redirectOwner(err)
+		if owner == nil {
+			return err
+		}
+		n.forgetLocation(node)
+		if i >= n.cnf.MaxRedirects {
+			return ERR_TOO_MANY_REDIRECTS
+		}
+		node = owner
+	}
+}
+
+func (n *Node) get(ns, key string) ([]byte, error) {
+	res, err := n.lookup(ns, key)
 	if err != nil {
 		return nil, err
 	}
-	return val.Value, nil
+	if len(res.Siblings) > 1 {
+		return nil, ERR_SIBLINGS
+	}
+	return res.Value, nil
 }
 
-func (n *Node) set(key, value string) error {
-	node, err := n.locate(key)
+// lookup reads key from its owner, with its version and expiry.
+func (n *Node) lookup(ns, key string) (*api.GetResponse, error) {
+	node, err := n.locate(namespacedKey(ns, key))
 	if err != nil {
+		return nil, err
+	}
+	var val *api.GetResponse
+	err = n.followRedirects(node, func(node *api.Node) error {
+		val, err = n.getKeyRPC(node, ns, key)
 		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	err = n.setKeyRPC(node, key, value)
-	return err
+	return val, nil
 }
 
-func (n *Node) delete(key string) error {
-	node, err := n.locate(key)
+func (n *Node) set(ns, key, value string, ctx []byte, ttl time.Duration) error {
+	node, err := n.locate(namespacedKey(ns, key))
 	if err != nil {
 		return err
 	}
-	err = n.deleteKeyRPC(node, key)
+	err = n.followRedirects(node, func(owner *api.Node) error {
+		node = owner
+		return n.setKeyRPC(owner, ns, key, value, ctx, ttl)
+	})
+	if err != nil && err != ERR_TOO_MANY_REDIRECTS && unreachable(err) {
+		return n.hintWrite(node, ns, key, value, ctx, ttl, err)
+	}
 	return err
 }
 
-func (n *Node) transferKeys(pred, succ *api.Node) {
+func (n *Node) delete(ns, key string) error {
+	node, err := n.locate(namespacedKey(ns, key))
+	if err != nil {
+		return err
+	}
+	return n.followRedirects(node, func(node *api.Node) error {
+		return n.deleteKeyRPC(node, ns, key)
+	})
+}
 
-	keys, _ := n.requestKeys(pred, succ)
-	if len(keys) > 0 {
-		log.Printf("Transfer Keys: %+v", keys)
+// transferKeys hands the keys in (pred, succ] to succ, which has just become
+// our predecessor. Copies are kept for namespaces that replicate to us.
+func (n *Node) transferKeys(pred, succ *api.Node) {
+	n.stMtx.RLock()
+	keys, err := n.storage.Between(pred.Id, succ.Id)
+	n.stMtx.RUnlock()
+	if err != nil || len(keys) == 0 {
+		return
+	}
+	if err := n.handOverKeysRPC(succ, keys); err != nil {
+		log.Println("error transfering keys: ", succ.Addr, err)
+		return
 	}
-	delKeyList := make([]string, 0, 10)
-	// store the keys in current node
+	delKeyList := make(map[string][]string)
+	moved := make(map[string][]string) // live keys, tombstones are not recorded
 	for _, item := range keys {
-		if item == nil {
+		settings, err := n.namespaceSettings(item.Namespace)
+		if err == nil && settings.ReplicationFactor > 1 {
 			continue
 		}
-		n.storage.Set(item.Key, item.Value)
-		delKeyList = append(delKeyList, item.Key)
+		delKeyList[item.Namespace] = append(delKeyList[item.Namespace], item.Key)
+		if !item.Deleted {
+			moved[item.Namespace] = append(moved[item.Namespace], item.Key)
+		}
 	}
-	// delete the keys from the successor node, as current node
-	// is responsible for the keys
-	if len(delKeyList) > 0 {
-		n.deleteKeys(succ, delKeyList)
+	// delete the keys from the current node, as the new predecessor
+	// is responsible for them
+	n.stMtx.Lock()
+	for ns, delKeys := range delKeyList {
+		n.storage.MDelete(ns, delKeys...)
+		n.recordKeys(api.Change_TRANSFER_OUT, ns, moved[ns]...)
 	}
-
+	n.stMtx.Unlock()
 }
 
+// transferKeysFromNode hands the keys in (pred, succ], ours, to succ as we
+// leave. Keys are only deleted once succ has stored them.
 func (n *Node) transferKeysFromNode(pred, succ *api.Node) {
+	n.stMtx.RLock()
 	keys, err := n.storage.Between(pred.Id, succ.Id)
-	if len(keys) > 0 {
-		log.Println("transfering: ", keys, succ, err)
+	n.stMtx.RUnlock()
+	if err != nil || len(keys) == 0 {
+		return
 	}
-	delKeyList := make([]string, 0, 10)
-	// store the keys in current node
+	// store the keys in the successor, keeping namespaces and expiry
+	if err := n.handOverKeysRPC(succ, keys); err != nil {
+		log.Println("error transfering keys: ", succ.Addr, err)
+		return
+	}
+	delKeyList := make(map[string][]string)
+	moved := make(map[string][]string)
 	for _, item := range keys {
-		if item == nil {
-			continue
-		}
-		err := n.setKeyRPC(succ, item.Key, item.Value)
-		if err != nil {
-			log.Println("error transfering key: ", item.Key, succ.Addr)
+		delKeyList[item.Namespace] = append(delKeyList[item.Namespace], item.Key)
+		if !item.Deleted {
+			moved[item.Namespace] = append(moved[item.Namespace], item.Key)
 		}
-		delKeyList = append(delKeyList, item.Key)
 	}
-	// delete the keys from the successor node, as current node
-	// is responsible for the keys
-	if len(delKeyList) > 0 {
-		n.deleteKeys(succ, delKeyList)
+	// delete the keys from the current node, as successor node
+	// is now responsible for the keys
+	n.stMtx.Lock()
+	for ns, delKeys := range delKeyList {
+		n.storage.MDelete(ns, delKeys...)
+		n.recordKeys(api.Change_TRANSFER_OUT, ns, moved[ns]...)
 	}
-
+	n.stMtx.Unlock()
 }
 
-func (n *Node) deleteKeys(node *api.Node, keys []string) error {
-	return n.deleteKeysRPC(node, keys)
+func (n *Node) deleteKeys(node *api.Node, ns string, keys []string) error {
+	return n.deleteKeysRPC(node, ns, keys)
 }
 
-// When a new node joins, it requests keys from it's successor
-func (n *Node) requestKeys(pred, succ *api.Node) ([]*api.KV, error) {
+func (n *Node) findSuccessor(id []byte) (*api.Node, error) {
+	succ, _, err := n.findSuccessorMode(id, LookupDefault)
+	return succ, err
+}
 
-	if bytesEqual(n.Id, succ.Id) {
-		return nil, nil
+// findSuccessorPath resolves req.Id recursively. req carries the hop count
+// and the nodes visited so far. The returned hops start with this node and
+// end with the node that answered the lookup. Our own hop counts the time
+// spent here; the next hop is timed from here, less the time it spent
+// waiting on the hops after it.
+func (n *Node) findSuccessorPath(req *api.ID) (*api.Node, []*api.Hop, error) {
+	start := time.Now()
+	id := req.Id
+	path := make([]*api.Node, 0, len(req.Path)+1)
+	path = append(path, req.Path...)
+	path = append(path, n.Node)
+	hop := &api.Hop{Node: n.Node, Finger: -1}
+	hops := []*api.Hop{hop}
+
+	for _, visited := range req.Path {
+		if bytesEqual(visited.Id, n.Id) {
+			return nil, hops, &LookupError{Reason: ERR_LOOKUP_LOOP, Path: path}
+		}
+	}
+	if int(req.Hops) > n.cnf.MaxLookupHops {
+		return nil, hops, &LookupError{Reason: ERR_LOOKUP_HOPS, Path: path}
 	}
-	return n.requestKeysRPC(
-		succ, pred.Id, n.Id,
-	)
-}
 
-func (n *Node) findSuccessor(id []byte) (*api.Node, error) {
 	n.succMtx.RLock()
-	defer n.succMtx.RUnlock()
-
-	curr := n.Node
 	succ := n.successor
+	n.succMtx.RUnlock()
 
 	if succ == nil {
-		return curr, nil
+		hop.Latency = int64(time.Since(start))
+		return n.Node, hops, nil
 	}
 
-	var err error
+	if keyBetwIncludeRight(id, n.Id, succ.Id) {
+		hop.Latency = int64(time.Since(start))
+		return succ, hops, nil
+	}
 
-	if keyBetwIncludeRight(id, curr.Id, succ.Id) {
-		return succ, nil
-	} else {
-		pred := n.closestPrecedingNode(id)
-		if bytesEqual(pred.Id, n.Id) {
-			succ, err = n.getSuccessorRPC(pred)
-
-			if err != nil {
-				return nil, err
-			}
-
-			if succ == nil {
-				return pred, nil
-			}
-			return succ, nil
-		}
+	pred, finger := n.closestPrecedingNode(id)
+	if bytesEqual(pred.Id, n.Id) {
+		hop.Latency = int64(time.Since(start))
+		return succ, hops, nil
+	}
 
-		succ, err := n.findSuccessorRPC(pred, id)
-		// fmt.Println("successor to closest node ", succ, err)
-		if err != nil {
-			return nil, err
-		}
-		if succ == nil {
-			// not able to wrap around, current node is the successor
-			return curr, nil
+	hop.Finger = int32(finger)
+	hop.Latency = int64(time.Since(start))
+	start = time.Now()
+	res, err := n.transport.FindSuccessor(pred, &api.ID{
+		Id:   id,
+		Hops: req.Hops + 1,
+		Path: path,
+	})
+	if err != nil {
+		return nil, hops, err
+	}
+	if len(res.Hops) > 0 {
+		latency := int64(time.Since(start))
+		for _, later := range res.Hops[1:] {
+			latency -= later.Latency
 		}
-		return succ, nil
-
+		res.Hops[0].Latency = latency
+	}
+	hops = append(hops, res.Hops...)
+	if res.Node == nil {
+		// not able to wrap around, current node is the successor
+		return n.Node, hops, nil
 	}
-	// return nil, nil
+	return res.Node, hops, nil
 }
 
-// Fig 5 implementation for closest_preceding_node
-func (n *Node) closestPrecedingNode(id []byte) *api.Node {
-	n.predMtx.RLock()
-	defer n.predMtx.RUnlock()
+// Fig 5 implementation for closest_preceding_node. Nodes listed in exclude
+// are skipped, letting iterative lookups route around unreachable nodes.
+// Also returns the finger index the node came from, -1 for the successor.
+func (n *Node) closestPrecedingNode(id []byte, exclude ...[]byte) (*api.Node, int) {
+	n.ftMtx.RLock()
+	defer n.ftMtx.RUnlock()
 
 	curr := n.Node
 
 	m := len(n.fingerTable) - 1
 	for i := m; i >= 0; i-- {
 		f := n.fingerTable[i]
-		if f == nil || f.Node == nil {
+		if f == nil || f.Node == nil || containsID(exclude, f.Node.Id) {
 			continue
 		}
-		if between(f.Id, curr.Id, id) {
-			return f.Node
+		if between(f.Node.Id, curr.Id, id) {
+			return f.Node, i
 		}
 	}
-	return curr
+
+	// Fingers may not have caught up with the ring yet, the successor
+	// is always a valid next hop.
+	n.succMtx.RLock()
+	succ := n.successor
+	n.succMtx.RUnlock()
+	if succ != nil && !containsID(exclude, succ.Id) && between(succ.Id, curr.Id, id) {
+		return succ, -1
+	}
+	return curr, -1
 }
 
 // Pseudocode in paper
@@ -432,6 +744,8 @@ func (n *Node) stabilize() {
 		n.succMtx.Lock()
 		n.successor = pred
 		n.succMtx.Unlock()
+		n.neighboursChanged()
+		succ = pred
 	}
 
 	// call notify
@@ -450,6 +764,7 @@ func (n *Node) checkPredecessor() {
 			n.predMtx.Lock()
 			n.predecessor = nil
 			n.predMtx.Unlock()
+			n.neighboursChanged()
 		}
 	}
 }
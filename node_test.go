@@ -0,0 +1,252 @@
+package boopy
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// freeAddr returns a loopback address with a port nobody is listening on.
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func testConfig(t *testing.T, id string) *Config {
+	cnf := BaseConfig()
+	cnf.Id = id
+	cnf.Addr = freeAddr(t)
+	cnf.MaxTimeoutDuration = time.Second
+	cnf.MaxIdleDuration = time.Minute
+	return cnf
+}
+
+// newTestRing starts size nodes joined into one ring and drives
+// stabilization and finger fixing until the ring is consistent.
+func newTestRing(t *testing.T, size int, configure func(*Config)) []*Node {
+	nodes := make([]*Node, 0, size)
+	var join *api.Node
+	for i := 0; i < size; i++ {
+		cnf := testConfig(t, fmt.Sprintf("%d", i+1))
+		if configure != nil {
+			configure(cnf)
+		}
+		node, err := NewNode(cnf, join)
+		if err != nil || node == nil {
+			t.Fatalf("NewNode() error = %v", err)
+		}
+		nodes = append(nodes, node)
+		join = nodes[0].Node
+	}
+	t.Cleanup(func() {
+		for _, node := range nodes {
+			if node.isLeaving() {
+				continue // already stopped by the test
+			}
+			node.transport.Stop()
+			close(node.shutdownCh)
+		}
+	})
+
+	for round := 0; round < size*2; round++ {
+		for _, node := range nodes {
+			node.stabilize()
+		}
+	}
+	for _, node := range nodes {
+		for i := 0; i < node.cnf.HashSize; i++ {
+			node.fixFinger(i)
+		}
+	}
+	return nodes
+}
+
+// ringOwner computes the owner of key directly from the node IDs.
+func ringOwner(nodes []*Node, key string) *api.Node {
+	id := GetHashID(key)
+	for _, node := range nodes {
+		node.predMtx.RLock()
+		pred := node.predecessor
+		node.predMtx.RUnlock()
+		if pred != nil && keyBetwIncludeRight(id, pred.Id, node.Id) {
+			return node.Node
+		}
+	}
+	return nil
+}
+
+func TestNode_SetGetDelete(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := nodes[i%3].Set(key, key); err != nil {
+			t.Fatalf("Set(%q) error = %v", key, err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		got, err := nodes[(i+1)%3].Get(key)
+		if err != nil || string(got) != key {
+			t.Errorf("Get(%q) = %q, %v, want %q", key, got, err, key)
+		}
+		if err := nodes[(i+2)%3].Delete(key); err != nil {
+			t.Errorf("Delete(%q) error = %v", key, err)
+		}
+		if _, err := nodes[i%3].Get(key); err == nil {
+			t.Errorf("Get(%q) after delete succeeded", key)
+		}
+	}
+}
+
+func TestNode_misroutedSet(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+
+	key := "misrouted"
+	owner := ringOwner(nodes, key)
+	var wrong *Node
+	for _, node := range nodes {
+		if !bytesEqual(node.Id, owner.Id) {
+			wrong = node
+			break
+		}
+	}
+
+	// Writing straight to the wrong node must still land on the owner.
+	if err := nodes[0].setKeyRPC(wrong.Node, "", key, "v", nil, 0); err != nil {
+		t.Fatalf("setKeyRPC() error = %v", err)
+	}
+	if _, err := wrong.storage.Get("", key); err == nil {
+		t.Errorf("misrouted key stored on %s", wrong.Addr)
+	}
+
+	// With forwarding disabled the caller is redirected to the owner.
+	wrong.cnf.MaxForwardHops = 0
+	err := nodes[0].setKeyRPC(wrong.Node, "", key, "v", nil, 0)
+	if got := redirectOwner(err); got == nil || !bytesEqual(got.Id, owner.Id) {
+		t.Errorf("setKeyRPC() redirect = %v, want %v", got, owner)
+	}
+}
+
+func TestNode_joinHandsOverKeys(t *testing.T) {
+	nodes := newTestRing(t, 1, nil)
+	first := nodes[0]
+	if err := first.CreateNamespace(&api.Namespace{Name: "rep", ReplicationFactor: 2}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := first.Set(key, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := first.SetIn("rep", key, key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	joined, err := NewNode(testConfig(t, "2"), first.Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		joined.transport.Stop()
+		close(joined.shutdownCh)
+	})
+	ring := []*Node{first, joined}
+	for round := 0; round < 4; round++ {
+		first.stabilize()
+		joined.stabilize()
+	}
+
+	moved := 0
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if !bytesEqual(ringOwner(ring, key).Id, joined.Id) {
+			continue
+		}
+		moved++
+		if val, err := joined.storage.Get("", key); err != nil || string(val) != key {
+			t.Errorf("joined node holds %s = %q, %v, want it handed over", key, val, err)
+		}
+		if _, err := first.storage.Get("", key); err == nil {
+			t.Errorf("%s left behind on the old owner", key)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if !bytesEqual(ringOwner(ring, namespacedKey("rep", key)).Id, joined.Id) {
+			continue
+		}
+		moved++
+		// Namespaces replicating to the old owner keep its copy.
+		if _, err := first.storage.Get("rep", key); err != nil {
+			t.Errorf("replicated %s dropped from the old owner: %v", key, err)
+		}
+		if val, err := joined.storage.Get("rep", key); err != nil || string(val) != key {
+			t.Errorf("joined node holds replicated %s = %q, %v", key, val, err)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("no key moved to the joined node")
+	}
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if val, err := first.Get(key); err != nil || string(val) != key {
+			t.Errorf("Get(%s) after join = %q, %v", key, val, err)
+		}
+	}
+}
+
+// refuseStoreTransport fails every StoreKeys, as a successor that cannot
+// take our keys would.
+type refuseStoreTransport struct {
+	Transport
+}
+
+func (rt *refuseStoreTransport) StoreKeys(node *api.Node, req *api.StoreKeysRequest) error {
+	return status.Error(codes.Unavailable, "refused")
+}
+
+func TestNode_transferKeysFromNodeFailed(t *testing.T) {
+	node := newTestRing(t, 1, nil)[0]
+	for i := 0; i < 10; i++ {
+		if err := node.Set(fmt.Sprintf("key%d", i), "v"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logged, _ := node.Changes(0, 1000)
+	next := logged[len(logged)-1].Seq + 1
+	node.transport = &refuseStoreTransport{node.transport}
+
+	succ := NewInode("2", freeAddr(t))
+	node.transferKeysFromNode(succ, succ)
+	for i := 0; i < 10; i++ {
+		if _, err := node.storage.Get("", fmt.Sprintf("key%d", i)); err != nil {
+			t.Errorf("key%d dropped after a failed handover: %v", i, err)
+		}
+	}
+	if changes, _ := node.Changes(next, 100); len(changes) != 0 {
+		t.Errorf("failed handover logged %d changes, want none", len(changes))
+	}
+}
+
+func TestNode_StopTwice(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	nodes[1].Stop()
+	nodes[1].Stop()
+	if !nodes[1].isLeaving() {
+		t.Errorf("node not leaving after Stop()")
+	}
+	if err := nodes[0].Set("key", "v"); err != nil {
+		t.Errorf("Set() on the remaining node error = %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package boopy
+
+import (
+	"errors"
+	"log"
+	"math"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+var ERR_QUORUM_NOT_REACHED = errors.New("not enough replicas answered")
+
+// nextVersion returns the version for a new write of key: a timestamp from
+// our clock, newer than the one stored. A stored version further ahead than
+// the clock follows is stepped past without moving the clock, so the key
+// stays writable. Must be called with stMtx held.
+func (n *Node) nextVersion(ns, key string) int64 {
+	version := n.clock.now()
+	if old, err := n.storage.Lookup(ns, key); err == nil && old.Version >= version {
+		version = n.clock.update(old.Version)
+		if version <= old.Version && old.Version < math.MaxInt64 {
+			version = old.Version + 1
+		}
+	}
+	return version
+}
+
+// replicaSet returns owner followed by the nodes holding copies of its keys,
+// at most factor nodes in all.
+func (n *Node) replicaSet(owner *api.Node, factor int) []*api.Node {
+	nodes := []*api.Node{owner}
+	next := owner
+	for len(nodes) < factor {
+		var succ *api.Node
+		var err error
+		if bytesEqual(next.Id, n.Id) {
+			n.succMtx.RLock()
+			succ = n.successor
+			n.succMtx.RUnlock()
+		} else if succ, err = n.getSuccessorRPC(next); err != nil {
+			log.Println("error walking replicas: ", next.Addr, err)
+			break
+		}
+		if succ == nil || bytesEqual(succ.Id, owner.Id) {
+			break
+		}
+		nodes = append(nodes, succ)
+		next = succ
+	}
+	return nodes
+}
+
+// GetQuorum reads key from its owner and the replicas of its namespace, and
+// returns the newest value if a majority of them answered. Replicas found
+// with an older copy, or none, are repaired in the background. A key whose
+// newest copy is a tombstone is not found.
+func (n *Node) GetQuorum(ns, key string) ([]byte, error) {
+	kv, err := n.quorumRead(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(kv.Value), nil
+}
+
+// GetQuorumStamped is GetQuorum, also returning the timestamp of the value.
+func (n *Node) GetQuorumStamped(ns, key string) ([]byte, Timestamp, error) {
+	kv, err := n.quorumRead(ns, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return []byte(kv.Value), Timestamp(kv.Version), nil
+}
+
+func (n *Node) quorumRead(ns, key string) (*api.KV, error) {
+	settings, err := n.namespaceSettings(ns)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := n.locate(namespacedKey(ns, key))
+	if err != nil {
+		return nil, err
+	}
+	nodes := n.replicaSet(owner, int(settings.ReplicationFactor))
+	n.metrics.inc("quorum_reads")
+
+	type reply struct {
+		node *api.Node
+		kv   *api.KV
+		err  error
+	}
+	replies := make(chan reply, len(nodes))
+	for _, node := range nodes {
+		go func(node *api.Node) {
+			kv, err := n.replicaGetRPC(node, ns, key)
+			replies <- reply{node: node, kv: kv, err: err}
+		}(node)
+	}
+
+	var newest *api.KV
+	answered := make([]reply, 0, len(nodes))
+	for range nodes {
+		r := <-replies
+		if r.err != nil && !isNotFound(r.err) {
+			log.Println("error reading replica: ", r.node.Addr, r.err)
+			continue
+		}
+		answered = append(answered, r)
+		if r.kv != nil && (newest == nil || r.kv.Version > newest.Version) {
+			newest = r.kv
+		}
+	}
+	if len(answered) < len(nodes)/2+1 {
+		return nil, ERR_QUORUM_NOT_REACHED
+	}
+	if newest == nil {
+		return nil, ERR_KEY_NOT_FOUND
+	}
+	if settings.Siblings && !newest.Deleted {
+		// Copies may hold concurrent writes the newest one lacks.
+		for _, r := range answered {
+			if r.kv == nil || r.kv.Deleted || r.kv == newest {
+				continue
+			}
+			merged, err := n.unionSiblings(newest, r.kv)
+			if err != nil {
+				return nil, err
+			}
+			if merged != nil {
+				newest = merged
+			}
+		}
+	}
+
+	var stale []*api.Node
+	for _, r := range answered {
+		if r.kv == nil || r.kv.Version < newest.Version {
+			stale = append(stale, r.node)
+		}
+	}
+	if len(stale) > 0 {
+		go n.readRepair(stale, newest)
+	}
+	if newest.Deleted {
+		return nil, ERR_KEY_NOT_FOUND
+	}
+	value, err := readValue(settings, newest)
+	if err != nil {
+		return nil, err
+	}
+	return &api.KV{Namespace: ns, Key: key, Value: value, Expires: newest.Expires, Version: newest.Version}, nil
+}
+
+// readRepair writes the newest copy of a key back to replicas that missed
+// it. Replicas that have since seen a newer write keep it.
+func (n *Node) readRepair(stale []*api.Node, newest *api.KV) {
+	req := &api.StoreKeysRequest{Values: []*api.KV{newest}}
+	for _, node := range stale {
+		var err error
+		if bytesEqual(node.Id, n.Id) {
+			_, err = n.XStoreKeys(context.Background(), req)
+		} else {
+			err = n.transport.StoreKeys(node, req)
+		}
+		if err != nil {
+			log.Println("error repairing replica: ", node.Addr, newest.Key, err)
+			n.metrics.inc("read_repair_failures")
+			continue
+		}
+		n.metrics.inc("read_repairs")
+	}
+}
+
+// replicaGetRPC reads a node's own copy of a key, whether or not it owns it.
+func (n *Node) replicaGetRPC(node *api.Node, ns, key string) (*api.KV, error) {
+	req := &api.GetRequest{Namespace: ns, Key: key, Replica: true}
+	var res *api.GetResponse
+	var err error
+	if bytesEqual(node.Id, n.Id) {
+		res, err = n.XGet(context.Background(), req)
+	} else {
+		res, err = n.transport.GetKey(node, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &api.KV{
+		Namespace: ns,
+		Key:       key,
+		Value:     string(res.Value),
+		Expires:   res.Expires,
+		Version:   res.Version,
+		Deleted:   res.Deleted,
+		Type:      res.Type,
+		Flags:     res.Flags,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package boopy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+// waitValue waits until node holds value for key in its own storage.
+func waitValue(t *testing.T, node *Node, ns, key, value string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		node.stMtx.RLock()
+		val, err := node.storage.Get(ns, key)
+		node.stMtx.RUnlock()
+		if err == nil && string(val) == value {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s never held %s = %q", node.Addr, key, value)
+}
+
+func TestNode_GetQuorum(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "rep", ReplicationFactor: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		// damage changes the copies of key after it is written, given the
+		// nodes in replica order.
+		damage func(replicas []*Node, key string)
+	}{
+		{"replicas behind", "apple", func(replicas []*Node, key string) {
+			replicas[1].storage.Set("rep", key, "old", time.Time{}, 1)
+			replicas[2].storage.MDelete("rep", key)
+		}},
+		{"owner behind", "banana", func(replicas []*Node, key string) {
+			replicas[0].storage.Set("rep", key, "old", time.Time{}, 1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := nodes[0].SetIn("rep", tt.key, "new", 0); err != nil {
+				t.Fatal(err)
+			}
+			var replicas []*Node
+			for _, member := range nodes[0].replicaSet(ringOwner(nodes, namespacedKey("rep", tt.key)), 3) {
+				for _, node := range nodes {
+					if bytesEqual(node.Id, member.Id) {
+						replicas = append(replicas, node)
+					}
+				}
+			}
+			if len(replicas) != 3 {
+				t.Fatalf("replicaSet() returned %d nodes, want 3", len(replicas))
+			}
+			for _, node := range replicas {
+				waitValue(t, node, "rep", tt.key, "new")
+				node.stMtx.Lock()
+			}
+			tt.damage(replicas, tt.key)
+			for _, node := range replicas {
+				node.stMtx.Unlock()
+			}
+
+			val, err := nodes[1].GetQuorum("rep", tt.key)
+			if err != nil || string(val) != "new" {
+				t.Fatalf("GetQuorum() = %q, %v, want new", val, err)
+			}
+			for _, node := range replicas {
+				waitValue(t, node, "rep", tt.key, "new")
+			}
+		})
+	}
+
+	// The last repair may still be counting once its write has landed.
+	deadline := time.Now().Add(time.Second)
+	for nodes[1].Metrics()["read_repairs"] < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := nodes[1].Metrics()["read_repairs"]; got != 3 {
+		t.Errorf("read_repairs = %d, want 3", got)
+	}
+	if _, err := nodes[2].GetQuorum("rep", "missing"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("GetQuorum(missing) error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+}
+
+func TestNode_XStoreKeys_keepsNewer(t *testing.T) {
+	nodes := newTestRing(t, 1, nil)
+	node := nodes[0]
+	if err := node.Set("key", "new"); err != nil {
+		t.Fatal(err)
+	}
+	kv, _ := node.storage.Lookup("", "key")
+
+	tests := []struct {
+		version int64
+		want    string
+	}{
+		{kv.Version - 1, "new"},
+		{kv.Version + 1, "newer"},
+	}
+	for _, tt := range tests {
+		pair := &api.KV{Key: "key", Value: "newer", Version: tt.version}
+		if _, err := node.XStoreKeys(context.Background(), &api.StoreKeysRequest{Values: []*api.KV{pair}}); err != nil {
+			t.Fatal(err)
+		}
+		if val, _ := node.storage.Get("", "key"); string(val) != tt.want {
+			t.Errorf("StoreKeys(version %d) left %q, want %q", tt.version, val, tt.want)
+		}
+	}
+}
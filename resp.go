@@ -0,0 +1,504 @@
+package boopy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+)
+
+var ERR_RESP_PROTOCOL = errors.New("invalid RESP request")
+
+const (
+	// maxRESPArgs and maxRESPBulk bound the number of arguments of a RESP
+	// request and the length of each, as Redis does.
+	maxRESPArgs = 1024 * 1024
+	maxRESPBulk = 512 << 20
+	// respCursors is the number of SCAN cursors remembered per server.
+	respCursors = 1024
+)
+
+// respServer answers Redis clients. Keys are those of one namespace.
+//
+// SCAN cursors are ring positions, too wide for the 64 bit cursors Redis
+// clients expect, so they are handed out as numbers standing for the last
+// respCursors positions returned.
+type respServer struct {
+	node *Node
+	ns   string
+
+	mtx     sync.Mutex
+	cursors map[uint64]string
+	order   []uint64
+	next    uint64
+}
+
+// errRESPNotInteger and errRESPOverflow are the errors Redis gives when INCR
+// meets a string that is not an integer or would leave int64.
+var (
+	errRESPNotInteger = errors.New("value is not an integer or out of range")
+	errRESPOverflow   = errors.New("increment or decrement would overflow")
+)
+
+// ServeRESP answers Redis clients connecting to l with the keys of
+// namespace ns, until l is closed. It supports GET, SET with EX or PX, DEL,
+// MGET, MSET, EXISTS, INCR, INCRBY, SCAN with MATCH and COUNT, and PING.
+// INCR creates counters, which merge increments made concurrently, and adds
+// to strings holding an integer, such as those written by SET, with a
+// compare-and-set.
+func (n *Node) ServeRESP(l net.Listener, ns string) error {
+	srv := &respServer{node: n, ns: ns, cursors: make(map[uint64]string)}
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go srv.serve(conn)
+	}
+}
+
+func (s *respServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readRESP(r)
+		if err != nil {
+			if err != io.EOF {
+				writeRESPError(w, err)
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := s.command(w, args)
+		// Pipelined requests are answered together.
+		if r.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// readRESP reads one request, either an array of bulk strings or an
+// inline command.
+func readRESP(r *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count < 0 || count > maxRESPArgs {
+		return nil, ERR_RESP_PROTOCOL
+	}
+	// Lengths come from the client, so nothing is allocated for arguments
+	// before their data arrives.
+	var args []string
+	for i := 0; i < count; i++ {
+		line, err := readRESPLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, ERR_RESP_PROTOCOL
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxRESPBulk {
+			return nil, ERR_RESP_PROTOCOL
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r, int64(size)+2); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		arg := buf.Bytes()
+		if string(arg[size:]) != "\r\n" {
+			return nil, ERR_RESP_PROTOCOL
+		}
+		args = append(args, string(arg[:size]))
+	}
+	return args, nil
+}
+
+func readRESPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			return "", ERR_RESP_PROTOCOL
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeRESPSimple(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "+%s\r\n", s)
+}
+
+func writeRESPError(w *bufio.Writer, err error) {
+	msg := "ERR " + err.Error()
+	if err == ERR_WRONG_TYPE {
+		msg = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	}
+	fmt.Fprintf(w, "-%s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(msg))
+}
+
+func writeRESPInt(w *bufio.Writer, i int64) {
+	fmt.Fprintf(w, ":%d\r\n", i)
+}
+
+// writeRESPBulk writes val, or a null reply if val is nil.
+func writeRESPBulk(w *bufio.Writer, val []byte) {
+	if val == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	fmt.Fprintf(w, "$%d\r\n", len(val))
+	w.Write(val)
+	w.WriteString("\r\n")
+}
+
+func writeRESPArray(w *bufio.Writer, size int) {
+	fmt.Fprintf(w, "*%d\r\n", size)
+}
+
+// respArgs checks the number of arguments of a command, name included.
+func respArgs(w *bufio.Writer, args []string, min int, pairs bool) bool {
+	if len(args) < min || (pairs && len(args)%2 == 0) {
+		writeRESPError(w, fmt.Errorf("wrong number of arguments for '%s' command", strings.ToLower(args[0])))
+		return false
+	}
+	return true
+}
+
+// incrString adds delta to a string key holding an integer. Keys of other
+// types fail with ERR_WRONG_TYPE, as they do for Increment.
+func (s *respServer) incrString(key string, delta int64) (int64, error) {
+	res, err := s.node.lookup(s.ns, key)
+	if err != nil {
+		return 0, err
+	}
+	if res.Type != api.KV_STRING {
+		return 0, ERR_WRONG_TYPE
+	}
+	var result int64
+	err = s.node.updateItem(s.ns, key, func(old *Item) (*Item, error) {
+		current, err := strconv.ParseInt(string(old.Value), 10, 64)
+		if err != nil {
+			return nil, errRESPNotInteger
+		}
+		result = current + delta
+		if (delta > 0 && result < current) || (delta < 0 && result > current) {
+			return nil, errRESPOverflow
+		}
+		value := []byte(strconv.FormatInt(result, 10))
+		return &Item{Value: value, Flags: old.Flags, TTL: remaining(old)}, nil
+	})
+	return result, err
+}
+
+// command runs one request and writes its reply. It returns true once the
+// client asked to close the connection.
+func (s *respServer) command(w *bufio.Writer, args []string) bool {
+	n := s.node
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		if len(args) > 1 {
+			writeRESPBulk(w, []byte(args[1]))
+		} else {
+			writeRESPSimple(w, "PONG")
+		}
+	case "QUIT":
+		writeRESPSimple(w, "OK")
+		return true
+	case "GET":
+		if !respArgs(w, args, 2, false) {
+			break
+		}
+		val, err := n.GetIn(s.ns, args[1])
+		if err != nil && !isNotFound(err) {
+			writeRESPError(w, err)
+			break
+		}
+		if val == nil && err == nil {
+			val = []byte{}
+		}
+		writeRESPBulk(w, val)
+	case "SET":
+		if !respArgs(w, args, 3, false) {
+			break
+		}
+		ttl, err := respTTL(args[3:])
+		if err == nil {
+			err = n.SetIn(s.ns, args[1], args[2], ttl)
+		}
+		if err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		writeRESPSimple(w, "OK")
+	case "DEL", "EXISTS":
+		if !respArgs(w, args, 2, false) {
+			break
+		}
+		found, err := s.exists(args[1:])
+		if err == nil && found > 0 && strings.ToUpper(args[0]) == "DEL" {
+			err = batchErr(n.MultiDelete(s.ns, args[1:]))
+		}
+		if err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		writeRESPInt(w, found)
+	case "MGET":
+		if !respArgs(w, args, 2, false) {
+			break
+		}
+		results := n.MultiGet(s.ns, args[1:])
+		for _, result := range results {
+			if result.Err != nil && !isNotFound(result.Err) {
+				writeRESPError(w, result.Err)
+				return false
+			}
+		}
+		writeRESPArray(w, len(results))
+		for _, result := range results {
+			val := result.Value
+			if val == nil && result.Err == nil {
+				val = []byte{}
+			}
+			writeRESPBulk(w, val)
+		}
+	case "MSET":
+		if !respArgs(w, args, 3, true) {
+			break
+		}
+		pairs := make([]*api.KV, 0, len(args)/2)
+		for i := 1; i < len(args); i += 2 {
+			pairs = append(pairs, &api.KV{Key: args[i], Value: args[i+1]})
+		}
+		if err := batchErr(n.MultiSet(s.ns, pairs, 0)); err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		writeRESPSimple(w, "OK")
+	case "INCR", "INCRBY":
+		delta := int64(1)
+		if strings.ToUpper(args[0]) == "INCRBY" {
+			if !respArgs(w, args, 3, false) {
+				break
+			}
+			var err error
+			if delta, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+				writeRESPError(w, errRESPNotInteger)
+				break
+			}
+		} else if !respArgs(w, args, 2, false) {
+			break
+		}
+		counter, err := n.IncrementIn(s.ns, args[1], delta, 0)
+		if err == ERR_WRONG_TYPE {
+			counter, err = s.incrString(args[1], delta)
+		}
+		if err != nil {
+			writeRESPError(w, err)
+			break
+		}
+		writeRESPInt(w, counter)
+	case "SCAN":
+		if !respArgs(w, args, 2, false) {
+			break
+		}
+		s.scan(w, args[1:])
+	default:
+		writeRESPError(w, fmt.Errorf("unknown command '%s'", args[0]))
+	}
+	return false
+}
+
+// respTTL reads the options of SET.
+func respTTL(opts []string) (time.Duration, error) {
+	if len(opts) == 0 {
+		return 0, nil
+	}
+	if len(opts) != 2 {
+		return 0, errors.New("syntax error")
+	}
+	amount, err := strconv.ParseInt(opts[1], 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, errors.New("invalid expire time in 'set' command")
+	}
+	switch strings.ToUpper(opts[0]) {
+	case "EX":
+		return time.Duration(amount) * time.Second, nil
+	case "PX":
+		return time.Duration(amount) * time.Millisecond, nil
+	}
+	return 0, errors.New("syntax error")
+}
+
+// exists counts the keys that are set.
+func (s *respServer) exists(keys []string) (int64, error) {
+	var found int64
+	for _, result := range s.node.MultiGet(s.ns, keys) {
+		switch {
+		case result.Err == nil, result.Err == ERR_SIBLINGS:
+			found++
+		case !isNotFound(result.Err):
+			return 0, result.Err
+		}
+	}
+	return found, nil
+}
+
+// batchErr returns the first error of a batch.
+func batchErr(results []BatchResult) error {
+	for _, result := range results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// scan answers SCAN cursor [MATCH pattern] [COUNT count].
+func (s *respServer) scan(w *bufio.Writer, args []string) {
+	opts := ScanOptions{Namespace: s.ns, Limit: 10}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err == nil && id != 0 {
+		var ok bool
+		if opts.Cursor, ok = s.cursor(id); !ok {
+			err = ERR_INVALID_CURSOR
+		}
+	}
+	pattern := ""
+	for i := 1; err == nil && i < len(args); i += 2 {
+		if i+1 == len(args) {
+			err = errors.New("syntax error")
+			break
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+			opts.Prefix = globPrefix(pattern)
+		case "COUNT":
+			if opts.Limit, err = strconv.Atoi(args[i+1]); err == nil && opts.Limit <= 0 {
+				err = errors.New("syntax error")
+			}
+		default:
+			err = errors.New("syntax error")
+		}
+	}
+	if err != nil {
+		writeRESPError(w, err)
+		return
+	}
+
+	keys, cursor, err := s.node.Scan(opts)
+	if err != nil {
+		writeRESPError(w, err)
+		return
+	}
+	matched := make([]string, 0, len(keys))
+	for _, kv := range keys {
+		if pattern == "" || globMatch(pattern, kv.Key) {
+			matched = append(matched, kv.Key)
+		}
+	}
+	writeRESPArray(w, 2)
+	writeRESPBulk(w, []byte(strconv.FormatUint(s.remember(cursor), 10)))
+	writeRESPArray(w, len(matched))
+	for _, key := range matched {
+		writeRESPBulk(w, []byte(key))
+	}
+}
+
+// remember returns the number handed out for a scan cursor, 0 for the end
+// of a scan.
+func (s *respServer) remember(cursor string) uint64 {
+	if cursor == "" {
+		return 0
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.next++
+	s.cursors[s.next] = cursor
+	s.order = append(s.order, s.next)
+	if len(s.order) > respCursors {
+		delete(s.cursors, s.order[0])
+		s.order = s.order[1:]
+	}
+	return s.next
+}
+
+func (s *respServer) cursor(id uint64) (string, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	cursor, ok := s.cursors[id]
+	return cursor, ok
+}
+
+// globPrefix returns the literal start of a Redis glob pattern.
+func globPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// globMatch matches s against a Redis glob pattern: * matches any run of
+// characters, ? any one, and \ escapes the next character. Character
+// classes are not supported and match literally. On a mismatch only the
+// last * is retried, one character further on, so a pattern from a client
+// takes at most its length times the length of s steps.
+func globMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, next := -1, 0 // pattern after the last *, and where s resumes
+	for i < len(s) {
+		if p < len(pattern) {
+			switch c := pattern[p]; c {
+			case '*':
+				star, next = p+1, i
+				p++
+				continue
+			case '?':
+				p, i = p+1, i+1
+				continue
+			default:
+				width := 1
+				if c == '\\' && p+1 < len(pattern) {
+					c, width = pattern[p+1], 2
+				}
+				if s[i] == c {
+					p, i = p+width, i+1
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		next++
+		p, i = star, next
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
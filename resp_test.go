@@ -0,0 +1,253 @@
+package boopy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// respError is an error reply, compared by its text.
+type respError string
+
+// respClient speaks raw RESP to a server.
+type respClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRESP(t *testing.T, node *Node) *respClient {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go node.ServeRESP(l, "")
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		l.Close()
+	})
+	return &respClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *respClient) do(args ...string) interface{} {
+	c.t.Helper()
+	req := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		req += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, req); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.reply()
+}
+
+func (c *respClient) reply() interface{} {
+	c.t.Helper()
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	line = line[:len(line)-2]
+	switch line[0] {
+	case '+':
+		return line[1:]
+	case '-':
+		return respError(line[1:])
+	case ':':
+		i, _ := strconv.ParseInt(line[1:], 10, 64)
+		return i
+	case '$':
+		size, _ := strconv.Atoi(line[1:])
+		if size < 0 {
+			return nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			c.t.Fatal(err)
+		}
+		return string(buf[:size])
+	case '*':
+		size, _ := strconv.Atoi(line[1:])
+		items := make([]interface{}, size)
+		for i := range items {
+			items[i] = c.reply()
+		}
+		return items
+	}
+	c.t.Fatalf("unexpected reply %q", line)
+	return nil
+}
+
+func TestNode_ServeRESP(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	client := dialRESP(t, nodes[0])
+	if _, err := nodes[1].AddIn("", "tags", 0, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want interface{}
+	}{
+		{[]string{"PING"}, "PONG"},
+		{[]string{"SET", "a", "1"}, "OK"},
+		{[]string{"get", "a"}, "1"},
+		{[]string{"GET", "missing"}, nil},
+		{[]string{"SET", "b", "2", "EX", "100"}, "OK"},
+		{[]string{"SET", "b", "2", "EX", "0"}, respError("ERR invalid expire time in 'set' command")},
+		{[]string{"SET", "b", "2", "KEEPTTL"}, respError("ERR syntax error")},
+		{[]string{"MSET", "x", "1", "y", ""}, "OK"},
+		{[]string{"MSET", "x"}, respError("ERR wrong number of arguments for 'mset' command")},
+		{[]string{"MGET", "a", "missing", "y"}, []interface{}{"1", nil, ""}},
+		{[]string{"EXISTS", "a", "missing", "x"}, int64(2)},
+		{[]string{"DEL", "a", "missing"}, int64(1)},
+		{[]string{"GET", "a"}, nil},
+		{[]string{"INCR", "hits"}, int64(1)},
+		{[]string{"INCRBY", "hits", "-5"}, int64(-4)},
+		{[]string{"GET", "hits"}, "-4"},
+		{[]string{"SET", "n", "10"}, "OK"},
+		{[]string{"INCR", "n"}, int64(11)},
+		{[]string{"INCRBY", "n", "-20"}, int64(-9)},
+		{[]string{"GET", "n"}, "-9"},
+		{[]string{"SET", "big", "9223372036854775807"}, "OK"},
+		{[]string{"INCR", "big"}, respError("ERR increment or decrement would overflow")},
+		{[]string{"INCR", "x"}, int64(2)},
+		{[]string{"INCR", "y"}, respError("ERR value is not an integer or out of range")},
+		{[]string{"INCR", "tags"}, respError("WRONGTYPE Operation against a key holding the wrong kind of value")},
+		{[]string{"FLUSHALL"}, respError("ERR unknown command 'FLUSHALL'")},
+	}
+	for _, tt := range tests {
+		if got := client.do(tt.args...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v = %#v, want %#v", tt.args, got, tt.want)
+		}
+	}
+
+	if got := client.do("SET", "short", "v", "PX", "50"); got != "OK" {
+		t.Fatalf("SET PX = %#v", got)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := client.do("GET", "short"); got != nil {
+		t.Errorf("GET of an expired key = %#v, want nil", got)
+	}
+
+	// Inline commands, as typed into telnet.
+	io.WriteString(client.conn, "PING hello\r\n")
+	if got := client.reply(); got != "hello" {
+		t.Errorf("inline PING = %#v, want hello", got)
+	}
+}
+
+func TestNode_ServeRESPMalformed(t *testing.T) {
+	nodes := newTestRing(t, 1, nil)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go nodes[0].ServeRESP(l, "")
+
+	// Lengths out of range are refused before anything is allocated.
+	headers := []string{
+		"*9223372036854775807\r\n",
+		"*-2\r\n",
+		"*1\r\n$9223372036854775807\r\n",
+		"*1\r\n$3\r\nabcd\r\n",
+	}
+	for _, header := range headers {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &respClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+		io.WriteString(conn, header)
+		if got := client.reply(); got != respError("ERR "+ERR_RESP_PROTOCOL.Error()) {
+			t.Errorf("%q = %#v, want a protocol error", header, got)
+		}
+		conn.Close()
+	}
+
+	// The server is still up.
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := &respClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	if got := client.do("PING"); got != "PONG" {
+		t.Errorf("PING after malformed requests = %#v", got)
+	}
+}
+
+func TestNode_ServeRESPScan(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	client := dialRESP(t, nodes[1])
+
+	args := []string{"MSET", "other", "x"}
+	for i := 0; i < 25; i++ {
+		args = append(args, fmt.Sprintf("user:%d", i), "x")
+	}
+	if got := client.do(args...); got != "OK" {
+		t.Fatalf("MSET = %#v", got)
+	}
+
+	seen := make(map[string]bool)
+	cursor := "0"
+	for page := 0; ; page++ {
+		if page > 20 {
+			t.Fatal("SCAN did not finish")
+		}
+		reply, ok := client.do("SCAN", cursor, "MATCH", "user:*", "COUNT", "7").([]interface{})
+		if !ok || len(reply) != 2 {
+			t.Fatalf("SCAN = %#v", reply)
+		}
+		for _, key := range reply[1].([]interface{}) {
+			seen[key.(string)] = true
+		}
+		if cursor = reply[0].(string); cursor == "0" {
+			break
+		}
+	}
+	if len(seen) != 25 || seen["other"] {
+		t.Errorf("SCAN found %d keys, want the 25 user keys: %v", len(seen), seen)
+	}
+	if got := client.do("SCAN", "12345"); got != respError("ERR "+ERR_INVALID_CURSOR.Error()) {
+		t.Errorf("SCAN of an unknown cursor = %#v", got)
+	}
+}
+
+func Test_globMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"user:*", "user:1", true},
+		{"user:*", "users", false},
+		{"*:1?", "user:12", true},
+		{"*:1?", "user:1", false},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+		{"*", "", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyyd", false},
+		{`\\`, `\`, true},
+		// Backtracking every * would take exponential time here.
+		{strings.Repeat("*a", 20) + "*b", strings.Repeat("a", 1000), false},
+		{strings.Repeat("*a", 20) + "*b", strings.Repeat("a", 1000) + "b", true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
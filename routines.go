@@ -48,3 +48,60 @@ func (node *Node) checkPredecessorRoutine(val int) {
 	}
 
 }
+
+// Expire keys routine
+func (node *Node) expireRoutine(val int) {
+	ticker := time.NewTicker(time.Duration(val) * time.Millisecond)
+	for {
+		select {
+		case <-ticker.C:
+			node.expireKeys()
+			node.purgeTombstones()
+		case <-node.shutdownCh:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// Replay hinted writes routine
+func (node *Node) hintRoutine(val int) {
+	ticker := time.NewTicker(time.Duration(val) * time.Millisecond)
+	for {
+		select {
+		case <-ticker.C:
+			node.replayHints()
+		case <-node.shutdownCh:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// Anti-entropy routine
+func (node *Node) antiEntropyRoutine(val int) {
+	ticker := time.NewTicker(time.Duration(val) * time.Millisecond)
+	for {
+		select {
+		case <-ticker.C:
+			node.antiEntropy()
+		case <-node.shutdownCh:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// Settle timed out transactions routine
+func (node *Node) txnRoutine(val int) {
+	ticker := time.NewTicker(time.Duration(val) * time.Millisecond)
+	for {
+		select {
+		case <-ticker.C:
+			node.settleExpired()
+		case <-node.shutdownCh:
+			ticker.Stop()
+			return
+		}
+	}
+}
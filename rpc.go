@@ -1,7 +1,7 @@
 package boopy
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/jseam2/boopy/api"
 	"golang.org/x/net/context"
@@ -18,12 +18,28 @@ func (n *Node) getSuccessorRPC(node *api.Node) (*api.Node, error) {
 
 // setSuccessorRPC sets the successor of a given node.
 func (n *Node) setSuccessorRPC(node *api.Node, succ *api.Node) error {
-	return n.transport.SetSuccessor(node, succ)
+	return n.transport.SetSuccessor(node, &api.NeighbourRequest{Node: succ, Caller: n.Node})
 }
 
 // findSuccessorRPC finds the successor node of a given ID in the entire ring.
 func (n *Node) findSuccessorRPC(node *api.Node, id []byte) (*api.Node, error) {
-	return n.transport.FindSuccessor(node, id)
+	res, err := n.transport.FindSuccessor(node, &api.ID{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return res.Node, nil
+}
+
+// closestPrecedingRPC asks a node for its closest finger preceding id and its
+// successor. Asking ourselves skips the network.
+func (n *Node) closestPrecedingRPC(
+	node *api.Node, id []byte, exclude [][]byte,
+) (*api.ClosestPrecedingResponse, error) {
+	req := &api.ClosestPrecedingRequest{Id: id, Exclude: exclude}
+	if bytesEqual(node.Id, n.Id) {
+		return n.ClosestPrecedingNode(context.Background(), req)
+	}
+	return n.transport.ClosestPrecedingNode(node, req)
 }
 
 // getSuccessorRPC the successor ID of a remote node.
@@ -33,7 +49,7 @@ func (n *Node) getPredecessorRPC(node *api.Node) (*api.Node, error) {
 
 // setPredecessorRPC sets the predecessor of a given node.
 func (n *Node) setPredecessorRPC(node *api.Node, pred *api.Node) error {
-	return n.transport.SetPredecessor(node, pred)
+	return n.transport.SetPredecessor(node, &api.NeighbourRequest{Node: pred, Caller: n.Node})
 }
 
 // notify notifies a remote node that pred is its predecessor.
@@ -41,14 +57,14 @@ func (n *Node) notify(node, pred *api.Node) error {
 	return n.transport.Notify(node, pred)
 }
 
-func (n *Node) getKeyRPC(node *api.Node, key string) (*api.GetResponse, error) {
-	return n.transport.GetKey(node, key)
+func (n *Node) getKeyRPC(node *api.Node, ns, key string) (*api.GetResponse, error) {
+	return n.transport.GetKey(node, &api.GetRequest{Namespace: ns, Key: key})
 }
-func (n *Node) setKeyRPC(node *api.Node, key, value string) error {
-	return n.transport.SetKey(node, key, value)
+func (n *Node) setKeyRPC(node *api.Node, ns, key, value string, ctx []byte, ttl time.Duration) error {
+	return n.transport.SetKey(node, &api.SetRequest{Namespace: ns, Key: key, Value: value, Context: ctx, Ttl: int64(ttl)})
 }
-func (n *Node) deleteKeyRPC(node *api.Node, key string) error {
-	return n.transport.DeleteKey(node, key)
+func (n *Node) deleteKeyRPC(node *api.Node, ns, key string) error {
+	return n.transport.DeleteKey(node, &api.DeleteRequest{Namespace: ns, Key: key})
 }
 
 func (n *Node) requestKeysRPC(
@@ -58,9 +74,17 @@ func (n *Node) requestKeysRPC(
 }
 
 func (n *Node) deleteKeysRPC(
-	node *api.Node, keys []string,
+	node *api.Node, ns string, keys []string,
 ) error {
-	return n.transport.DeleteKeys(node, keys)
+	return n.transport.DeleteKeys(node, ns, keys)
+}
+
+func (n *Node) storeKeysRPC(node *api.Node, keys []*api.KV) error {
+	return n.transport.StoreKeys(node, &api.StoreKeysRequest{Values: keys})
+}
+
+func (n *Node) handOverKeysRPC(node *api.Node, keys []*api.KV) error {
+	return n.transport.StoreKeys(node, &api.StoreKeysRequest{Values: keys, Transfer: true})
 }
 
 ////////////////////////////////////////////////////////////////
@@ -78,17 +102,30 @@ func (n *Node) GetSuccessor(ctx context.Context, r *api.ER) (*api.Node, error) {
 	return succ, nil
 }
 
-func (n *Node) SetSuccessor(ctx context.Context, succ *api.Node) (*api.ER, error) {
+func (n *Node) SetSuccessor(ctx context.Context, req *api.NeighbourRequest) (*api.ER, error) {
+	n.succMtx.RLock()
+	curr := n.successor
+	n.succMtx.RUnlock()
+
+	if err := n.verifyLeave(ctx, req, curr, probeSuccessor); err != nil {
+		n.metrics.inc("rejected_ring_updates")
+		return emptyRequest, err
+	}
+
 	n.succMtx.Lock()
-	n.successor = succ
+	n.successor = req.Node
 	n.succMtx.Unlock()
+	n.neighboursChanged()
 	return emptyRequest, nil
 }
 
-func (n *Node) FindSuccessor(ctx context.Context, id *api.ID) (*api.Node, error) {
-	succ, err := n.findSuccessor(id.Id)
+func (n *Node) FindSuccessor(ctx context.Context, id *api.ID) (*api.FindSuccessorResponse, error) {
+	succ, hops, err := n.findSuccessorPath(id)
 	// If there's an error
 	if err != nil {
+		if lookupErr, ok := err.(*LookupError); ok {
+			return nil, lookupErr.status()
+		}
 		return nil, err
 	}
 
@@ -97,10 +134,23 @@ func (n *Node) FindSuccessor(ctx context.Context, id *api.ID) (*api.Node, error)
 		return nil, ERR_NO_SUCCESSOR
 	}
 
-	return succ, nil
+	return &api.FindSuccessorResponse{Node: succ, Hops: hops}, nil
 
 }
 
+func (n *Node) ClosestPrecedingNode(ctx context.Context, req *api.ClosestPrecedingRequest) (*api.ClosestPrecedingResponse, error) {
+	n.succMtx.RLock()
+	succ := n.successor
+	n.succMtx.RUnlock()
+
+	pred, finger := n.closestPrecedingNode(req.Id, req.Exclude...)
+	return &api.ClosestPrecedingResponse{
+		Node:      pred,
+		Successor: succ,
+		Finger:    int32(finger),
+	}, nil
+}
+
 func (n *Node) CheckPredecessor(ctx context.Context, id *api.ID) (*api.ER, error) {
 	return emptyRequest, nil
 }
@@ -115,59 +165,253 @@ func (n *Node) GetPredecessor(ctx context.Context, r *api.ER) (*api.Node, error)
 	return pred, nil
 }
 
-func (n *Node) SetPredecessor(ctx context.Context, pred *api.Node) (*api.ER, error) {
+func (n *Node) SetPredecessor(ctx context.Context, req *api.NeighbourRequest) (*api.ER, error) {
+	n.predMtx.RLock()
+	curr := n.predecessor
+	n.predMtx.RUnlock()
+
+	if err := n.verifyLeave(ctx, req, curr, probePredecessor); err != nil {
+		n.metrics.inc("rejected_ring_updates")
+		return emptyRequest, err
+	}
+
 	n.predMtx.Lock()
-	n.predecessor = pred
+	n.predecessor = req.Node
 	n.predMtx.Unlock()
+	n.neighboursChanged()
 	return emptyRequest, nil
 }
 
+func (n *Node) Probe(ctx context.Context, r *api.ER) (*api.ProbeResponse, error) {
+	n.predMtx.RLock()
+	pred := n.predecessor
+	n.predMtx.RUnlock()
+	n.succMtx.RLock()
+	succ := n.successor
+	n.succMtx.RUnlock()
+
+	return &api.ProbeResponse{
+		Node:        n.Node,
+		Predecessor: pred,
+		Successor:   succ,
+		Leaving:     n.isLeaving(),
+	}, nil
+}
+
 func (n *Node) Notify(ctx context.Context, node *api.Node) (*api.ER, error) {
+	if err := n.verifyPeer(ctx, node); err != nil {
+		return emptyRequest, err
+	}
+
+	// Only probe nodes that would actually become our predecessor.
+	n.predMtx.RLock()
+	pred := n.predecessor
+	n.predMtx.RUnlock()
+	if pred != nil && !between(node.Id, pred.Id, n.Id) {
+		return emptyRequest, nil
+	}
+	if err := n.verifyNotify(node); err != nil {
+		n.metrics.inc("rejected_ring_updates")
+		return emptyRequest, err
+	}
+
 	n.predMtx.Lock()
-	defer n.predMtx.Unlock()
 	var prevPredNode *api.Node
 
-	pred := n.predecessor
+	pred = n.predecessor
 	if pred == nil || between(node.Id, pred.Id, n.Id) {
 		if n.predecessor != nil {
 			prevPredNode = n.predecessor
 		}
 		n.predecessor = node
+		n.neighboursChanged()
+	}
+	n.predMtx.Unlock()
 
-		if prevPredNode != nil {
-			if between(n.predecessor.Id, prevPredNode.Id, n.Id) {
-				n.transferKeys(prevPredNode, n.predecessor)
-			}
-		}
-
+	// Hand over outside the lock, looking up namespace settings may route
+	// requests back to us.
+	if prevPredNode != nil && between(node.Id, prevPredNode.Id, n.Id) {
+		n.transferKeys(prevPredNode, node)
+		n.rangeMoved(node)
 	}
 
 	return emptyRequest, nil
 }
 
+// misrouted checks whether key belongs to this node. If not, it returns the
+// owner the request should be forwarded to, or a redirect error once hops
+// has used up the forwarding budget.
+func (n *Node) misrouted(key string, hops uint32) (*api.Node, error) {
+	id, err := n.hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if n.owns(id) {
+		return nil, nil
+	}
+
+	owner, err := n.findSuccessor(id)
+	if err != nil {
+		return nil, err
+	}
+	// Our lookup still points at us, nobody better is known.
+	if owner == nil || bytesEqual(owner.Id, n.Id) {
+		return nil, nil
+	}
+	if int(hops) >= n.cnf.MaxForwardHops {
+		return nil, redirectError(owner)
+	}
+	return owner, nil
+}
+
 func (n *Node) XGet(ctx context.Context, req *api.GetRequest) (*api.GetResponse, error) {
+	var owner *api.Node
+	var err error
+	if !req.Replica {
+		owner, err = n.misrouted(namespacedKey(req.Namespace, req.Key), req.Hops)
+	}
+	if err != nil {
+		return emptyGetResponse, err
+	}
+	if owner != nil {
+		return n.transport.GetKey(owner, &api.GetRequest{
+			Namespace: req.Namespace, Key: req.Key, Hops: req.Hops + 1,
+		})
+	}
+
 	n.stMtx.RLock()
-	defer n.stMtx.RUnlock()
-	val, err := n.storage.Get(req.Key)
+	kv, err := n.storage.Lookup(req.Namespace, req.Key)
+	n.stMtx.RUnlock()
 	if err != nil {
 		return emptyGetResponse, err
 	}
-	return &api.GetResponse{Value: val}, nil
+	if kv.Deleted {
+		if !req.Replica {
+			return emptyGetResponse, ERR_KEY_NOT_FOUND
+		}
+		return &api.GetResponse{Version: kv.Version, Deleted: true}, nil
+	}
+	res := &api.GetResponse{Value: []byte(kv.Value), Version: kv.Version, Expires: kv.Expires, Type: kv.Type, Flags: kv.Flags}
+	if req.Replica {
+		return res, nil // copies are compared as stored
+	}
+	if kv.Type != api.KV_STRING {
+		value, err := renderValue(kv)
+		res.Value = []byte(value)
+		return res, err
+	}
+	return n.withSiblings(req.Namespace, res)
 }
 
 func (n *Node) XSet(ctx context.Context, req *api.SetRequest) (*api.SetResponse, error) {
+	owner, err := n.misrouted(namespacedKey(req.Namespace, req.Key), req.Hops)
+	if err != nil {
+		return emptySetResponse, err
+	}
+	if owner != nil {
+		forward := *req
+		forward.Hops++
+		return emptySetResponse, n.transport.SetKey(owner, &forward)
+	}
+
+	settings, err := n.namespaceSettings(req.Namespace)
+	if err != nil {
+		return emptySetResponse, err
+	}
+	expires := expiry(settings, req.Ttl)
+	kv := &api.KV{Namespace: req.Namespace, Key: req.Key, Value: req.Value, Flags: req.Flags}
+	if !expires.IsZero() {
+		kv.Expires = expires.UnixNano()
+	}
+	if req.Conditional && settings.Siblings {
+		return emptySetResponse, ERR_CONDITIONAL_SIBLINGS
+	}
+
 	n.stMtx.Lock()
-	defer n.stMtx.Unlock()
-	fmt.Println("setting key on ", n.Node.Addr, req.Key, req.Value)
-	err := n.storage.Set(req.Key, req.Value)
-	return emptySetResponse, err
+	if err := n.txns.check(req.Namespace, req.Key); err != nil {
+		n.stMtx.Unlock()
+		return emptySetResponse, err
+	}
+	if req.Conditional {
+		var current int64
+		if old, err := n.storage.Lookup(req.Namespace, req.Key); err == nil && !old.Deleted {
+			current = old.Version
+		}
+		if current != req.Expected {
+			n.stMtx.Unlock()
+			return emptySetResponse, ERR_VERSION_MISMATCH
+		}
+	}
+	// Siblings keep late writes beside newer ones instead of dropping them.
+	if old, err := n.storage.Lookup(req.Namespace, req.Key); req.Version != 0 && !settings.Siblings && err == nil && old.Version >= req.Version {
+		n.stMtx.Unlock()
+		return emptySetResponse, nil // a newer write has already landed
+	}
+	value := req.Value
+	if settings.Siblings {
+		value, err = n.siblingWrite(req.Namespace, req.Key, req.Value, req.Context)
+	}
+	if err == nil {
+		err = n.checkQuota(settings, req.Key, value)
+	}
+	if err == nil {
+		kv.Version = req.Version
+		if kv.Version == 0 || settings.Siblings {
+			kv.Version = n.nextVersion(req.Namespace, req.Key)
+		}
+		err = n.storage.Put(&api.KV{
+			Namespace: req.Namespace, Key: req.Key, Value: value, Expires: kv.Expires, Version: kv.Version, Flags: kv.Flags,
+		})
+	}
+	if err == nil {
+		// Record and publish under the lock so readers see writes in order.
+		n.record(api.Change_SET, kv)
+		n.publishSet(req.Namespace, req.Key, req.Value)
+	}
+	n.stMtx.Unlock()
+	if err != nil {
+		return emptySetResponse, err
+	}
+
+	kv.Value = value // replicas get every sibling
+	n.replicate(settings, kv)
+	return emptySetResponse, nil
 }
 
 func (n *Node) XDelete(ctx context.Context, req *api.DeleteRequest) (*api.DeleteResponse, error) {
+	owner, err := n.misrouted(namespacedKey(req.Namespace, req.Key), req.Hops)
+	if err != nil {
+		return emptyDeleteResponse, err
+	}
+	if owner != nil {
+		err = n.transport.DeleteKey(owner, &api.DeleteRequest{
+			Namespace: req.Namespace, Key: req.Key, Hops: req.Hops + 1,
+		})
+		return emptyDeleteResponse, err
+	}
+
+	settings, err := n.namespaceSettings(req.Namespace)
+	if err != nil {
+		return emptyDeleteResponse, err
+	}
+
 	n.stMtx.Lock()
-	defer n.stMtx.Unlock()
-	err := n.storage.Delete(req.Key)
-	return emptyDeleteResponse, err
+	kv := &api.KV{Namespace: req.Namespace, Key: req.Key, Deleted: true}
+	kv.Version = n.nextVersion(req.Namespace, req.Key)
+	err = n.txns.check(req.Namespace, req.Key)
+	if err == nil {
+		err = n.storage.Delete(req.Namespace, req.Key, kv.Version)
+	}
+	if err == nil {
+		n.record(api.Change_DELETE, kv)
+		n.publishDelete(req.Namespace, req.Key)
+	}
+	n.stMtx.Unlock()
+	if err != nil {
+		return emptyDeleteResponse, err
+	}
+	n.replicate(settings, kv)
+	return emptyDeleteResponse, nil
 }
 
 func (n *Node) XRequestKeys(ctx context.Context, req *api.RequestKeysRequest) (*api.RequestKeysResponse, error) {
@@ -183,6 +427,86 @@ func (n *Node) XRequestKeys(ctx context.Context, req *api.RequestKeysRequest) (*
 func (n *Node) XMultiDelete(ctx context.Context, req *api.MultiDeleteRequest) (*api.DeleteResponse, error) {
 	n.stMtx.Lock()
 	defer n.stMtx.Unlock()
-	err := n.storage.MDelete(req.Keys...)
+	err := n.storage.MDelete(req.Namespace, req.Keys...)
+	if err == nil {
+		n.recordKeys(api.Change_DELETE, req.Namespace, req.Keys...)
+	}
 	return emptyDeleteResponse, err
 }
+
+// XStoreKeys stores copies of keys sent by other nodes: replicas, repairs
+// and ranges handed over to us. Copies that would take a namespace over its
+// quota are skipped, failing the call with ERR_QUOTA_EXCEEDED once the rest
+// are stored; handovers are not checked, as their keys have nowhere else to
+// go.
+func (n *Node) XStoreKeys(ctx context.Context, req *api.StoreKeysRequest) (*api.ER, error) {
+	settings, err := n.settingsOf(req.Values)
+	if err != nil {
+		return emptyRequest, err
+	}
+	// quota reports whether storing kv keeps its namespace within limits.
+	var quotaErr error
+	quota := func(kv *api.KV) bool {
+		ns := settings[kv.Namespace]
+		if req.Transfer || ns == nil {
+			return true
+		}
+		if err := n.checkQuota(ns, kv.Key, kv.Value); err != nil {
+			quotaErr = err
+			return false
+		}
+		return true
+	}
+	n.stMtx.Lock()
+	defer n.stMtx.Unlock()
+	typ := api.Change_SET
+	if req.Transfer {
+		typ = api.Change_TRANSFER_IN
+	}
+	for _, kv := range req.Values {
+		old, err := n.storage.Lookup(kv.Namespace, kv.Key)
+		if err == nil && !old.Deleted && !kv.Deleted && old.Type == kv.Type && kv.Type != api.KV_STRING {
+			merged, err := n.mergeStored(old, kv)
+			if err != nil {
+				return emptyRequest, err
+			}
+			if merged != nil {
+				n.record(typ, merged)
+			}
+			continue
+		}
+		if ns := settings[kv.Namespace]; err == nil && !old.Deleted && !kv.Deleted && ns != nil && ns.Siblings {
+			merged, err := n.unionSiblings(old, kv)
+			if err != nil {
+				return emptyRequest, err
+			}
+			if merged != nil && quota(merged) {
+				if err := n.storage.Put(merged); err != nil {
+					return emptyRequest, err
+				}
+				n.record(typ, merged)
+			}
+			continue
+		}
+		if err == nil && old.Version > kv.Version {
+			continue // we already hold a newer write
+		}
+		if kv.Deleted {
+			if err := n.storage.Delete(kv.Namespace, kv.Key, kv.Version); err != nil {
+				return emptyRequest, err
+			}
+			if !req.Transfer {
+				n.record(api.Change_DELETE, kv)
+			}
+			continue
+		}
+		if !quota(kv) {
+			continue
+		}
+		if err := n.storage.Put(kv); err != nil {
+			return emptyRequest, err
+		}
+		n.record(typ, kv)
+	}
+	return emptyRequest, quotaErr
+}
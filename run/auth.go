@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Roles a token can hold. Admin implies write, write implies read.
+const (
+	RoleRead  = "read"
+	RoleWrite = "write"
+	RoleAdmin = "admin"
+)
+
+var roleRank = map[string]int{RoleRead: 1, RoleWrite: 2, RoleAdmin: 3}
+
+// Token describes a bearer token accepted by the REST server
+type Token struct {
+	Token    string   `json:"token"`
+	Roles    []string `json:"roles"`
+	Prefixes []string `json:"prefixes"` // keys the token may touch, empty allows every key
+}
+
+// AuthConfig is the format of the file passed with -auth-file
+type AuthConfig struct {
+	Tokens []Token `json:"tokens"`
+}
+
+// has checks if the token holds role, directly or through a higher role
+func (t *Token) has(role string) bool {
+	for _, r := range t.Roles {
+		if roleRank[r] >= roleRank[role] {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsKey checks the key against the token's prefix ACL
+func (t *Token) allowsKey(key string) bool {
+	if len(t.Prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range t.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// auth checks REST requests against the configured tokens. A nil *auth lets
+// every request through, which is the behaviour without -auth-file.
+type auth struct {
+	tokens  []Token
+	origins []string
+}
+
+func loadAuth(path string) ([]Token, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cnf AuthConfig
+	if err := json.Unmarshal(data, &cnf); err != nil {
+		return nil, err
+	}
+	return cnf.Tokens, nil
+}
+
+type tokenKey struct{}
+
+// lookup finds the token presented in the Authorization header
+func (a *auth) lookup(r *http.Request) *Token {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil
+	}
+	presented := []byte(strings.TrimPrefix(header, "Bearer "))
+	for i := range a.tokens {
+		if subtle.ConstantTimeCompare(presented, []byte(a.tokens[i].Token)) == 1 {
+			return &a.tokens[i]
+		}
+	}
+	return nil
+}
+
+// cors sets the CORS headers, echoing the origin only if it is allowed
+func (a *auth) cors(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	for _, allowed := range a.origins {
+		if allowed == "*" || allowed == origin {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if allowed != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+			break
+		}
+	}
+	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+}
+
+// require wraps a handler so it only runs for tokens holding role.
+// Preflight requests are answered without authentication.
+func (a *auth) require(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.cors(w, r)
+		if r.Method == http.MethodOptions {
+			return
+		}
+		if a.tokens == nil {
+			next(w, r)
+			return
+		}
+
+		token := a.lookup(r)
+		if token == nil {
+			deny(w, http.StatusUnauthorized, "missing or unknown bearer token")
+			return
+		}
+		if !token.has(role) {
+			deny(w, http.StatusForbidden, "token lacks the "+role+" role")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	}
+}
+
+// allowKey checks the key against the caller's prefix ACL, writing a 403
+// and returning false if it is not allowed.
+func allowKey(w http.ResponseWriter, r *http.Request, key string) bool {
+	token, ok := r.Context().Value(tokenKey{}).(*Token)
+	if !ok || token.allowsKey(key) {
+		return true
+	}
+	deny(w, http.StatusForbidden, "token may not access key "+key)
+	return false
+}
+
+func deny(w http.ResponseWriter, code int, reason string) {
+	w.WriteHeader(code)
+	res := Response{
+		Message: http.StatusText(code),
+		Error:   reason,
+	}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		panic(err)
+	}
+}
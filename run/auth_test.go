@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newAuthServer serves stand-ins for a read, a write and an admin endpoint
+// behind authz, each answering 200 once its key passes allowKey.
+func newAuthServer(t *testing.T, authz *auth) *httptest.Server {
+	keyed := func(role string) http.HandlerFunc {
+		return authz.require(role, func(w http.ResponseWriter, r *http.Request) {
+			var k Key
+			if err := json.NewDecoder(r.Body).Decode(&k); err != nil {
+				t.Errorf("decoding request: %v", err)
+			}
+			if !allowKey(w, r, scopedKey(k.Namespace, k.Key)) {
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/get", keyed(RoleRead))
+	mux.HandleFunc("/set", keyed(RoleWrite))
+	mux.HandleFunc("/join", authz.require(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func post(t *testing.T, srv *httptest.Server, path, token, body string, header http.Header) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res
+}
+
+func Test_auth_require(t *testing.T) {
+	srv := newAuthServer(t, &auth{tokens: []Token{
+		{Token: "reader", Roles: []string{RoleRead}},
+		{Token: "writer", Roles: []string{RoleWrite}},
+		{Token: "admin", Roles: []string{RoleAdmin}},
+		{Token: "team-a", Roles: []string{RoleWrite}, Prefixes: []string{"team-a/"}},
+	}})
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		body  string
+		want  int
+	}{
+		{"missing token", "/get", "", `{"key": "k"}`, http.StatusUnauthorized},
+		{"bad token", "/get", "nobody", `{"key": "k"}`, http.StatusUnauthorized},
+		{"read", "/get", "reader", `{"key": "k"}`, http.StatusOK},
+		{"read token writing", "/set", "reader", `{"key": "k"}`, http.StatusForbidden},
+		{"write token reading", "/get", "writer", `{"key": "k"}`, http.StatusOK},
+		{"write token joining", "/join", "writer", `{}`, http.StatusForbidden},
+		{"admin joining", "/join", "admin", `{}`, http.StatusOK},
+		{"prefix allowed", "/set", "team-a", `{"key": "team-a/k"}`, http.StatusOK},
+		{"prefix denied", "/set", "team-a", `{"key": "team-b/k"}`, http.StatusForbidden},
+		{"prefix allowed as namespace", "/set", "team-a", `{"namespace": "team-a", "key": "k"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if res := post(t, srv, tt.path, tt.token, tt.body, nil); res.StatusCode != tt.want {
+				t.Errorf("POST %s = %d, want %d", tt.path, res.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func Test_auth_cors(t *testing.T) {
+	srv := newAuthServer(t, &auth{origins: []string{"http://allowed.example"}})
+
+	tests := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{"allowed", "http://allowed.example", "http://allowed.example"},
+		{"not listed", "http://evil.example", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := post(t, srv, "/get", "", `{"key": "k"}`, http.Header{"Origin": {tt.origin}})
+			if got := res.Header.Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
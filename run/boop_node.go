@@ -2,12 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math/big"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jseam2/boopy"
@@ -28,27 +32,218 @@ type SetResponse struct {
 }
 
 type GetResponse struct {
-	Message string `json:"message"`
-	Error   string `json:"error"`
-	Key     string `json:"key"`
-	Value   string `json:"value"`
+	Message   string   `json:"message"`
+	Error     string   `json:"error"`
+	Key       string   `json:"key"`
+	Value     string   `json:"value"`
+	Timestamp int64    `json:"timestamp,omitempty"` // hybrid logical clock timestamp of the write
+	Siblings  []string `json:"siblings,omitempty"`  // with "siblings": true, every concurrent value
+	Context   []byte   `json:"context,omitempty"`   // causal context to send with the next /set
 }
 
 type FindResponse struct {
-	Message string `json:"message"`
-	Error   string `json:"error"`
-	Id      string `json:"id"`
-	Addr    string `json:"address"`
+	Message string        `json:"message"`
+	Error   string        `json:"error"`
+	Id      string        `json:"id"`
+	Addr    string        `json:"address"`
+	Hops    []HopResponse `json:"hops,omitempty"`
+}
+
+// HopResponse describes one node visited by a traced lookup
+type HopResponse struct {
+	Id      string  `json:"id"`
+	Addr    string  `json:"address"`
+	Finger  int32   `json:"finger"`
+	Latency float64 `json:"latency_ms"`
 }
 
 // KeyValue describes the values for inserting a key-value pair into the network
 type KeyValue struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Namespace string   `json:"namespace"`
+	Key       string   `json:"key"`
+	Value     string   `json:"value"`
+	TTL       float64  `json:"ttl,omitempty"`      // seconds, 0 uses the namespace default
+	Context   []byte   `json:"context,omitempty"`  // /set only: from the /get the write is based on
+	Siblings  []string `json:"siblings,omitempty"` // /scan only: values of a key with several
 }
 
 type Key struct {
-	Key string `json:"key"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Quorum    bool   `json:"quorum,omitempty"`   // /get only: read a majority of replicas
+	Siblings  bool   `json:"siblings,omitempty"` // /get only: return every concurrent value
+}
+
+// MultiRequest describes a batch of keys, or of pairs for /multiset
+type MultiRequest struct {
+	Namespace string     `json:"namespace"`
+	Keys      []string   `json:"keys"`
+	Values    []KeyValue `json:"values"`
+	TTL       float64    `json:"ttl"` // seconds, 0 uses the namespace default
+}
+
+// MultiResponse has one result per key, in request order
+type MultiResponse struct {
+	Message string        `json:"message"`
+	Error   string        `json:"error"`
+	Results []KeyResponse `json:"results"`
+}
+
+type KeyResponse struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error"`
+}
+
+// ScanRequest asks for one page of keys, continuing from cursor
+type ScanRequest struct {
+	Namespace string `json:"namespace"`
+	Prefix    string `json:"prefix"`
+	Values    bool   `json:"values"`
+	Limit     int    `json:"limit"`
+	Cursor    string `json:"cursor"`
+}
+
+type ScanResponse struct {
+	Message string     `json:"message"`
+	Error   string     `json:"error"`
+	Keys    []KeyValue `json:"keys"`
+	Cursor  string     `json:"cursor"` // empty once the scan is complete
+}
+
+// WatchEvent is one change streamed by /watch
+type WatchEvent struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// NamespaceConfig describes a namespace and its settings
+type NamespaceConfig struct {
+	Name              string  `json:"name"`
+	ReplicationFactor uint32  `json:"replication_factor"`
+	DefaultTTL        float64 `json:"default_ttl"` // seconds
+	MaxKeys           uint64  `json:"max_keys"`
+	MaxBytes          uint64  `json:"max_bytes"`
+	Siblings          bool    `json:"siblings"` // keep concurrent writes instead of the last one
+}
+
+// TxnRequest reads and writes keys of any namespaces atomically. The values
+// read are returned, and the writes only applied if none of them changed.
+type TxnRequest struct {
+	Reads   []Key      `json:"reads"`
+	Writes  []KeyValue `json:"writes"`
+	Deletes []Key      `json:"deletes"`
+}
+
+type TxnResponse struct {
+	Message string        `json:"message"`
+	Error   string        `json:"error"`
+	Reads   []KeyResponse `json:"reads"`
+}
+
+// LeaseRequest acquires a lease with /lease/acquire, or renews or releases
+// the grant carrying token
+type LeaseRequest struct {
+	Name  string  `json:"name"`
+	Token int64   `json:"token,omitempty"`
+	TTL   float64 `json:"ttl"` // seconds
+}
+
+type LeaseResponse struct {
+	Message string    `json:"message"`
+	Error   string    `json:"error"`
+	Name    string    `json:"name"`
+	Token   int64     `json:"token"` // fencing token, larger for every new grant
+	Expires time.Time `json:"expires"`
+}
+
+// UpdateRequest changes a counter with /incr, or a set with /members/add
+// and /members/remove
+type UpdateRequest struct {
+	Namespace string   `json:"namespace"`
+	Key       string   `json:"key"`
+	Delta     int64    `json:"delta"`
+	Members   []string `json:"members"`
+	TTL       float64  `json:"ttl,omitempty"` // seconds, applies when the key is created
+}
+
+type UpdateResponse struct {
+	Message string   `json:"message"`
+	Error   string   `json:"error"`
+	Key     string   `json:"key"`
+	Counter int64    `json:"counter"`
+	Members []string `json:"members"`
+}
+
+type NamespaceListResponse struct {
+	Message    string            `json:"message"`
+	Error      string            `json:"error"`
+	Namespaces []NamespaceConfig `json:"namespaces"`
+}
+
+// scopedKey is the name access control sees for a key, bucket/key
+func scopedKey(namespace, key string) string {
+	if namespace == "" {
+		return key
+	}
+	return namespace + "/" + key
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// multiResponse reports per key results, failing the request as a whole
+// only if every key failed
+func multiResponse(w http.ResponseWriter, results []boopy.BatchResult) {
+	res := MultiResponse{
+		Message: "Multi Success",
+		Error:   "",
+		Results: make([]KeyResponse, 0, len(results)),
+	}
+	failed := 0
+	for _, result := range results {
+		kr := KeyResponse{Key: result.Key, Value: string(result.Value)}
+		if result.Err != nil {
+			kr.Error = fmt.Sprintf("%v", result.Err)
+			failed++
+		}
+		res.Results = append(res.Results, kr)
+	}
+	if failed > 0 {
+		res.Message = "Multi Partial Failure"
+		if failed == len(results) {
+			res.Message = "Multi Failed"
+			res.Error = "every key failed"
+		}
+	}
+
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		panic(err)
+	}
+}
+
+// decodeMulti reads a batch request, checking every key against the ACL
+func decodeMulti(w http.ResponseWriter, r *http.Request) (MultiRequest, bool) {
+	decoder := json.NewDecoder(r.Body)
+	var req MultiRequest
+	err := decoder.Decode(&req)
+	if err != nil {
+		panic(err)
+	}
+	for _, key := range req.Keys {
+		if !allowKey(w, r, scopedKey(req.Namespace, key)) {
+			return req, false
+		}
+	}
+	for _, kv := range req.Values {
+		if !allowKey(w, r, scopedKey(req.Namespace, kv.Key)) {
+			return req, false
+		}
+	}
+	return req, true
 }
 
 type JoinConfig struct {
@@ -56,31 +251,91 @@ type JoinConfig struct {
 	Addr string `json:"address"`
 }
 
+// Optional mutual TLS between nodes, see boopy.Config
+var (
+	tlsCert       = flag.String("tls-cert", "", "certificate presented to other nodes, enables mutual TLS")
+	tlsKey        = flag.String("tls-key", "", "key for -tls-cert")
+	tlsClientCert = flag.String("tls-client-cert", "", "certificate used when dialing other nodes, defaults to -tls-cert")
+	tlsClientKey  = flag.String("tls-client-key", "", "key for -tls-client-cert")
+	tlsCA         = flag.String("tls-ca", "", "CA bundle used to verify other nodes")
+)
+
+// gRPC KeyValue service for clients, see boopy.Config
+var (
+	kvAddr    = flag.String("kv-addr", "", "address to answer KeyValue gRPC clients on, disabled when empty")
+	kvTLSCert = flag.String("kv-tls-cert", "", "certificate presented to KeyValue clients, enables TLS")
+	kvTLSKey  = flag.String("kv-tls-key", "", "key for -kv-tls-cert")
+	kvTLSCA   = flag.String("kv-tls-ca", "", "CA bundle KeyValue client certificates must be signed by, required with -auth-file")
+)
+
+// REST access control, see auth.go
+var (
+	authFile    = flag.String("auth-file", "", "JSON file of bearer tokens, roles and key prefixes; the API is open without it")
+	corsOrigins = flag.String("cors-origins", "*", "comma separated origins allowed to call the API")
+)
+
+// Redis protocol frontend, see boopy.Node.ServeRESP
+var (
+	respAddr      = flag.String("resp-addr", "", "address to answer Redis clients on, disabled when empty")
+	respNamespace = flag.String("resp-namespace", "", "namespace of the keys served to Redis clients")
+)
+
+// memcached protocol frontend, see boopy.Node.ServeMemcache
+var (
+	memcacheAddr      = flag.String("memcache-addr", "", "address to answer memcached clients on, disabled when empty")
+	memcacheNamespace = flag.String("memcache-namespace", "", "namespace of the keys served to memcached clients")
+)
+
+// Replica repair, see boopy.Config
+var (
+	antiEntropyInterval = flag.Duration("anti-entropy-interval", 30*time.Second, "how often owners compare keys with their replicas, 0 disables it")
+	repairRate          = flag.Int("repair-rate", 1000, "keys repaired per second by anti-entropy, 0 for no limit")
+	tombstoneGrace      = flag.Duration("tombstone-grace", 24*time.Hour, "how long deleted keys are remembered before being purged, 0 keeps them forever")
+	txnLockTimeout      = flag.Duration("txn-lock-timeout", 10*time.Second, "how long a prepared transaction locks its keys before its outcome is looked up")
+	maxClockDrift       = flag.Duration("max-clock-drift", time.Minute, "how far ahead of this node's clock other clocks are followed, 0 for no limit")
+	hintFile            = flag.String("hint-file", "", "file writes for unreachable owners are saved to until replayed, writes to them fail without it")
+)
+
 func createNode(id string, addr string, sister *api.Node) (*boopy.Node, error) {
 	// Wrapper function calling the newNode function from the core API
 
 	// Set gRPC settings for node location, timeouts, etc.
-	cnf := boopy.DefaultConfig()
+	cnf := boopy.BaseConfig()
 	cnf.Id = id
 	cnf.Addr = addr
 	cnf.MaxTimeoutDuration = 10 * time.Millisecond
 	cnf.MaxIdleDuration = 100 * time.Millisecond
+	cnf.TLSCertFile = *tlsCert
+	cnf.TLSKeyFile = *tlsKey
+	cnf.TLSClientCertFile = *tlsClientCert
+	cnf.TLSClientKeyFile = *tlsClientKey
+	cnf.TLSCAFile = *tlsCA
+	cnf.AntiEntropyInterval = *antiEntropyInterval
+	cnf.RepairRate = *repairRate
+	cnf.TombstoneGracePeriod = *tombstoneGrace
+	cnf.TxnLockTimeout = *txnLockTimeout
+	cnf.MaxClockDrift = *maxClockDrift
+	cnf.HintFile = *hintFile
+	cnf.KVAddr = *kvAddr
+	cnf.KVTLSCertFile = *kvTLSCert
+	cnf.KVTLSKeyFile = *kvTLSKey
+	cnf.KVTLSCAFile = *kvTLSCA
 
 	// Passthrough to the boopy library for newNode
 	n, err := boopy.NewNode(cnf, sister)
 	return n, err
 }
 
-func enableCors(w *http.ResponseWriter, req *http.Request) {
-	(*w).Header().Set("Access-Control-Allow-Origin", "*")
-	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-}
-
 func main() {
-	id := os.Args[1]
-	chordAddr := os.Args[2]
-	frontEndAddr := os.Args[3]
+	flag.Parse()
+	id := flag.Arg(0)
+	chordAddr := flag.Arg(1)
+	frontEndAddr := flag.Arg(2)
+
+	// Without client certificates the KeyValue port has no authentication.
+	if *kvAddr != "" && *authFile != "" && *kvTLSCA == "" {
+		log.Fatalln("-kv-addr cannot be used with -auth-file unless -kv-tls-ca is set")
+	}
 
 	node, err := createNode(id, chordAddr, nil)
 	if err != nil {
@@ -88,6 +343,42 @@ func main() {
 		return
 	}
 
+	authz := &auth{origins: strings.Split(*corsOrigins, ",")}
+	if *authFile != "" {
+		authz.tokens, err = loadAuth(*authFile)
+		if err != nil {
+			log.Fatalln(err)
+			return
+		}
+	}
+
+	// The RESP and memcached ports have no authentication, so they cannot sit
+	// beside tokens.
+	if *respAddr != "" {
+		if *authFile != "" {
+			log.Fatalln("-resp-addr cannot be used with -auth-file")
+		}
+		l, err := net.Listen("tcp", *respAddr)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		go func() {
+			log.Println("RESP frontend stopped: ", node.ServeRESP(l, *respNamespace))
+		}()
+	}
+	if *memcacheAddr != "" {
+		if *authFile != "" {
+			log.Fatalln("-memcache-addr cannot be used with -auth-file")
+		}
+		l, err := net.Listen("tcp", *memcacheAddr)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		go func() {
+			log.Println("memcached frontend stopped: ", node.ServeMemcache(l, *memcacheNamespace))
+		}()
+	}
+
 	shut := make(chan bool)
 
 	// REST Server
@@ -96,7 +387,7 @@ func main() {
 
 	// Basic ping function
 	http.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+		authz.cors(w, r)
 		res := Response{
 			Message: "Pong!",
 			Error:   "",
@@ -109,21 +400,22 @@ func main() {
 
 	// Setter Interface: input key-value pair into network
 	// submit {key: "", value: ""} -> Response
-	http.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+	http.HandleFunc("/set", authz.require(RoleWrite, func(w http.ResponseWriter, r *http.Request) {
 		decoder := json.NewDecoder(r.Body)
 		var kv KeyValue
 		err := decoder.Decode(&kv)
 		if err != nil {
 			panic(err)
 		}
+		if !allowKey(w, r, scopedKey(kv.Namespace, kv.Key)) {
+			return
+		}
 
-		nodeErr := node.Set(kv.Key, kv.Value)
+		nodeErr := node.SetWithContext(kv.Namespace, kv.Key, kv.Value, kv.Context, seconds(kv.TTL))
 		if nodeErr != nil {
-			panic(nodeErr)
 			res := SetResponse{
 				Message: "Set Failed",
-				Error:   fmt.Sprintf("%v", err),
+				Error:   fmt.Sprintf("%v", nodeErr),
 				Key:     kv.Key,
 				Value:   kv.Value,
 			}
@@ -143,33 +435,47 @@ func main() {
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
 		}
-	})
+	}))
 
 	// Search interface: given {key:""} -> Response{ID of node : "", Address:""}
-	http.HandleFunc("/find", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+	// With ?trace=true every hop of the lookup is returned as well.
+	http.HandleFunc("/find", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
 		decoder := json.NewDecoder(r.Body)
 		var k Key
 		err := decoder.Decode(&k)
 		if err != nil {
 			panic(err)
 		}
+		if !allowKey(w, r, scopedKey(k.Namespace, k.Key)) {
+			return
+		}
 
-		tempNode, nodeErr := node.Find(k.Key)
+		trace := r.URL.Query().Get("trace") == "true"
+		lookup, nodeErr := node.Lookup(scopedKey(k.Namespace, k.Key), boopy.LookupDefault)
 		if nodeErr != nil {
-			panic(nodeErr)
 			res := FindResponse{
 				Message: "Find Failed",
-				Error:   fmt.Sprintf("%v", err),
+				Error:   fmt.Sprintf("%v", nodeErr),
 				Id:      "",
 				Addr:    "",
 			}
+			// Abandoned lookups only know the nodes they visited.
+			if lookupErr, ok := nodeErr.(*boopy.LookupError); ok && trace {
+				for _, visited := range lookupErr.Path {
+					res.Hops = append(res.Hops, HopResponse{
+						Id:     fmt.Sprintf("%d", (&big.Int{}).SetBytes(visited.Id)),
+						Addr:   visited.Addr,
+						Finger: -1,
+					})
+				}
+			}
 			if err := json.NewEncoder(w).Encode(res); err != nil {
 				panic(err)
 			}
 			return
 		}
 
+		tempNode := lookup.Node
 		aInt := (&big.Int{}).SetBytes(tempNode.Id)
 
 		res := FindResponse{
@@ -179,58 +485,198 @@ func main() {
 			Addr:    tempNode.Addr,
 		}
 
+		if trace {
+			for _, hop := range lookup.Hops {
+				res.Hops = append(res.Hops, HopResponse{
+					Id:      fmt.Sprintf("%d", (&big.Int{}).SetBytes(hop.Node.Id)),
+					Addr:    hop.Node.Addr,
+					Finger:  hop.Finger,
+					Latency: float64(hop.Latency) / float64(time.Millisecond),
+				})
+			}
+		}
+
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
 		}
-	})
+	}))
 	// Value finder: given {key} -> find {value} in network
-	http.HandleFunc("/get", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+	http.HandleFunc("/get", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
 		decoder := json.NewDecoder(r.Body)
 		var k Key
 		err := decoder.Decode(&k)
 		if err != nil {
 			panic(err)
 		}
+		if !allowKey(w, r, scopedKey(k.Namespace, k.Key)) {
+			return
+		}
 
-		val, nodeErr := node.Get(k.Key)
+		if k.Siblings {
+			res := GetResponse{Message: "Get Success", Key: k.Key}
+			vals, context, nodeErr := node.GetSiblings(k.Namespace, k.Key)
+			if nodeErr != nil {
+				res.Message = "Get Failed"
+				res.Error = fmt.Sprintf("%v", nodeErr)
+			}
+			res.Siblings, res.Context = vals, context
+			if err := json.NewEncoder(w).Encode(res); err != nil {
+				panic(err)
+			}
+			return
+		}
+		get := node.GetStamped
+		if k.Quorum {
+			get = node.GetQuorumStamped
+		}
+		val, ts, nodeErr := get(k.Namespace, k.Key)
 		if nodeErr != nil {
 			res := GetResponse{
 				Message: "Get Failed",
-				Error:   fmt.Sprintf("%v", err),
+				Error:   fmt.Sprintf("%v", nodeErr),
 				Key:     k.Key,
 				Value:   "",
 			}
 			if err := json.NewEncoder(w).Encode(res); err != nil {
 				panic(err)
 			}
-			panic(nodeErr)
 			return
 		}
 
 		res := GetResponse{
-			Message: "Get Success",
+			Message:   "Get Success",
+			Error:     "",
+			Key:       k.Key,
+			Value:     fmt.Sprintf("%s", val),
+			Timestamp: int64(ts),
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+
+	// Batches: {namespace, keys: [...]} or {namespace, values: [{key, value}], ttl}
+	// -> one result per key. Keys are grouped by owning node.
+	http.HandleFunc("/multiget", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeMulti(w, r)
+		if !ok {
+			return
+		}
+		multiResponse(w, node.MultiGet(req.Namespace, req.Keys))
+	}))
+
+	http.HandleFunc("/multiset", authz.require(RoleWrite, func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeMulti(w, r)
+		if !ok {
+			return
+		}
+		pairs := make([]*api.KV, 0, len(req.Values))
+		for _, kv := range req.Values {
+			pairs = append(pairs, &api.KV{Key: kv.Key, Value: kv.Value})
+		}
+		multiResponse(w, node.MultiSet(req.Namespace, pairs, seconds(req.TTL)))
+	}))
+
+	http.HandleFunc("/multidelete", authz.require(RoleWrite, func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeMulti(w, r)
+		if !ok {
+			return
+		}
+		multiResponse(w, node.MultiDelete(req.Namespace, req.Keys))
+	}))
+
+	// Scan: given {namespace, prefix, values, limit, cursor} -> page of keys in ring order
+	http.HandleFunc("/scan", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		var req ScanRequest
+		err := decoder.Decode(&req)
+		if err != nil {
+			panic(err)
+		}
+		if !allowKey(w, r, scopedKey(req.Namespace, req.Prefix)) {
+			return
+		}
+
+		keys, cursor, nodeErr := node.Scan(boopy.ScanOptions{
+			Namespace: req.Namespace,
+			Prefix:    req.Prefix,
+			Values:    req.Values,
+			Limit:     req.Limit,
+			Cursor:    req.Cursor,
+		})
+		res := ScanResponse{
+			Message: "Scan Success",
 			Error:   "",
-			Key:     k.Key,
-			Value:   fmt.Sprintf("%s", val),
+			Keys:    []KeyValue{},
+			Cursor:  cursor,
+		}
+		if nodeErr != nil {
+			res.Message = "Scan Failed"
+			res.Error = fmt.Sprintf("%v", nodeErr)
+		}
+		for _, kv := range keys {
+			res.Keys = append(res.Keys, KeyValue{Namespace: kv.Namespace, Key: kv.Key, Value: kv.Value, Siblings: kv.Siblings})
 		}
 
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
 		}
-	})
+	}))
+
+	// Watch: given ?namespace=&key=&prefix=true -> server-sent events for changes
+	http.HandleFunc("/watch", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		namespace, key := query.Get("namespace"), query.Get("key")
+		if !allowKey(w, r, scopedKey(namespace, key)) {
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			deny(w, http.StatusInternalServerError, "streaming unsupported")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		watcher := node.Watch(namespace, key, query.Get("prefix") == "true")
+		defer watcher.Close()
+		for {
+			select {
+			case ev := <-watcher.Events():
+				data, err := json.Marshal(WatchEvent{
+					Type:      strings.ToLower(ev.Type.String()),
+					Namespace: ev.Namespace,
+					Key:       ev.Key,
+					Value:     ev.Value,
+				})
+				if err != nil {
+					panic(err)
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", strings.ToLower(ev.Type.String()), data)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
 
 	// Key deletion: Given {key} delete {key, value} from network
-	http.HandleFunc("/delete", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+	http.HandleFunc("/delete", authz.require(RoleWrite, func(w http.ResponseWriter, r *http.Request) {
 		decoder := json.NewDecoder(r.Body)
 		var k Key
 		err := decoder.Decode(&k)
 		if err != nil {
 			panic(err)
 		}
+		if !allowKey(w, r, scopedKey(k.Namespace, k.Key)) {
+			return
+		}
 
-		nodeErr := node.Delete(k.Key)
+		nodeErr := node.DeleteIn(k.Namespace, k.Key)
 		if nodeErr != nil {
 			panic(nodeErr)
 		}
@@ -243,11 +689,132 @@ func main() {
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
 		}
-	})
+	}))
+
+	// Transactions: {reads: [{namespace, key}], writes: [{namespace, key, value}],
+	// deletes: [{namespace, key}]} -> TxnResponse with the values read
+	http.HandleFunc("/txn", authz.require(RoleWrite, func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		var req TxnRequest
+		err := decoder.Decode(&req)
+		if err != nil {
+			panic(err)
+		}
+		for _, k := range append(req.Reads, req.Deletes...) {
+			if !allowKey(w, r, scopedKey(k.Namespace, k.Key)) {
+				return
+			}
+		}
+		for _, kv := range req.Writes {
+			if !allowKey(w, r, scopedKey(kv.Namespace, kv.Key)) {
+				return
+			}
+		}
+
+		txn := node.Begin()
+		res := TxnResponse{Message: "Transaction Success", Reads: make([]KeyResponse, 0, len(req.Reads))}
+		for _, k := range req.Reads {
+			val, err := txn.Get(k.Namespace, k.Key)
+			kr := KeyResponse{Key: k.Key, Value: string(val)}
+			if err != nil {
+				kr.Error = fmt.Sprintf("%v", err)
+			}
+			res.Reads = append(res.Reads, kr)
+		}
+		for _, kv := range req.Writes {
+			txn.Set(kv.Namespace, kv.Key, kv.Value)
+		}
+		for _, k := range req.Deletes {
+			txn.Delete(k.Namespace, k.Key)
+		}
+		if err := txn.Commit(); err != nil {
+			res.Message = "Transaction Failed"
+			res.Error = fmt.Sprintf("%v", err)
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+
+	// Leases: {name, ttl} or {name, token, ttl} -> LeaseResponse
+	lease := func(call func(req LeaseRequest) (*boopy.Lease, error)) http.HandlerFunc {
+		return authz.require(RoleWrite, func(w http.ResponseWriter, r *http.Request) {
+			decoder := json.NewDecoder(r.Body)
+			var req LeaseRequest
+			err := decoder.Decode(&req)
+			if err != nil {
+				panic(err)
+			}
+			if !allowKey(w, r, req.Name) {
+				return
+			}
+
+			res := LeaseResponse{Message: "Lease Success", Name: req.Name}
+			granted, nodeErr := call(req)
+			if nodeErr != nil {
+				res.Message = "Lease Failed"
+				res.Error = fmt.Sprintf("%v", nodeErr)
+			} else if granted != nil {
+				res.Token = granted.Token
+				res.Expires = granted.Expires
+			}
+			if err := json.NewEncoder(w).Encode(res); err != nil {
+				panic(err)
+			}
+		})
+	}
+	http.HandleFunc("/lease/acquire", lease(func(req LeaseRequest) (*boopy.Lease, error) {
+		return node.AcquireLease(req.Name, seconds(req.TTL))
+	}))
+	http.HandleFunc("/lease/renew", lease(func(req LeaseRequest) (*boopy.Lease, error) {
+		return node.RenewLease(&boopy.Lease{Name: req.Name, Token: req.Token}, seconds(req.TTL))
+	}))
+	http.HandleFunc("/lease/release", lease(func(req LeaseRequest) (*boopy.Lease, error) {
+		return nil, node.ReleaseLease(&boopy.Lease{Name: req.Name, Token: req.Token})
+	}))
+
+	// Counters and sets: {namespace, key, delta} or {namespace, key, members}
+	// -> UpdateResponse with the value after the change
+	update := func(role string, change func(req UpdateRequest) (UpdateResponse, error)) http.HandlerFunc {
+		return authz.require(role, func(w http.ResponseWriter, r *http.Request) {
+			decoder := json.NewDecoder(r.Body)
+			var req UpdateRequest
+			err := decoder.Decode(&req)
+			if err != nil {
+				panic(err)
+			}
+			if !allowKey(w, r, scopedKey(req.Namespace, req.Key)) {
+				return
+			}
+
+			res, nodeErr := change(req)
+			res.Key = req.Key
+			res.Message = "Update Success"
+			if nodeErr != nil {
+				res.Message = "Update Failed"
+				res.Error = fmt.Sprintf("%v", nodeErr)
+			}
+			if err := json.NewEncoder(w).Encode(res); err != nil {
+				panic(err)
+			}
+		})
+	}
+	http.HandleFunc("/incr", update(RoleWrite, func(req UpdateRequest) (res UpdateResponse, err error) {
+		res.Counter, err = node.IncrementIn(req.Namespace, req.Key, req.Delta, seconds(req.TTL))
+		return res, err
+	}))
+	http.HandleFunc("/members/add", update(RoleWrite, func(req UpdateRequest) (res UpdateResponse, err error) {
+		res.Members, err = node.AddIn(req.Namespace, req.Key, seconds(req.TTL), req.Members...)
+		return res, err
+	}))
+	http.HandleFunc("/members/remove", update(RoleWrite, func(req UpdateRequest) (res UpdateResponse, err error) {
+		res.Members, err = node.RemoveIn(req.Namespace, req.Key, req.Members...)
+		return res, err
+	}))
 
 	// Join
-	http.HandleFunc("/join", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+	http.HandleFunc("/join", authz.require(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
 		decoder := json.NewDecoder(r.Body)
 		var joinConfig JoinConfig
 		err := decoder.Decode(&joinConfig)
@@ -275,10 +842,101 @@ func main() {
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
 		}
-	})
+	}))
+
+	// Namespaces: create {name, replication_factor, default_ttl, max_keys, max_bytes}
+	http.HandleFunc("/namespace/create", authz.require(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		var cnf NamespaceConfig
+		err := decoder.Decode(&cnf)
+		if err != nil {
+			panic(err)
+		}
 
-	http.HandleFunc("/stabilize", func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w, r)
+		res := Response{
+			Message: "Create Namespace Success",
+			Error:   "",
+		}
+		nodeErr := node.CreateNamespace(&api.Namespace{
+			Name:              cnf.Name,
+			ReplicationFactor: cnf.ReplicationFactor,
+			DefaultTtl:        int64(seconds(cnf.DefaultTTL)),
+			MaxKeys:           cnf.MaxKeys,
+			MaxBytes:          cnf.MaxBytes,
+			Siblings:          cnf.Siblings,
+		})
+		if nodeErr != nil {
+			res = Response{
+				Message: "Create Namespace Failed",
+				Error:   fmt.Sprintf("%v", nodeErr),
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+
+	// Namespaces: delete {name} along with all of its keys
+	http.HandleFunc("/namespace/delete", authz.require(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		var cnf NamespaceConfig
+		err := decoder.Decode(&cnf)
+		if err != nil {
+			panic(err)
+		}
+
+		res := Response{
+			Message: "Delete Namespace Success",
+			Error:   "",
+		}
+		if nodeErr := node.DeleteNamespace(cnf.Name); nodeErr != nil {
+			res = Response{
+				Message: "Delete Namespace Failed",
+				Error:   fmt.Sprintf("%v", nodeErr),
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+
+	http.HandleFunc("/namespace/list", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
+		res := NamespaceListResponse{
+			Message:    "List Namespaces Success",
+			Error:      "",
+			Namespaces: []NamespaceConfig{},
+		}
+		list, nodeErr := node.ListNamespaces()
+		if nodeErr != nil {
+			res.Message = "List Namespaces Failed"
+			res.Error = fmt.Sprintf("%v", nodeErr)
+		}
+		for _, ns := range list {
+			res.Namespaces = append(res.Namespaces, NamespaceConfig{
+				Name:              ns.Name,
+				ReplicationFactor: ns.ReplicationFactor,
+				DefaultTTL:        time.Duration(ns.DefaultTtl).Seconds(),
+				MaxKeys:           ns.MaxKeys,
+				MaxBytes:          ns.MaxBytes,
+				Siblings:          ns.Siblings,
+			})
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
+
+	// Counters kept by the node, e.g. location cache hits and misses
+	http.HandleFunc("/metrics", authz.require(RoleRead, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(node.Metrics()); err != nil {
+			panic(err)
+		}
+	}))
+
+	http.HandleFunc("/stabilize", authz.require(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
 		node.Stabilize()
 
 		res := Response{
@@ -289,7 +947,26 @@ func main() {
 		if err := json.NewEncoder(w).Encode(res); err != nil {
 			panic(err)
 		}
-	})
+	}))
+
+	// Leave: hand our keys to the successor and stop serving the ring
+	var left int32
+	http.HandleFunc("/leave", authz.require(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		if !atomic.CompareAndSwapInt32(&left, 0, 1) {
+			deny(w, http.StatusConflict, "node has already left the ring")
+			return
+		}
+		node.Stop()
+
+		res := Response{
+			Message: "Leave Success",
+			Error:   "",
+		}
+
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			panic(err)
+		}
+	}))
 
 	// Expose server
 	log.Fatal(http.ListenAndServe(frontEndAddr, nil))
@@ -1,4 +1,9 @@
 python test_ping.py
 python test_set_get_delete.py
 python test_kill.py
+python test_auth.py
+python test_namespace.py
+python test_scan.py
+python test_multi.py
+python test_watch.py
 echo "Completed Integration Test Cases. Check for any failures in test cases"
\ No newline at end of file
@@ -0,0 +1,82 @@
+import os 
+import time
+import json
+import subprocess
+import signal
+import requests
+
+print('='*81)
+print("Running Integration Test: {}".format(__file__))
+
+node = ['1', '0.0.0.0:8001', '0.0.0.0:81']
+auth = {
+    'tokens': [
+        {'token': 'admin-token', 'roles': ['admin']},
+        {'token': 'writer-token', 'roles': ['write'], 'prefixes': ['team-a/']},
+        {'token': 'reader-token', 'roles': ['read']},
+    ]
+}
+
+with open('auth_test.json', 'w') as f:
+    json.dump(auth, f)
+
+print('-'*81)
+print("Initializing node")
+proc = subprocess.Popen([
+    'nohup', './boop_node', '-auth-file', 'auth_test.json',
+    '-cors-origins', 'http://localhost:3000',
+    node[0], node[1], node[2]
+])
+
+time.sleep(1)
+print("Completed Initialization")
+print('-'*81)
+
+def post(path, token, body):
+    headers = {}
+    if token:
+        headers['Authorization'] = 'Bearer ' + token
+    return requests.post('http://' + node[2] + path, json=body, headers=headers)
+
+# (description, path, token, body, expected status)
+cases = [
+    ("no token", '/set', None, {'key': 'team-a/1', 'value': 'v'}, 401),
+    ("unknown token", '/set', 'nope', {'key': 'team-a/1', 'value': 'v'}, 401),
+    ("reader cannot write", '/set', 'reader-token', {'key': 'team-a/1', 'value': 'v'}, 403),
+    ("writer inside prefix", '/set', 'writer-token', {'key': 'team-a/1', 'value': 'v'}, 200),
+    ("writer outside prefix", '/set', 'writer-token', {'key': 'team-b/1', 'value': 'v'}, 403),
+    ("writer can read", '/get', 'writer-token', {'key': 'team-a/1'}, 200),
+    ("reader can read", '/get', 'reader-token', {'key': 'team-a/1'}, 200),
+    ("writer cannot stabilize", '/stabilize', 'writer-token', {}, 403),
+    ("admin can stabilize", '/stabilize', 'admin-token', {}, 200),
+]
+
+for description, path, token, body, expected in cases:
+    res = post(path, token, body)
+    if res.status_code == expected:
+        print("Test Passed: {}".format(description))
+    else:
+        print("Test Failed: {} got {}".format(description, res.status_code))
+
+res = requests.options('http://' + node[2] + '/set',
+    headers={'Origin': 'http://localhost:3000'})
+if res.headers.get('Access-Control-Allow-Origin') == 'http://localhost:3000':
+    print("Test Passed: allowed origin")
+else:
+    print("Test Failed: allowed origin")
+
+res = requests.options('http://' + node[2] + '/set',
+    headers={'Origin': 'http://evil.example'})
+if 'Access-Control-Allow-Origin' not in res.headers:
+    print("Test Passed: rejected origin")
+else:
+    print("Test Failed: rejected origin")
+
+print('-'*81)
+
+print("Completed Integration test")
+print("Kill PID: {}".format(proc.pid))
+proc.terminate()
+os.remove('auth_test.json')
+
+print('='*81)
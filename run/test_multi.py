@@ -0,0 +1,66 @@
+import os 
+import time
+import subprocess
+import signal
+import requests
+
+print('='*81)
+print("Running Integration Test: {}".format(__file__))
+
+nodes = [
+    ['1', '0.0.0.0:8001', '0.0.0.0:81'],
+    ['2', '0.0.0.0:8002', '0.0.0.0:82'],
+    ['3', '0.0.0.0:8003', '0.0.0.0:83'],
+]
+
+proc_list = []
+
+print('-'*81)
+print("Initializing nodes")
+for node in nodes:
+    proc = subprocess.Popen([
+        'nohup', './boop_node', node[0], node[1], node[2]
+    ])
+    proc_list.append(proc)
+
+time.sleep(1)
+print("Completed Initialization")
+print('-'*81)
+
+for node in nodes[1:]:
+    requests.post('http://' + node[2] + '/join',
+        json={'id': nodes[0][0], 'address': nodes[0][1]})
+time.sleep(3)
+
+def post(node, path, body):
+    return requests.post('http://' + node[2] + path, json=body).json()
+
+def check(description, passed):
+    if passed:
+        print("Test Passed: {}".format(description))
+    else:
+        print("Test Failed: {}".format(description))
+
+keys = ['multi' + str(i) for i in range(30)]
+
+data = post(nodes[0], '/multiset', {'values': [{'key': k, 'value': k} for k in keys]})
+check("multiset", data['message'] == 'Multi Success')
+
+data = post(nodes[1], '/multiget', {'keys': keys + ['missing']})
+check("multiget values", [r['value'] for r in data['results'][:30]] == keys)
+check("multiget partial failure", data['results'][30]['error'] and data['message'] == 'Multi Partial Failure')
+
+data = post(nodes[2], '/multidelete', {'keys': keys})
+check("multidelete", data['message'] == 'Multi Success')
+
+data = post(nodes[0], '/multiget', {'keys': keys})
+check("keys deleted", data['message'] == 'Multi Failed')
+
+print('-'*81)
+
+print("Completed Integration test")
+for proc in proc_list:
+    print("Kill PID: {}".format(proc.pid))
+    proc.terminate()
+
+print('='*81)
@@ -0,0 +1,64 @@
+import os 
+import time
+import subprocess
+import signal
+import requests
+
+print('='*81)
+print("Running Integration Test: {}".format(__file__))
+
+node = ['1', '0.0.0.0:8001', '0.0.0.0:81']
+
+print('-'*81)
+print("Initializing node")
+proc = subprocess.Popen([
+    'nohup', './boop_node', node[0], node[1], node[2]
+])
+
+time.sleep(1)
+print("Completed Initialization")
+print('-'*81)
+
+def post(path, body):
+    return requests.post('http://' + node[2] + path, json=body).json()
+
+def check(description, passed):
+    if passed:
+        print("Test Passed: {}".format(description))
+    else:
+        print("Test Failed: {}".format(description))
+
+data = post('/namespace/create', {'name': 'photos', 'max_keys': 1})
+check("create namespace", not data['error'])
+
+data = post('/set', {'namespace': 'photos', 'key': 'cat', 'value': 'meow'})
+check("set in namespace", not data['error'])
+
+data = post('/set', {'key': 'cat', 'value': 'default'})
+check("set in default namespace", not data['error'])
+
+data = post('/get', {'namespace': 'photos', 'key': 'cat'})
+check("namespaces are isolated", data['value'] == 'meow')
+
+data = post('/set', {'namespace': 'photos', 'key': 'dog', 'value': 'woof'})
+check("quota rejects extra keys", data['error'])
+
+data = post('/set', {'namespace': 'missing', 'key': 'cat', 'value': 'meow'})
+check("unknown namespace rejected", data['error'])
+
+data = post('/namespace/list', {})
+check("list namespaces", [ns['name'] for ns in data['namespaces']] == ['photos'])
+
+data = post('/namespace/delete', {'name': 'photos'})
+check("delete namespace", not data['error'])
+
+data = post('/get', {'key': 'cat'})
+check("default namespace survives delete", data['value'] == 'default')
+
+print('-'*81)
+
+print("Completed Integration test")
+print("Kill PID: {}".format(proc.pid))
+proc.terminate()
+
+print('='*81)
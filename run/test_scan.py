@@ -0,0 +1,64 @@
+import os 
+import time
+import subprocess
+import signal
+import requests
+
+print('='*81)
+print("Running Integration Test: {}".format(__file__))
+
+nodes = [
+    ['1', '0.0.0.0:8001', '0.0.0.0:81'],
+    ['2', '0.0.0.0:8002', '0.0.0.0:82'],
+    ['3', '0.0.0.0:8003', '0.0.0.0:83'],
+]
+
+proc_list = []
+
+print('-'*81)
+print("Initializing nodes")
+for node in nodes:
+    proc = subprocess.Popen([
+        'nohup', './boop_node', node[0], node[1], node[2]
+    ])
+    proc_list.append(proc)
+
+time.sleep(1)
+print("Completed Initialization")
+print('-'*81)
+
+for node in nodes[1:]:
+    requests.post('http://' + node[2] + '/join',
+        json={'id': nodes[0][0], 'address': nodes[0][1]})
+time.sleep(3)
+
+for i in range(20):
+    requests.post('http://' + nodes[i % 3][2] + '/set',
+        json={'key': 'scan' + str(i), 'value': str(i)})
+
+seen = []
+cursor = ''
+while True:
+    data = requests.post('http://' + nodes[1][2] + '/scan',
+        json={'prefix': 'scan', 'values': True, 'limit': 6, 'cursor': cursor}).json()
+    if data['error']:
+        print("Test Failed: scan page {}".format(data['error']))
+        break
+    seen += [kv['key'] for kv in data['keys'] if kv['value'] == kv['key'][4:]]
+    cursor = data['cursor']
+    if not cursor:
+        break
+
+if sorted(seen) == sorted('scan' + str(i) for i in range(20)):
+    print("Test Passed: scan returned every key once")
+else:
+    print("Test Failed: scan returned {}".format(seen))
+
+print('-'*81)
+
+print("Completed Integration test")
+for proc in proc_list:
+    print("Kill PID: {}".format(proc.pid))
+    proc.terminate()
+
+print('='*81)
@@ -0,0 +1,65 @@
+import os 
+import time
+import subprocess
+import signal
+import requests
+
+print('='*81)
+print("Running Integration Test: {}".format(__file__))
+
+nodes = [
+    ['1', '0.0.0.0:8001', '0.0.0.0:81'],
+    ['2', '0.0.0.0:8002', '0.0.0.0:82'],
+    ['3', '0.0.0.0:8003', '0.0.0.0:83'],
+]
+
+proc_list = []
+
+print('-'*81)
+print("Initializing nodes")
+for node in nodes:
+    proc = subprocess.Popen([
+        'nohup', './boop_node', node[0], node[1], node[2]
+    ])
+    proc_list.append(proc)
+
+time.sleep(1)
+print("Completed Initialization")
+print('-'*81)
+
+for node in nodes[1:]:
+    requests.post('http://' + node[2] + '/join',
+        json={'id': nodes[0][0], 'address': nodes[0][1]})
+time.sleep(3)
+
+stream = requests.get('http://' + nodes[0][2] + '/watch',
+    params={'key': 'watch', 'prefix': 'true'}, stream=True, timeout=10)
+time.sleep(1)
+
+for i in range(6):
+    requests.post('http://' + nodes[i % 3][2] + '/set',
+        json={'key': 'watch' + str(i), 'value': str(i)})
+requests.post('http://' + nodes[1][2] + '/set',
+    json={'key': 'other', 'value': 'x'})
+
+seen = []
+for line in stream.iter_lines():
+    if line.startswith(b'data: '):
+        seen.append(line[6:])
+    if len(seen) == 6:
+        break
+stream.close()
+
+if all(b'"type":"set"' in data and b'"key":"watch' in data for data in seen):
+    print("Test Passed: watch streamed every matching set")
+else:
+    print("Test Failed: watch streamed {}".format(seen))
+
+print('-'*81)
+
+print("Completed Integration test")
+for proc in proc_list:
+    print("Kill PID: {}".format(proc.pid))
+    proc.terminate()
+
+print('='*81)
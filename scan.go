@@ -0,0 +1,182 @@
+package boopy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/jseam2/boopy/api"
+)
+
+var ERR_INVALID_CURSOR = errors.New("invalid scan cursor")
+
+// DefaultScanLimit is the page size used when ScanOptions.Limit is 0.
+const DefaultScanLimit = 100
+
+// ScanOptions selects the keys returned by Scan.
+type ScanOptions struct {
+	Namespace string
+	Prefix    string // only keys starting with Prefix
+	Values    bool   // also return values
+	Limit     int    // keys per page
+	Cursor    string // from the previous page, empty to start a scan
+}
+
+// Scan returns one page of keys in ring order and the cursor for the next
+// page, which is empty once the whole ring has been read. The cursor is a
+// ring position rather than a node, so a scan carries on across joins and
+// leaves: each page asks whoever owns the position now. A failed page can
+// be retried with the same cursor.
+func (n *Node) Scan(opts ScanOptions) ([]*api.KV, string, error) {
+	after, err := n.decodeCursor(opts.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultScanLimit
+	}
+
+	page := make([]*api.KV, 0, limit)
+	for {
+		owner, err := n.findSuccessor(nextID(after, len(n.Id)))
+		if err != nil {
+			return nil, "", err
+		}
+		var keys []*api.KV
+		err = n.followRedirects(owner, func(node *api.Node) error {
+			owner = node
+			keys, err = n.scanRPC(node, &api.ScanRequest{
+				Namespace: opts.Namespace,
+				After:     after,
+				Prefix:    opts.Prefix,
+				Values:    opts.Values,
+				Limit:     uint32(limit - len(page)),
+			})
+			return err
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		page = append(page, keys...)
+
+		if len(page) == limit {
+			last := page[len(page)-1]
+			pos, err := n.hashKey(namespacedKey(opts.Namespace, last.Key))
+			if err != nil {
+				return nil, "", err
+			}
+			return page, base64.RawURLEncoding.EncodeToString(pos), nil
+		}
+		// The owner's range wraps past the end of the ring, we are done.
+		if bytes.Compare(owner.Id, after) <= 0 {
+			return page, "", nil
+		}
+		after = owner.Id
+	}
+}
+
+func (n *Node) decodeCursor(cursor string) ([]byte, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	after, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(after) != len(n.Id) {
+		return nil, ERR_INVALID_CURSOR
+	}
+	return after, nil
+}
+
+func (n *Node) scanRPC(node *api.Node, req *api.ScanRequest) ([]*api.KV, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.scan(req)
+	}
+	return n.transport.Scan(node, req)
+}
+
+// scan collects the keys we own after req.After, sorted by ring position.
+// Replicas we hold for other ranges are never returned.
+func (n *Node) scan(req *api.ScanRequest) ([]*api.KV, error) {
+	next := nextID(req.After, len(n.Id))
+	if !n.owns(next) {
+		owner, err := n.findSuccessor(next)
+		if err != nil {
+			return nil, err
+		}
+		if !bytesEqual(owner.Id, n.Id) {
+			return nil, redirectError(owner)
+		}
+	}
+
+	n.stMtx.RLock()
+	vals, err := n.storage.List(req.Namespace)
+	n.stMtx.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	type position struct {
+		id []byte
+		kv *api.KV
+	}
+	found := make([]position, 0, len(vals))
+	for _, kv := range vals {
+		if !strings.HasPrefix(kv.Key, req.Prefix) {
+			continue
+		}
+		id, err := n.hashKey(namespacedKey(req.Namespace, kv.Key))
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Compare(id, req.After) > 0 && keyBetwIncludeRight(id, req.After, n.Id) {
+			found = append(found, position{id, kv})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return bytes.Compare(found[i].id, found[j].id) < 0
+	})
+	if req.Limit > 0 && len(found) > int(req.Limit) {
+		found = found[:req.Limit]
+	}
+
+	var settings *api.Namespace
+	if req.Values && len(found) > 0 {
+		if settings, err = n.namespaceSettings(req.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	keys := make([]*api.KV, 0, len(found))
+	for _, pos := range found {
+		kv := pos.kv
+		if !req.Values {
+			kv = &api.KV{Namespace: kv.Namespace, Key: kv.Key, Expires: kv.Expires}
+		} else if kv.Type != api.KV_STRING {
+			rendered := *kv
+			if rendered.Value, err = renderValue(kv); err != nil {
+				return nil, err
+			}
+			kv = &rendered
+		} else if settings.Siblings {
+			if kv, err = scannedSiblings(kv); err != nil {
+				return nil, err
+			}
+		}
+		keys = append(keys, kv)
+	}
+	return keys, nil
+}
+
+func (n *Node) XScan(req *api.ScanRequest, stream api.Chord_XScanServer) error {
+	keys, err := n.scan(req)
+	if err != nil {
+		return err
+	}
+	for _, kv := range keys {
+		if err := stream.Send(kv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
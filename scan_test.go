@@ -0,0 +1,126 @@
+package boopy
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/jseam2/boopy/api"
+)
+
+// scanAll pages through a whole scan, joining a new node to the ring after
+// the first page when join is set.
+func scanAll(t *testing.T, node *Node, opts ScanOptions, join func()) []*api.KV {
+	var all []*api.KV
+	for page := 0; page < 100; page++ {
+		keys, cursor, err := node.Scan(opts)
+		if err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if len(keys) > opts.Limit {
+			t.Fatalf("Scan() returned %d keys, limit %d", len(keys), opts.Limit)
+		}
+		all = append(all, keys...)
+		if cursor == "" {
+			return all
+		}
+		if page == 0 && join != nil {
+			join()
+		}
+		opts.Cursor = cursor
+	}
+	t.Fatalf("Scan() did not finish")
+	return nil
+}
+
+func TestNode_Scan(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "photos"}); err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]string)
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("key%d", i)
+		want[key] = fmt.Sprintf("value%d", i)
+		if err := nodes[i%3].Set(key, want[key]); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodes[i%3].SetIn("photos", fmt.Sprintf("other%d", i), "v", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(t *testing.T, got []*api.KV, values bool) {
+		seen := make(map[string]bool)
+		var last []byte
+		for _, kv := range got {
+			if seen[kv.Key] {
+				t.Errorf("Scan() returned %q twice", kv.Key)
+			}
+			seen[kv.Key] = true
+			if values && kv.Value != want[kv.Key] {
+				t.Errorf("Scan() value of %q = %q, want %q", kv.Key, kv.Value, want[kv.Key])
+			}
+			if !values && kv.Value != "" {
+				t.Errorf("Scan() returned value %q without Values", kv.Value)
+			}
+			id := GetHashID(kv.Key)
+			if bytes.Compare(id, last) <= 0 {
+				t.Errorf("Scan() returned %q out of ring order", kv.Key)
+			}
+			last = id
+		}
+		if len(seen) != len(want) {
+			t.Errorf("Scan() returned %d keys, want %d", len(seen), len(want))
+		}
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		check(t, scanAll(t, nodes[1], ScanOptions{Limit: 7, Values: true}, nil), true)
+	})
+
+	t.Run("keys only", func(t *testing.T) {
+		check(t, scanAll(t, nodes[2], ScanOptions{Limit: 100}, nil), false)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		got := scanAll(t, nodes[0], ScanOptions{Limit: 4, Prefix: "key1"}, nil)
+		if len(got) != 11 {
+			t.Errorf("Scan(key1) returned %d keys, want 11", len(got))
+		}
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		got := scanAll(t, nodes[0], ScanOptions{Limit: 10, Namespace: "photos"}, nil)
+		if len(got) != 30 || got[0].Namespace != "photos" {
+			t.Errorf("Scan(photos) returned %d keys, want 30", len(got))
+		}
+	})
+
+	t.Run("ring change", func(t *testing.T) {
+		join := func() {
+			cnf := testConfig(t, "4")
+			node, err := NewNode(cnf, nodes[0].Node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				node.transport.Stop()
+				close(node.shutdownCh)
+			})
+			ring := append(nodes, node)
+			for round := 0; round < 8; round++ {
+				for _, node := range ring {
+					node.stabilize()
+				}
+			}
+		}
+		check(t, scanAll(t, nodes[0], ScanOptions{Limit: 5, Values: true}, join), true)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		if _, _, err := nodes[0].Scan(ScanOptions{Cursor: "nope"}); err != ERR_INVALID_CURSOR {
+			t.Errorf("Scan() error = %v, want %v", err, ERR_INVALID_CURSOR)
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package boopy
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jseam2/boopy/api"
+)
+
+var (
+	ERR_SIBLINGS        = errors.New("key has concurrent values, read them with GetSiblings")
+	ERR_INVALID_CONTEXT = errors.New("invalid causal context")
+)
+
+// Namespaces created with Siblings keep concurrent writes of a key instead
+// of resolving them by timestamp. Each value carries a vector clock; a write
+// replaces the values its causal context has seen and is kept beside the
+// others. The owner, which accepts every write of its keys, stores them as
+// one SiblingSet, copied to replicas like any other value.
+
+// descends reports whether clock a has seen every write clock b has.
+func descends(a, b *api.VectorClock) bool {
+	for node, count := range b.GetCounters() {
+		if a.GetCounters()[node] < count {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeClocks returns the smallest clock descending from all of clocks.
+func mergeClocks(clocks ...*api.VectorClock) *api.VectorClock {
+	merged := &api.VectorClock{Counters: make(map[string]uint64)}
+	for _, clock := range clocks {
+		for node, count := range clock.GetCounters() {
+			if count > merged.Counters[node] {
+				merged.Counters[node] = count
+			}
+		}
+	}
+	return merged
+}
+
+// addSibling returns set after node accepted value written with the causal
+// context ctx: siblings ctx has seen are replaced, the others kept.
+func addSibling(set *api.SiblingSet, value string, ctx *api.VectorClock, node string) *api.SiblingSet {
+	clock := mergeClocks(ctx)
+	for _, sibling := range set.Siblings {
+		if count := sibling.Clock.GetCounters()[node]; count > clock.Counters[node] {
+			clock.Counters[node] = count
+		}
+	}
+	clock.Counters[node]++
+
+	next := &api.SiblingSet{Siblings: make([]*api.Sibling, 0, len(set.Siblings)+1)}
+	for _, sibling := range set.Siblings {
+		if !descends(ctx, sibling.Clock) {
+			next.Siblings = append(next.Siblings, sibling)
+		}
+	}
+	next.Siblings = append(next.Siblings, &api.Sibling{Value: value, Clock: clock})
+	return next
+}
+
+// mergeSiblingSets returns the siblings of a and b that no other sibling
+// has seen, and whether a and b each hold all of them.
+func mergeSiblingSets(a, b *api.SiblingSet) (merged *api.SiblingSet, inA, inB bool) {
+	all := append(append([]*api.Sibling(nil), a.Siblings...), b.Siblings...)
+	merged = new(api.SiblingSet)
+	for i, sibling := range all {
+		if !siblingSeen(all, i) {
+			merged.Siblings = append(merged.Siblings, sibling)
+		}
+	}
+	return merged, holdsSiblings(a, merged), holdsSiblings(b, merged)
+}
+
+// siblingSeen reports whether another of siblings has seen siblings[i], or
+// is the same write listed earlier.
+func siblingSeen(siblings []*api.Sibling, i int) bool {
+	clock := siblings[i].Clock
+	for j, other := range siblings {
+		if j == i || !descends(other.Clock, clock) {
+			continue
+		}
+		if j < i || !descends(clock, other.Clock) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdsSiblings reports whether set holds every write of siblings.
+func holdsSiblings(set, siblings *api.SiblingSet) bool {
+	for _, sibling := range siblings.Siblings {
+		held := false
+		for _, own := range set.Siblings {
+			if descends(own.Clock, sibling.Clock) && descends(sibling.Clock, own.Clock) {
+				held = true
+				break
+			}
+		}
+		if !held {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeSiblings(value string) (*api.SiblingSet, error) {
+	set := new(api.SiblingSet)
+	if err := proto.Unmarshal([]byte(value), set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func encodeSiblings(set *api.SiblingSet) (string, error) {
+	data, err := proto.Marshal(set)
+	return string(data), err
+}
+
+// decodeContext reads a causal context handed out with siblings. An empty
+// context has seen nothing.
+func decodeContext(ctx []byte) (*api.VectorClock, error) {
+	clock := new(api.VectorClock)
+	if err := proto.Unmarshal(ctx, clock); err != nil {
+		return nil, ERR_INVALID_CONTEXT
+	}
+	return clock, nil
+}
+
+// siblingValues returns the values of set and a causal context that has
+// seen all of them.
+func siblingValues(set *api.SiblingSet) ([]string, []byte, error) {
+	values := make([]string, len(set.Siblings))
+	clocks := make([]*api.VectorClock, len(set.Siblings))
+	for i, sibling := range set.Siblings {
+		values[i] = sibling.Value
+		clocks[i] = sibling.Clock
+	}
+	ctx, err := proto.Marshal(mergeClocks(clocks...))
+	return values, ctx, err
+}
+
+// singleValue returns a value stored in a namespace as read by callers that
+// expect one, failing with ERR_SIBLINGS if concurrent writes are kept.
+func singleValue(settings *api.Namespace, value string) (string, error) {
+	if !settings.Siblings {
+		return value, nil
+	}
+	set, err := decodeSiblings(value)
+	if err != nil {
+		return "", err
+	}
+	if len(set.Siblings) != 1 {
+		return "", ERR_SIBLINGS
+	}
+	return set.Siblings[0].Value, nil
+}
+
+// scannedSiblings returns a stored pair of a namespace keeping siblings as
+// scans return it.
+func scannedSiblings(kv *api.KV) (*api.KV, error) {
+	set, err := decodeSiblings(kv.Value)
+	if err != nil {
+		return nil, err
+	}
+	scanned := &api.KV{Namespace: kv.Namespace, Key: kv.Key, Expires: kv.Expires, Version: kv.Version}
+	if len(set.Siblings) == 1 {
+		scanned.Value = set.Siblings[0].Value
+	} else {
+		scanned.Siblings, _, err = siblingValues(set)
+	}
+	return scanned, err
+}
+
+// unionSiblings returns the copy of a key holding the siblings of both old
+// and kv, two live copies of it in a namespace keeping siblings, or nil if
+// old already is that copy. kv is returned when it holds them all and is
+// newer; otherwise the union takes a version newer than both, so that
+// anti-entropy carries it back.
+func (n *Node) unionSiblings(old, kv *api.KV) (*api.KV, error) {
+	ours, err := decodeSiblings(old.Value)
+	if err != nil {
+		return nil, err
+	}
+	theirs, err := decodeSiblings(kv.Value)
+	if err != nil {
+		return nil, err
+	}
+	set, inOurs, inTheirs := mergeSiblingSets(ours, theirs)
+	newer := old
+	if kv.Version > old.Version {
+		newer = kv
+	}
+	switch {
+	case inTheirs && newer == kv:
+		return kv, nil
+	case inOurs && newer == old:
+		return nil, nil // nothing new
+	}
+	merged := *newer
+	if merged.Value, err = encodeSiblings(set); err != nil {
+		return nil, err
+	}
+	merged.Version = n.clock.update(newer.Version)
+	if merged.Version <= newer.Version && newer.Version < math.MaxInt64 {
+		merged.Version = newer.Version + 1
+	}
+	return &merged, nil
+}
+
+// siblingWrite returns the value to store when value is written to key with
+// the causal context ctx. Must be called with stMtx held.
+func (n *Node) siblingWrite(ns, key, value string, ctx []byte) (string, error) {
+	clock, err := decodeContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	set := new(api.SiblingSet)
+	if old, err := n.storage.Lookup(ns, key); err == nil && !old.Deleted {
+		if set, err = decodeSiblings(old.Value); err != nil {
+			return "", err
+		}
+	}
+	return encodeSiblings(addSibling(set, value, clock, hex.EncodeToString(n.Id)))
+}
+
+// withSiblings fills in res, read from ns, with the siblings it holds if
+// the namespace keeps them.
+func (n *Node) withSiblings(ns string, res *api.GetResponse) (*api.GetResponse, error) {
+	settings, err := n.namespaceSettings(ns)
+	if err != nil || !settings.Siblings {
+		return res, err
+	}
+	set, err := decodeSiblings(string(res.Value))
+	if err != nil {
+		return nil, err
+	}
+	res.Siblings, res.Context, err = siblingValues(set)
+	if err != nil {
+		return nil, err
+	}
+	res.Value = nil
+	if len(res.Siblings) == 1 {
+		res.Value = []byte(res.Siblings[0])
+	}
+	return res, nil
+}
+
+// GetSiblings reads every concurrent value of a key and the causal context
+// to pass to SetWithContext. In namespaces without siblings the key's value
+// is returned alone, with no context.
+func (n *Node) GetSiblings(ns, key string) ([]string, []byte, error) {
+	res, err := n.lookup(ns, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res.Siblings) == 0 {
+		return []string{string(res.Value)}, nil, nil
+	}
+	return res.Siblings, res.Context, nil
+}
+
+// SetWithContext writes a key with the causal context returned by
+// GetSiblings, replacing the values read. Values written concurrently are
+// kept as siblings. Namespaces without siblings ignore the context.
+func (n *Node) SetWithContext(ns, key, value string, ctx []byte, ttl time.Duration) error {
+	return n.set(ns, key, value, ctx, ttl)
+}
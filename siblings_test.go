@@ -0,0 +1,222 @@
+package boopy
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/jseam2/boopy/api"
+)
+
+func clockOf(counters map[string]uint64) *api.VectorClock {
+	return &api.VectorClock{Counters: counters}
+}
+
+func Test_addSibling(t *testing.T) {
+	first := &api.Sibling{Value: "a", Clock: clockOf(map[string]uint64{"n1": 1})}
+	second := &api.Sibling{Value: "b", Clock: clockOf(map[string]uint64{"n1": 2})}
+	set := &api.SiblingSet{Siblings: []*api.Sibling{first, second}}
+
+	tests := []struct {
+		name  string
+		ctx   *api.VectorClock
+		want  []string
+		clock map[string]uint64
+	}{
+		{"blind", clockOf(nil), []string{"a", "b", "c"}, map[string]uint64{"n2": 1}},
+		{"seen one", clockOf(map[string]uint64{"n1": 1}), []string{"b", "c"}, map[string]uint64{"n1": 1, "n2": 1}},
+		{"seen all", clockOf(map[string]uint64{"n1": 2}), []string{"c"}, map[string]uint64{"n1": 2, "n2": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := addSibling(set, "c", tt.ctx, "n2")
+			var values []string
+			for _, sibling := range got.Siblings {
+				values = append(values, sibling.Value)
+			}
+			if len(values) != len(tt.want) {
+				t.Fatalf("addSibling() = %v, want %v", values, tt.want)
+			}
+			for i := range values {
+				if values[i] != tt.want[i] {
+					t.Errorf("addSibling() = %v, want %v", values, tt.want)
+				}
+			}
+			clock := got.Siblings[len(got.Siblings)-1].Clock
+			if !descends(clock, clockOf(tt.clock)) || !descends(clockOf(tt.clock), clock) {
+				t.Errorf("new sibling clock = %v, want %v", clock.Counters, tt.clock)
+			}
+		})
+	}
+}
+
+func TestNode_siblings(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "carts", ReplicationFactor: 2, Siblings: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[0].SetIn("carts", "ann", "milk", 0); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := nodes[1].GetIn("carts", "ann"); err != nil || string(val) != "milk" {
+		t.Fatalf("GetIn() of a single value = %q, %v, want milk", val, err)
+	}
+	_, ctx, err := nodes[1].GetSiblings("carts", "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two writers base their change on the same read.
+	if err := nodes[0].SetWithContext("carts", "ann", "milk,eggs", ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[1].SetWithContext("carts", "ann", "milk,bread", ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nodes[0].GetIn("carts", "ann"); err != ERR_SIBLINGS {
+		t.Errorf("GetIn() of concurrent values error = %v, want %v", err, ERR_SIBLINGS)
+	}
+	if _, err := nodes[0].GetQuorum("carts", "ann"); err != ERR_SIBLINGS {
+		t.Errorf("GetQuorum() of concurrent values error = %v, want %v", err, ERR_SIBLINGS)
+	}
+	values, ctx, err := nodes[0].GetSiblings("carts", "ann")
+	sort.Strings(values)
+	if err != nil || len(values) != 2 || values[0] != "milk,bread" || values[1] != "milk,eggs" {
+		t.Fatalf("GetSiblings() = %v, %v, want both writes", values, err)
+	}
+	keys, _, err := nodes[0].Scan(ScanOptions{Namespace: "carts", Values: true})
+	if err != nil || len(keys) != 1 || len(keys[0].Siblings) != 2 {
+		t.Errorf("Scan() = %v, %v, want ann with 2 siblings", keys, err)
+	}
+
+	// Writing with the context of both resolves them.
+	if err := nodes[1].SetWithContext("carts", "ann", "milk,eggs,bread", ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		if val, err := node.GetQuorum("carts", "ann"); err != nil || string(val) != "milk,eggs,bread" {
+			t.Errorf("GetQuorum() after resolving = %q, %v", val, err)
+		}
+	}
+
+	// Other namespaces ignore contexts and keep the last write.
+	if err := nodes[0].SetWithContext("", "key", "a", ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[0].SetWithContext("", "key", "b", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if values, ctx, err := nodes[1].GetSiblings("", "key"); err != nil || len(values) != 1 || values[0] != "b" || ctx != nil {
+		t.Errorf("GetSiblings() without siblings = %v, %v, %v, want [b]", values, ctx, err)
+	}
+	if err := nodes[0].SetWithContext("carts", "bob", "x", []byte("not a clock"), 0); err == nil {
+		t.Errorf("SetWithContext() with an invalid context succeeded")
+	}
+}
+
+func Test_mergeSiblingSets(t *testing.T) {
+	milk := &api.Sibling{Value: "milk", Clock: clockOf(map[string]uint64{"n1": 1})}
+	eggs := &api.Sibling{Value: "eggs", Clock: clockOf(map[string]uint64{"n1": 1, "n2": 1})}
+	bread := &api.Sibling{Value: "bread", Clock: clockOf(map[string]uint64{"n1": 1, "n3": 1})}
+	setOf := func(siblings ...*api.Sibling) *api.SiblingSet {
+		return &api.SiblingSet{Siblings: siblings}
+	}
+
+	tests := []struct {
+		name     string
+		a, b     *api.SiblingSet
+		want     []string
+		inA, inB bool
+	}{
+		{"same", setOf(milk), setOf(milk), []string{"milk"}, true, true},
+		{"b descends", setOf(milk), setOf(eggs), []string{"eggs"}, false, true},
+		{"a descends", setOf(eggs), setOf(milk), []string{"eggs"}, true, false},
+		{"concurrent", setOf(eggs), setOf(bread), []string{"bread", "eggs"}, false, false},
+		{"a holds both", setOf(eggs, bread), setOf(milk, bread), []string{"bread", "eggs"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, inA, inB := mergeSiblingSets(tt.a, tt.b)
+			var values []string
+			for _, sibling := range got.Siblings {
+				values = append(values, sibling.Value)
+			}
+			sort.Strings(values)
+			if len(values) != len(tt.want) || inA != tt.inA || inB != tt.inB {
+				t.Fatalf("mergeSiblingSets() = %v, %v, %v, want %v, %v, %v", values, inA, inB, tt.want, tt.inA, tt.inB)
+			}
+			for i := range values {
+				if values[i] != tt.want[i] {
+					t.Errorf("mergeSiblingSets() = %v, want %v", values, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNode_siblingsRepair(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "carts", ReplicationFactor: 2, Siblings: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[0].SetIn("carts", "ann", "milk", 0); err != nil {
+		t.Fatal(err)
+	}
+	owner, replica := nodes[0], nodes[1]
+	if !bytesEqual(ringOwner(nodes, namespacedKey("carts", "ann")).Id, owner.Id) {
+		owner, replica = replica, owner
+	}
+
+	// Each copy takes a write the other missed, the replica's stamped later.
+	diverge := func(node *Node, value, writer string) {
+		node.stMtx.Lock()
+		defer node.stMtx.Unlock()
+		old, err := node.storage.Lookup("carts", "ann")
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := decodeSiblings(old.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ctx, _ := siblingValues(set)
+		clock, _ := decodeContext(ctx)
+		value, err = encodeSiblings(addSibling(set, value, clock, writer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		kv := *old
+		kv.Value, kv.Version = value, node.clock.now()
+		if err := node.storage.Put(&kv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	diverge(owner, "milk,eggs", "w1")
+	diverge(replica, "milk,bread", "w2")
+
+	for round := 0; round < 2; round++ {
+		for _, node := range nodes {
+			node.antiEntropy()
+		}
+	}
+	for _, node := range nodes {
+		node.stMtx.RLock()
+		kv, err := node.storage.Lookup("carts", "ann")
+		node.stMtx.RUnlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := decodeSiblings(kv.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, _, _ := siblingValues(set)
+		sort.Strings(values)
+		if len(values) != 2 || values[0] != "milk,bread" || values[1] != "milk,eggs" {
+			t.Errorf("%s holds %v after anti-entropy, want both writes", node.Addr, values)
+		}
+	}
+	values, _, err := replica.GetSiblings("carts", "ann")
+	if err != nil || len(values) != 2 {
+		t.Errorf("GetSiblings() = %v, %v, want both writes", values, err)
+	}
+}
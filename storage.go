@@ -2,33 +2,81 @@ package boopy
 
 import (
 	"hash"
+	"time"
 
 	"github.com/jseam2/boopy/api"
 )
 
-// Storage defines the interface that allows the node to communicate with the underlying distributed map of [key] to [value]
+// Storage defines the interface that allows the node to communicate with the underlying distributed map of [key] to [value].
+// Keys are grouped into namespaces, the empty namespace being the default.
 type Storage interface {
-	Get(string) ([]byte, error)
-	Set(string, string) error
-	Delete(string) error
-	Between([]byte, []byte) ([]*api.KV, error)
-	MDelete(...string) error
+	Get(ns, key string) ([]byte, error)
+	Lookup(ns, key string) (*api.KV, error)                            // Get with the expiry and version of the key
+	Set(ns, key, value string, expires time.Time, version int64) error // zero expires means the key never expires
+	Put(kv *api.KV) error                                              // Set with the type of the pair
+	Delete(ns, key string, version int64) error                        // leaves a tombstone at version
+	Between([]byte, []byte) ([]*api.KV, error)                         // includes tombstones
+	MDelete(ns string, keys ...string) error                           // removes keys and their tombstones outright
+	List(ns string) ([]*api.KV, error)
+	Usage(ns string) (keys, bytes int)
+	DropNamespace(ns string) error
+	Expire(now time.Time) []*api.KV
+	Purge(before time.Time) int // drops tombstones left before before
 }
 
 /* mapStore defines two things:
-A map matching a key (string) to a value (string)
+A map matching a namespace to the buckets of key (string) to value (string)
 A Hash function that the store uses*/
 type mapStore struct {
-	data map[string]string
-	Hash func() hash.Hash // Hash function to use
+	buckets map[string]*bucket
+	Hash    func() hash.Hash // Hash function to use
 
 }
 
+// bucket holds the keys of one namespace.
+type bucket struct {
+	data     map[string]string
+	expires  map[string]time.Time   // only keys with a ttl
+	versions map[string]int64       // only keys written with a version
+	types    map[string]api.KV_Type // only keys that are not strings
+	flags    map[string]uint32      // only keys with flags
+	bytes    int                    // size of all keys and values
+
+	tombstones map[string]tombstone // deleted keys, kept until purged
+}
+
+// tombstone records that a key was deleted, so that older copies of it
+// held elsewhere are not taken for newer writes.
+type tombstone struct {
+	version int64
+	at      time.Time // when the tombstone was left here
+}
+
+func newBucket() *bucket {
+	return &bucket{
+		data:     make(map[string]string),
+		expires:  make(map[string]time.Time),
+		versions: make(map[string]int64),
+		types:    make(map[string]api.KV_Type),
+		flags:    make(map[string]uint32),
+
+		tombstones: make(map[string]tombstone),
+	}
+}
+
+// kvExpiry converts the expiry carried by a pair back to a time.
+func kvExpiry(kv *api.KV) time.Time {
+	if kv.Expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, kv.Expires)
+}
+
 // NewMapStore takes in a function which produces a hash and creates an empty mapStore that uses the hash function.
 func NewMapStore(hashFunc func() hash.Hash) Storage {
 	return &mapStore{
-		data: make(map[string]string),
-		Hash: hashFunc,
+		buckets: make(map[string]*bucket),
+		Hash:    hashFunc,
 	}
 }
 
@@ -42,42 +90,157 @@ func (storeptr *mapStore) hashKey(key string) ([]byte, error) {
 	return val, nil
 }
 
+// live checks that key exists in b and has not expired.
+func (b *bucket) live(key string, now time.Time) (string, bool) {
+	val, ok := b.data[key]
+	if !ok {
+		return "", false
+	}
+	if exp, ok := b.expires[key]; ok && !now.Before(exp) {
+		return "", false
+	}
+	return val, true
+}
+
+func (b *bucket) remove(key string) {
+	if val, ok := b.data[key]; ok {
+		b.bytes -= len(key) + len(val)
+		delete(b.data, key)
+		delete(b.expires, key)
+		delete(b.versions, key)
+		delete(b.types, key)
+		delete(b.flags, key)
+	}
+}
+
+// bury removes key and leaves a tombstone for it.
+func (b *bucket) bury(key string, version int64, at time.Time) {
+	b.remove(key)
+	b.tombstones[key] = tombstone{version: version, at: at}
+}
+
+// tombstoneKV builds the api representation of a deleted key.
+func (b *bucket) tombstoneKV(ns, key string) *api.KV {
+	return &api.KV{Namespace: ns, Key: key, Version: b.tombstones[key].version, Deleted: true}
+}
+
+// kv builds the api representation of key, carrying its namespace, expiry,
+// version, type and flags.
+func (b *bucket) kv(ns, key, val string) *api.KV {
+	pair := &api.KV{Namespace: ns, Key: key, Value: val, Version: b.versions[key], Type: b.types[key], Flags: b.flags[key]}
+	if exp, ok := b.expires[key]; ok {
+		pair.Expires = exp.UnixNano()
+	}
+	return pair
+}
+
 // Get performs a direct retrieval from the map of key-values to get the bytearray representation
-func (storeptr *mapStore) Get(key string) ([]byte, error) {
-	val, ok := storeptr.data[key]
+func (storeptr *mapStore) Get(ns, key string) ([]byte, error) {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		return nil, ERR_KEY_NOT_FOUND
+	}
+	val, ok := b.live(key, time.Now())
 	if !ok {
 		return nil, ERR_KEY_NOT_FOUND
 	}
 	return []byte(val), nil
 }
 
+// Lookup retrieves a live key as a pair, with its expiry and version. A
+// deleted key is returned as its tombstone.
+func (storeptr *mapStore) Lookup(ns, key string) (*api.KV, error) {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		return nil, ERR_KEY_NOT_FOUND
+	}
+	if _, ok := b.tombstones[key]; ok {
+		return b.tombstoneKV(ns, key), nil
+	}
+	val, ok := b.live(key, time.Now())
+	if !ok {
+		return nil, ERR_KEY_NOT_FOUND
+	}
+	return b.kv(ns, key, val), nil
+}
+
 // Set adds a key to the mapStore.s
-func (storeptr *mapStore) Set(key, value string) error {
-	storeptr.data[key] = value
+func (storeptr *mapStore) Set(ns, key, value string, expires time.Time, version int64) error {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		b = newBucket()
+		storeptr.buckets[ns] = b
+	}
+	b.remove(key)
+	delete(b.tombstones, key)
+	b.data[key] = value
+	b.bytes += len(key) + len(value)
+	if !expires.IsZero() {
+		if b.expires == nil {
+			b.expires = make(map[string]time.Time)
+		}
+		b.expires[key] = expires
+	}
+	if version != 0 {
+		if b.versions == nil {
+			b.versions = make(map[string]int64)
+		}
+		b.versions[key] = version
+	}
+	return nil
+}
+
+// Put stores a pair as given, keeping its type, flags, expiry and version.
+func (storeptr *mapStore) Put(kv *api.KV) error {
+	if err := storeptr.Set(kv.Namespace, kv.Key, kv.Value, kvExpiry(kv), kv.Version); err != nil {
+		return err
+	}
+	if kv.Type != api.KV_STRING {
+		storeptr.buckets[kv.Namespace].types[kv.Key] = kv.Type
+	}
+	if kv.Flags != 0 {
+		storeptr.buckets[kv.Namespace].flags[kv.Key] = kv.Flags
+	}
 	return nil
 }
 
-// Delete removes a given key-value pair from the mapStore by the given key.
-func (storeptr *mapStore) Delete(key string) error {
-	delete(storeptr.data, key)
+// Delete removes a given key-value pair from the mapStore by the given key,
+// leaving a tombstone at version in its place.
+func (storeptr *mapStore) Delete(ns, key string, version int64) error {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		b = newBucket()
+		storeptr.buckets[ns] = b
+	}
+	b.bury(key, version, time.Now())
 	return nil
 }
 
-// Between returns up to 10 keys that are between a given
+// Between returns the keys of every namespace whose hash falls between from
+// (exclusive) and to (inclusive). Namespaced keys are hashed as ns/key.
 func (storeptr *mapStore) Between(from []byte, to []byte) ([]*api.KV, error) {
 	// Generate a slice of up to 10 key-value pairs
 	betwVals := make([]*api.KV, 0, 10)
-	for key, val := range storeptr.data {
-		// generate hash of each key
-		hashedKey, err := storeptr.hashKey(key)
-		if err == nil {
-			// check if any of the hashed keys match the search range; add if it does to returned slice
-			if keyBetwIncludeRight(hashedKey, from, to) {
-				pair := &api.KV{
-					Key:   key,
-					Value: val,
+	now := time.Now()
+	for ns, b := range storeptr.buckets {
+		for key := range b.data {
+			val, ok := b.live(key, now)
+			if !ok {
+				continue
+			}
+			// generate hash of each key
+			hashedKey, err := storeptr.hashKey(namespacedKey(ns, key))
+			if err == nil {
+				// check if any of the hashed keys match the search range; add if it does to returned slice
+				if keyBetwIncludeRight(hashedKey, from, to) {
+					betwVals = append(betwVals, b.kv(ns, key, val))
 				}
-				betwVals = append(betwVals, pair)
+			}
+		}
+		for key := range b.tombstones {
+			hashedKey, err := storeptr.hashKey(namespacedKey(ns, key))
+			if err == nil && keyBetwIncludeRight(hashedKey, from, to) {
+				betwVals = append(betwVals, b.tombstoneKV(ns, key))
 			}
 		}
 	}
@@ -86,9 +249,74 @@ func (storeptr *mapStore) Between(from []byte, to []byte) ([]*api.KV, error) {
 }
 
 // MDelete allows users to delete more than one key by providing multiple strings
-func (storeptr *mapStore) MDelete(keys ...string) error {
+func (storeptr *mapStore) MDelete(ns string, keys ...string) error {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		return nil
+	}
 	for _, key := range keys {
-		delete(storeptr.data, key)
+		b.remove(key)
+		delete(b.tombstones, key)
+	}
+	return nil
+}
+
+// List returns every live key in a namespace.
+func (storeptr *mapStore) List(ns string) ([]*api.KV, error) {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		return nil, nil
+	}
+	vals := make([]*api.KV, 0, len(b.data))
+	now := time.Now()
+	for key := range b.data {
+		if val, ok := b.live(key, now); ok {
+			vals = append(vals, b.kv(ns, key, val))
+		}
+	}
+	return vals, nil
+}
+
+// Usage returns the number of keys held in a namespace and their size,
+// including keys that have expired but not been collected yet.
+func (storeptr *mapStore) Usage(ns string) (int, int) {
+	b, ok := storeptr.buckets[ns]
+	if !ok {
+		return 0, 0
 	}
+	return len(b.data), b.bytes
+}
+
+// DropNamespace removes a namespace and all of its keys at once.
+func (storeptr *mapStore) DropNamespace(ns string) error {
+	delete(storeptr.buckets, ns)
 	return nil
 }
+
+// Expire removes keys that expired before now, returning them.
+func (storeptr *mapStore) Expire(now time.Time) []*api.KV {
+	var removed []*api.KV
+	for ns, b := range storeptr.buckets {
+		for key, exp := range b.expires {
+			if !now.Before(exp) {
+				removed = append(removed, &api.KV{Namespace: ns, Key: key, Expires: exp.UnixNano()})
+				b.remove(key)
+			}
+		}
+	}
+	return removed
+}
+
+// Purge drops tombstones left before before, returning how many it dropped.
+func (storeptr *mapStore) Purge(before time.Time) int {
+	purged := 0
+	for _, b := range storeptr.buckets {
+		for key, stone := range b.tombstones {
+			if stone.at.Before(before) {
+				delete(b.tombstones, key)
+				purged++
+			}
+		}
+	}
+	return purged
+}
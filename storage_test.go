@@ -5,6 +5,7 @@ import (
 	"hash"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jseam2/boopy/api"
 )
@@ -29,6 +30,17 @@ func TestNewMapStore(t *testing.T) {
 		})
 	}
 }
+
+// defaultBuckets puts data in the default namespace of a mapStore.
+func defaultBuckets(data map[string]string) map[string]*bucket {
+	b := newBucket()
+	for key, val := range data {
+		b.data[key] = val
+		b.bytes += len(key) + len(val)
+	}
+	return map[string]*bucket{"": b}
+}
+
 func shaSum(str string) []byte {
 	h := sha1.New()
 	h.Write([]byte(str))
@@ -69,8 +81,8 @@ func Test_mapStore_hashKey(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &mapStore{
-				data: tt.fields.data,
-				Hash: tt.fields.Hash,
+				buckets: defaultBuckets(tt.fields.data),
+				Hash:    tt.fields.Hash,
 			}
 			got, err := a.hashKey(tt.args.key)
 			if (err != nil) != tt.wantErr {
@@ -124,10 +136,10 @@ func Test_mapStore_Get(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &mapStore{
-				data: tt.fields.data,
-				Hash: tt.fields.Hash,
+				buckets: defaultBuckets(tt.fields.data),
+				Hash:    tt.fields.Hash,
 			}
-			got, err := a.Get(tt.args.key)
+			got, err := a.Get("", tt.args.key)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("mapStore.Get() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -167,10 +179,10 @@ func Test_mapStore_Set(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &mapStore{
-				data: tt.fields.data,
-				Hash: tt.fields.Hash,
+				buckets: defaultBuckets(tt.fields.data),
+				Hash:    tt.fields.Hash,
 			}
-			if err := a.Set(tt.args.key, tt.args.value); (err != nil) != tt.wantErr {
+			if err := a.Set("", tt.args.key, tt.args.value, time.Time{}, 0); (err != nil) != tt.wantErr {
 				t.Errorf("mapStore.Set() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -208,10 +220,10 @@ func Test_mapStore_Delete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &mapStore{
-				data: tt.fields.data,
-				Hash: tt.fields.Hash,
+				buckets: defaultBuckets(tt.fields.data),
+				Hash:    tt.fields.Hash,
 			}
-			if err := a.Delete(tt.args.key); (err != nil) != tt.wantErr {
+			if err := a.Delete("", tt.args.key, 1); (err != nil) != tt.wantErr {
 				t.Errorf("mapStore.Delete() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -261,8 +273,8 @@ func Test_mapStore_Between(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &mapStore{
-				data: tt.fields.data,
-				Hash: tt.fields.Hash,
+				buckets: defaultBuckets(tt.fields.data),
+				Hash:    tt.fields.Hash,
 			}
 			got, err := a.Between(tt.args.from, tt.args.to)
 			if (err != nil) != tt.wantErr {
@@ -311,12 +323,106 @@ func Test_mapStore_MDelete(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &mapStore{
-				data: tt.fields.data,
-				Hash: tt.fields.Hash,
+				buckets: defaultBuckets(tt.fields.data),
+				Hash:    tt.fields.Hash,
 			}
-			if err := a.MDelete(tt.args.keys...); (err != nil) != tt.wantErr {
+			if err := a.MDelete("", tt.args.keys...); (err != nil) != tt.wantErr {
 				t.Errorf("mapStore.MDelete() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_mapStore_tombstones(t *testing.T) {
+	store := NewMapStore(sha1.New)
+	store.Set("", "key", "value", time.Time{}, 1)
+	store.Delete("", "key", 2)
+
+	if _, err := store.Get("", "key"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("Get() of a deleted key error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+	if kv, err := store.Lookup("", "key"); err != nil || !kv.Deleted || kv.Version != 2 {
+		t.Errorf("Lookup() = %v, %v, want a tombstone at version 2", kv, err)
+	}
+	if list, _ := store.List(""); len(list) != 0 {
+		t.Errorf("List() = %v, want no keys", list)
+	}
+	if keys, size := store.Usage(""); keys != 0 || size != 0 {
+		t.Errorf("Usage() = %d, %d, want 0, 0", keys, size)
+	}
+	if all, _ := store.Between([]byte{0}, []byte{0}); len(all) != 1 || !all[0].Deleted {
+		t.Errorf("Between() = %v, want the tombstone", all)
+	}
+
+	if purged := store.Purge(time.Now().Add(-time.Hour)); purged != 0 {
+		t.Errorf("Purge() of a recent tombstone = %d, want 0", purged)
+	}
+	if purged := store.Purge(time.Now().Add(time.Second)); purged != 1 {
+		t.Errorf("Purge() = %d, want 1", purged)
+	}
+	if _, err := store.Lookup("", "key"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("Lookup() after Purge() error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+
+	store.Delete("", "key", 3)
+	store.Set("", "key", "again", time.Time{}, 4)
+	if kv, err := store.Lookup("", "key"); err != nil || kv.Deleted || kv.Value != "again" {
+		t.Errorf("Lookup() after Set() = %v, %v, want the new value", kv, err)
+	}
+	store.Delete("", "key", 5)
+	store.MDelete("", "key")
+	if _, err := store.Lookup("", "key"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("Lookup() after MDelete() error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+}
+
+func Test_mapStore_namespaces(t *testing.T) {
+	store := NewMapStore(sha1.New)
+	now := time.Now()
+	store.Set("", "key", "default", time.Time{}, 0)
+	store.Set("photos", "key", "photo", time.Time{}, 0)
+	store.Set("photos", "old", "gone", now.Add(-time.Second), 0)
+	store.Set("photos", "new", "kept", now.Add(time.Hour), 0)
+
+	if got, err := store.Get("photos", "key"); err != nil || string(got) != "photo" {
+		t.Errorf("Get(photos, key) = %q, %v, want photo", got, err)
+	}
+	if got, err := store.Get("", "key"); err != nil || string(got) != "default" {
+		t.Errorf("Get(, key) = %q, %v, want default", got, err)
+	}
+	if _, err := store.Get("photos", "old"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("Get(photos, old) error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+	if keys, size := store.Usage("photos"); keys != 3 || size != 22 {
+		t.Errorf("Usage(photos) = %d, %d, want 3, 22", keys, size)
+	}
+
+	if removed := store.Expire(now); len(removed) != 1 || removed[0].Key != "old" {
+		t.Errorf("Expire() = %v, want [old]", removed)
+	}
+	list, _ := store.List("photos")
+	if len(list) != 2 {
+		t.Errorf("List(photos) = %v, want 2 keys", list)
+	}
+	for _, kv := range list {
+		if kv.Key == "new" && kv.Expires != now.Add(time.Hour).UnixNano() {
+			t.Errorf("List(photos) expiry = %d, want %d", kv.Expires, now.Add(time.Hour).UnixNano())
+		}
+	}
+
+	all, _ := store.Between([]byte{0}, []byte{0})
+	if len(all) != 3 {
+		t.Errorf("Between() over the whole ring = %v, want 3 keys", all)
+	}
+
+	store.DropNamespace("photos")
+	if _, err := store.Get("photos", "key"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("Get(photos, key) after drop error = %v", err)
+	}
+	if keys, _ := store.Usage("photos"); keys != 0 {
+		t.Errorf("Usage(photos) after drop = %d keys", keys)
+	}
+	if _, err := store.Get("", "key"); err != nil {
+		t.Errorf("DropNamespace(photos) removed the default namespace: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package boopy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"hash"
+	"io/ioutil"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	ERR_NO_PEER_CERT    = errors.New("peer did not present a certificate")
+	ERR_PEER_IDENTITY   = errors.New("peer certificate does not match node identity")
+	ERR_NO_CA_CERTS     = errors.New("no certificates found in CA bundle")
+	ERR_TLS_CLIENT_ONLY = errors.New("client certificate set without server certificate")
+	ERR_KV_TLS_CA_ONLY  = errors.New("client CA set without a certificate for the KeyValue service")
+)
+
+// tlsEnabled checks if the config asks for TLS between nodes.
+func (cnf *Config) tlsEnabled() bool {
+	return cnf.TLSCertFile != "" || cnf.TLSClientCertFile != ""
+}
+
+// nodeTLS holds what a transport needs to run Chord RPCs over mutual TLS.
+type nodeTLS struct {
+	server *tls.Config
+	client tls.Certificate
+	roots  *x509.CertPool
+	hash   func() hash.Hash
+}
+
+func loadNodeTLS(cnf *Config) (*nodeTLS, error) {
+	if cnf.TLSCertFile == "" {
+		return nil, ERR_TLS_CLIENT_ONLY
+	}
+
+	serverCert, err := tls.LoadX509KeyPair(cnf.TLSCertFile, cnf.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCert := serverCert
+	if cnf.TLSClientCertFile != "" {
+		clientCert, err = tls.LoadX509KeyPair(cnf.TLSClientCertFile, cnf.TLSClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	roots, err := loadCertPool(cnf.TLSCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodeTLS{
+		server: &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    roots,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		},
+		client: clientCert,
+		roots:  roots,
+		hash:   cnf.Hash,
+	}, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pemCerts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pemCerts) {
+		return nil, ERR_NO_CA_CERTS
+	}
+	return roots, nil
+}
+
+// loadKVTLS returns the credentials the KeyValue service listens with. They
+// share nothing with those between nodes: clients are not ring members.
+func loadKVTLS(cnf *Config) (credentials.TransportCredentials, error) {
+	if cnf.KVTLSCertFile == "" {
+		return nil, ERR_KV_TLS_CA_ONLY
+	}
+	cert, err := tls.LoadX509KeyPair(cnf.KVTLSCertFile, cnf.KVTLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if cnf.KVTLSCAFile != "" {
+		if config.ClientCAs, err = loadCertPool(cnf.KVTLSCAFile); err != nil {
+			return nil, err
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// serverCredentials returns the credentials the gRPC server listens with.
+func (t *nodeTLS) serverCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(t.server)
+}
+
+// clientCredentials returns credentials for dialing node. The server must
+// present a certificate from our CA whose identity hashes to node.Id.
+func (t *nodeTLS) clientCredentials(node *api.Node) credentials.TransportCredentials {
+	id := node.Id
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{t.client},
+		// Peers are addressed by node ID rather than hostname, the chain
+		// and identity are checked in VerifyPeerCertificate instead.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			cert, err := t.verifyChain(rawCerts)
+			if err != nil {
+				return err
+			}
+			if !certMatchesID(cert, id, t.hash) {
+				return ERR_PEER_IDENTITY
+			}
+			return nil
+		},
+	})
+}
+
+func (t *nodeTLS) verifyChain(rawCerts [][]byte) (*x509.Certificate, error) {
+	if len(rawCerts) == 0 {
+		return nil, ERR_NO_PEER_CERT
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         t.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+// certMatchesID checks if the certificate names the node with the given ID.
+// Node IDs are hashes of Config.Id (or Config.Addr), which must appear as the
+// certificate's common name or one of its DNS names.
+func certMatchesID(cert *x509.Certificate, id []byte, hashFunc func() hash.Hash) bool {
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		h := hashFunc()
+		if _, err := h.Write([]byte(name)); err != nil {
+			continue
+		}
+		if bytesEqual(h.Sum(nil), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyPeer checks that the caller of an RPC holds a certificate for the
+// node it claims to be. Always passes when TLS is disabled.
+func (n *Node) verifyPeer(ctx context.Context, claimed *api.Node) error {
+	if !n.cnf.tlsEnabled() {
+		return nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ERR_NO_PEER_CERT
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return ERR_NO_PEER_CERT
+	}
+	if !certMatchesID(info.State.PeerCertificates[0], claimed.Id, n.cnf.Hash) {
+		return ERR_PEER_IDENTITY
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package boopy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// testCA issues certificates for test nodes and writes them to dir.
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, dir, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &testCA{dir: dir, cert: cert, key: key}
+	writePEM(t, ca.file(name+".crt"), "CERTIFICATE", der)
+	return ca
+}
+
+func (ca *testCA) file(name string) string {
+	return filepath.Join(ca.dir, name)
+}
+
+// issue writes a certificate and key for the node identity name, returning
+// the file paths.
+func (ca *testCA) issue(t *testing.T, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := ca.file(ca.cert.Subject.CommonName + "-" + name + ".crt")
+	keyFile := ca.file(ca.cert.Subject.CommonName + "-" + name + ".key")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDer)
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tlsConfig returns a test config for node id using a certificate issued
+// for certName.
+func tlsConfig(t *testing.T, ca, trusted *testCA, id, certName string) *Config {
+	cnf := testConfig(t, id)
+	cnf.TLSCertFile, cnf.TLSKeyFile = ca.issue(t, certName)
+	cnf.TLSCAFile = trusted.file(trusted.cert.Subject.CommonName + ".crt")
+	return cnf
+}
+
+func TestNode_mutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boopy-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := newTestCA(t, dir, "ca")
+	rogueCA := newTestCA(t, dir, "rogue")
+
+	first, err := NewNode(tlsConfig(t, ca, ca, "1", "1"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Stop()
+	second, err := NewNode(tlsConfig(t, ca, ca, "2", "2"), first.Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Stop()
+
+	first.stabilize()
+	second.stabilize()
+	first.stabilize()
+	if err := second.Set("key", "value"); err != nil {
+		t.Fatalf("Set() over TLS error = %v", err)
+	}
+	if got, err := first.Get("key"); err != nil || string(got) != "value" {
+		t.Fatalf("Get() over TLS = %q, %v", got, err)
+	}
+
+	t.Run("untrusted CA", func(t *testing.T) {
+		cnf := tlsConfig(t, rogueCA, rogueCA, "3", "3")
+		cnf.DialOpts = append(cnf.DialOpts, grpc.WithTimeout(time.Second))
+		if _, err := NewNode(cnf, first.Node); err == nil {
+			t.Errorf("NewNode() with untrusted certificate joined the ring")
+		}
+	})
+
+	t.Run("wrong server identity", func(t *testing.T) {
+		cnf := tlsConfig(t, ca, ca, "4", "4")
+		cnf.DialOpts = append(cnf.DialOpts, grpc.WithTimeout(time.Second))
+		transport, err := NewGrpcTransport(cnf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer transport.Stop()
+		impostor := NewInode("2", first.Addr)
+		if _, err := transport.GetSuccessor(impostor); err == nil {
+			t.Errorf("GetSuccessor() accepted %s presenting a certificate for node 1", first.Addr)
+		}
+	})
+
+	t.Run("wrong claimed identity", func(t *testing.T) {
+		// Certificate for node 5 but claiming to be node 6.
+		cnf := tlsConfig(t, ca, ca, "6", "5")
+		transport, err := NewGrpcTransport(cnf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer transport.Stop()
+		if err := transport.Notify(first.Node, NewInode("6", cnf.Addr)); err == nil {
+			t.Errorf("Notify() accepted a peer claiming an identity its certificate lacks")
+		}
+	})
+
+	t.Run("another node's address", func(t *testing.T) {
+		// A valid certificate for an id that would become first's
+		// predecessor, claimed at second's address.
+		first.predMtx.RLock()
+		pred := first.predecessor
+		first.predMtx.RUnlock()
+		name := ""
+		for i := 0; name == ""; i++ {
+			candidate := fmt.Sprintf("r%d", i)
+			if between(NewInode(candidate, "").Id, pred.Id, first.Id) {
+				name = candidate
+			}
+		}
+		cnf := tlsConfig(t, ca, ca, name, name)
+		transport, err := NewGrpcTransport(cnf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer transport.Stop()
+		if err := transport.Notify(first.Node, NewInode(name, second.Addr)); err == nil {
+			t.Errorf("Notify() accepted %s at the address of node 2", name)
+		}
+		first.predMtx.RLock()
+		got := first.predecessor
+		first.predMtx.RUnlock()
+		if got.Addr != pred.Addr {
+			t.Errorf("predecessor changed to %v, want %v", got, pred)
+		}
+	})
+}
+
+func Test_loadNodeTLS_clientOnly(t *testing.T) {
+	cnf := BaseConfig()
+	cnf.TLSClientCertFile = "client.crt"
+	if _, err := loadNodeTLS(cnf); err != ERR_TLS_CLIENT_ONLY {
+		t.Errorf("loadNodeTLS() error = %v, want %v", err, ERR_TLS_CLIENT_ONLY)
+	}
+}
@@ -0,0 +1,17 @@
+package boopy
+
+import "time"
+
+// purgeTombstones drops tombstones older than TombstoneGracePeriod. By then
+// the delete has reached every replica, or anti-entropy has carried it.
+func (n *Node) purgeTombstones() {
+	if n.cnf.TombstoneGracePeriod <= 0 {
+		return
+	}
+	n.stMtx.Lock()
+	purged := n.storage.Purge(time.Now().Add(-n.cnf.TombstoneGracePeriod))
+	n.stMtx.Unlock()
+	if purged > 0 {
+		n.metrics.add("tombstones_purged", uint64(purged))
+	}
+}
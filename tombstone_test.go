@@ -0,0 +1,100 @@
+package boopy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+func TestNode_tombstones(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	if err := nodes[0].CreateNamespace(&api.Namespace{Name: "rep", ReplicationFactor: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[0].SetIn("rep", "apple", "v", 0); err != nil {
+		t.Fatal(err)
+	}
+	owner := ringOwner(nodes, namespacedKey("rep", "apple"))
+	var stale *Node
+	for _, node := range nodes {
+		waitValue(t, node, "rep", "apple", "v")
+		if !bytesEqual(node.Id, owner.Id) {
+			stale = node
+		}
+	}
+	stale.stMtx.RLock()
+	old, _ := stale.storage.Lookup("rep", "apple")
+	stale.stMtx.RUnlock()
+
+	if err := nodes[1].DeleteIn("rep", "apple"); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		node.stMtx.RLock()
+		kv, err := node.storage.Lookup("rep", "apple")
+		node.stMtx.RUnlock()
+		if err != nil || !kv.Deleted || kv.Version <= old.Version {
+			t.Fatalf("%s holds %v, %v, want a newer tombstone", node.Addr, kv, err)
+		}
+	}
+
+	// A late copy of the old write must not bring the key back.
+	if _, err := stale.XStoreKeys(context.Background(), &api.StoreKeysRequest{Values: []*api.KV{old}}); err != nil {
+		t.Fatal(err)
+	}
+	stale.stMtx.RLock()
+	_, err := stale.storage.Get("rep", "apple")
+	stale.stMtx.RUnlock()
+	if err != ERR_KEY_NOT_FOUND {
+		t.Errorf("old write replayed over the tombstone, Get() error = %v", err)
+	}
+
+	// A replica that missed the delete is repaired rather than resurrecting it.
+	stale.stMtx.Lock()
+	stale.storage.Set("rep", "apple", old.Value, time.Time{}, old.Version)
+	stale.stMtx.Unlock()
+	if _, err := nodes[2].GetQuorum("rep", "apple"); err != ERR_KEY_NOT_FOUND {
+		t.Errorf("GetQuorum() error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+	stale.stMtx.Lock()
+	stale.storage.Set("rep", "apple", old.Value, time.Time{}, old.Version)
+	stale.stMtx.Unlock()
+	for _, node := range nodes {
+		node.antiEntropy()
+	}
+	for _, node := range nodes {
+		node.stMtx.RLock()
+		kv, err := node.storage.Lookup("rep", "apple")
+		node.stMtx.RUnlock()
+		if err != nil || !kv.Deleted {
+			t.Errorf("%s holds %v, %v after anti-entropy, want the tombstone", node.Addr, kv, err)
+		}
+	}
+}
+
+func TestNode_purgeTombstones(t *testing.T) {
+	nodes := newTestRing(t, 1, func(cnf *Config) {
+		cnf.TombstoneGracePeriod = time.Millisecond
+	})
+	node := nodes[0]
+	if err := node.Set("key", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	node.purgeTombstones()
+
+	node.stMtx.RLock()
+	_, err := node.storage.Lookup("", "key")
+	node.stMtx.RUnlock()
+	if err != ERR_KEY_NOT_FOUND {
+		t.Errorf("Lookup() after purge error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+	if got := node.Metrics()["tombstones_purged"]; got != 1 {
+		t.Errorf("tombstones_purged = %d, want 1", got)
+	}
+}
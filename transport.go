@@ -2,7 +2,7 @@ package boopy
 
 import (
 	"errors"
-	"fmt"
+	"io"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -13,6 +13,8 @@ import (
 	"google.golang.org/grpc"
 )
 
+var ERR_TRANSPORT_SHUTDOWN = errors.New("TCP transport is shutdown")
+
 var (
 	emptyNode                = &api.Node{}
 	emptyRequest             = &api.ER{}
@@ -36,19 +38,42 @@ type Transport interface {
 
 	//RPC
 	GetSuccessor(*api.Node) (*api.Node, error)
-	FindSuccessor(*api.Node, []byte) (*api.Node, error)
-	SetSuccessor(*api.Node, *api.Node) error
+	FindSuccessor(*api.Node, *api.ID) (*api.FindSuccessorResponse, error)
+	ClosestPrecedingNode(*api.Node, *api.ClosestPrecedingRequest) (*api.ClosestPrecedingResponse, error)
+	SetSuccessor(*api.Node, *api.NeighbourRequest) error
 	GetPredecessor(*api.Node) (*api.Node, error)
 	CheckPredecessor(*api.Node) error
-	SetPredecessor(*api.Node, *api.Node) error
+	SetPredecessor(*api.Node, *api.NeighbourRequest) error
 	Notify(*api.Node, *api.Node) error
+	Probe(*api.Node) (*api.ProbeResponse, error)
 
 	//Storage
-	GetKey(*api.Node, string) (*api.GetResponse, error)
-	SetKey(*api.Node, string, string) error
-	DeleteKey(*api.Node, string) error
+	GetKey(*api.Node, *api.GetRequest) (*api.GetResponse, error)
+	SetKey(*api.Node, *api.SetRequest) error
+	DeleteKey(*api.Node, *api.DeleteRequest) error
 	RequestKeys(*api.Node, []byte, []byte) ([]*api.KV, error)
-	DeleteKeys(*api.Node, []string) error
+	DeleteKeys(*api.Node, string, []string) error
+	StoreKeys(*api.Node, *api.StoreKeysRequest) error
+	Scan(*api.Node, *api.ScanRequest) ([]*api.KV, error)
+	BatchGet(*api.Node, *api.BatchRequest) (*api.BatchResponse, error)
+	BatchSet(*api.Node, *api.BatchRequest) (*api.BatchResponse, error)
+	BatchDelete(*api.Node, *api.BatchRequest) (*api.BatchResponse, error)
+	Watch(context.Context, *api.Node, *api.WatchRequest) (api.Chord_XWatchClient, error)
+	Merkle(*api.Node, *api.MerkleRequest) (*api.MerkleResponse, error)
+	Update(*api.Node, *api.UpdateRequest) (*api.UpdateResponse, error)
+
+	//Transactions
+	Prepare(*api.Node, *api.TxnRequest) error
+	CommitTxn(*api.Node, *api.TxnRequest) error
+	AbortTxn(*api.Node, *api.TxnRequest) error
+	Decide(*api.Node, *api.TxnRecord) (*api.TxnRecord, error)
+
+	//Leases
+	Lease(*api.Node, *api.LeaseRequest) (*api.Lease, error)
+
+	//Namespaces
+	DropNamespace(*api.Node, *api.NamespaceRequest) error
+	ListNamespaces(*api.Node) ([]*api.Namespace, error)
 }
 
 type GrpcTransport struct {
@@ -64,6 +89,10 @@ type GrpcTransport struct {
 
 	server *grpc.Server
 
+	tls *nodeTLS // nil when connections are plaintext
+
+	clock *hlc // advanced by every RPC sent or served
+
 	shutdown int32
 }
 
@@ -86,9 +115,24 @@ func NewGrpcTransport(config *Config) (*GrpcTransport, error) {
 		maxIdle: config.MaxIdleDuration,
 		pool:    pool,
 		config:  config,
+		clock:   newHLC(config.MaxClockDrift),
 	}
 
-	grp.server = grpc.NewServer(config.ServerOpts...)
+	serverOpts := append([]grpc.ServerOption{}, config.ServerOpts...)
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(grp.clock.unaryServerInterceptor),
+		grpc.ChainStreamInterceptor(grp.clock.streamServerInterceptor),
+	)
+	if config.tlsEnabled() {
+		grp.tls, err = loadNodeTLS(config)
+		if err != nil {
+			tcpListener.Close()
+			return nil, err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(grp.tls.serverCredentials()))
+	}
+
+	grp.server = grpc.NewServer(serverOpts...)
 
 	// Done
 	return grp, nil
@@ -96,6 +140,7 @@ func NewGrpcTransport(config *Config) (*GrpcTransport, error) {
 
 type grpcConn struct {
 	addr       string
+	id         []byte // node verified when dialing over TLS
 	client     api.ChordClient
 	conn       *grpc.ClientConn
 	lastActive time.Time
@@ -115,31 +160,47 @@ func (gt *GrpcTransport) GetServer() *grpc.Server {
 
 // Gets an outbound connection to a host
 func (gt *GrpcTransport) getConn(
-	addr string,
+	node *api.Node,
 ) (api.ChordClient, error) {
+	addr := node.Addr
 
 	gt.poolMtx.RLock()
 
 	if atomic.LoadInt32(&gt.shutdown) == 1 {
-		gt.poolMtx.Unlock()
-		return nil, fmt.Errorf("TCP transport is shutdown")
+		gt.poolMtx.RUnlock()
+		return nil, ERR_TRANSPORT_SHUTDOWN
 	}
 
 	cc, ok := gt.pool[addr]
 	gt.poolMtx.RUnlock()
 	if ok {
+		// Over TLS the connection only vouches for the node it was dialed for.
+		if gt.tls != nil && !bytesEqual(cc.id, node.Id) {
+			return nil, ERR_PEER_IDENTITY
+		}
 		return cc.client, nil
 	}
 
+	opts := append([]grpc.DialOption{}, gt.config.DialOpts...)
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(gt.clock.unaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(gt.clock.streamClientInterceptor),
+	)
+	if gt.tls != nil {
+		opts = append(opts, grpc.WithTransportCredentials(gt.tls.clientCredentials(node)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
 	var conn *grpc.ClientConn
 	var err error
-	conn, err = Dial(addr, gt.config.DialOpts...)
+	conn, err = Dial(addr, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	client := api.NewChordClient(conn)
-	cc = &grpcConn{addr, client, conn, time.Now()}
+	cc = &grpcConn{addr, node.Id, client, conn, time.Now()}
 	gt.poolMtx.Lock()
 	if gt.pool != nil {
 		gt.pool[addr] = cc
@@ -226,7 +287,7 @@ func (gt *GrpcTransport) listen() {
 
 // GetSuccessor the successor ID of a remote node.
 func (gt *GrpcTransport) GetSuccessor(node *api.Node) (*api.Node, error) {
-	client, err := gt.getConn(node.Addr)
+	client, err := gt.getConn(node)
 	if err != nil {
 		return nil, err
 	}
@@ -237,21 +298,39 @@ func (gt *GrpcTransport) GetSuccessor(node *api.Node) (*api.Node, error) {
 }
 
 // FindSuccessor the successor ID of a remote node.
-func (gt *GrpcTransport) FindSuccessor(node *api.Node, id []byte) (*api.Node, error) {
+func (gt *GrpcTransport) FindSuccessor(node *api.Node, id *api.ID) (*api.FindSuccessorResponse, error) {
 	// fmt.Println("yo", node.Id, id)
-	client, err := gt.getConn(node.Addr)
+	client, err := gt.getConn(node)
 	if err != nil {
 		return nil, err
 	}
 
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
-	return client.FindSuccessor(conntx, &api.ID{Id: id})
+	res, err := client.FindSuccessor(conntx, id)
+	if err != nil {
+		return nil, lookupErrorFromStatus(err)
+	}
+	return res, nil
+}
+
+// ClosestPrecedingNode asks a remote node for its closest preceding finger.
+func (gt *GrpcTransport) ClosestPrecedingNode(
+	node *api.Node, req *api.ClosestPrecedingRequest,
+) (*api.ClosestPrecedingResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.ClosestPrecedingNode(conntx, req)
 }
 
 // GetPredecessor the successor ID of a remote node.
 func (gt *GrpcTransport) GetPredecessor(node *api.Node) (*api.Node, error) {
-	client, err := gt.getConn(node.Addr)
+	client, err := gt.getConn(node)
 	if err != nil {
 		return nil, err
 	}
@@ -261,32 +340,32 @@ func (gt *GrpcTransport) GetPredecessor(node *api.Node) (*api.Node, error) {
 	return client.GetPredecessor(conntx, emptyRequest)
 }
 
-func (gt *GrpcTransport) SetPredecessor(node *api.Node, predecessor *api.Node) error {
-	client, err := gt.getConn(node.Addr)
+func (gt *GrpcTransport) SetPredecessor(node *api.Node, req *api.NeighbourRequest) error {
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
 
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
-	_, err = client.SetPredecessor(conntx, predecessor)
+	_, err = client.SetPredecessor(conntx, req)
 	return err
 }
 
-func (gt *GrpcTransport) SetSuccessor(node *api.Node, succ *api.Node) error {
-	client, err := gt.getConn(node.Addr)
+func (gt *GrpcTransport) SetSuccessor(node *api.Node, req *api.NeighbourRequest) error {
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
 
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
-	_, err = client.SetSuccessor(conntx, succ)
+	_, err = client.SetSuccessor(conntx, req)
 	return err
 }
 
 func (gt *GrpcTransport) Notify(node, predecessor *api.Node) error {
-	client, err := gt.getConn(node.Addr)
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
@@ -298,8 +377,20 @@ func (gt *GrpcTransport) Notify(node, predecessor *api.Node) error {
 
 }
 
+// Probe asks a remote node for its identity and neighbours.
+func (gt *GrpcTransport) Probe(node *api.Node) (*api.ProbeResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.Probe(conntx, emptyRequest)
+}
+
 func (gt *GrpcTransport) CheckPredecessor(node *api.Node) error {
-	client, err := gt.getConn(node.Addr)
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
@@ -310,43 +401,43 @@ func (gt *GrpcTransport) CheckPredecessor(node *api.Node) error {
 	return err
 }
 
-func (gt *GrpcTransport) GetKey(node *api.Node, key string) (*api.GetResponse, error) {
-	client, err := gt.getConn(node.Addr)
+func (gt *GrpcTransport) GetKey(node *api.Node, req *api.GetRequest) (*api.GetResponse, error) {
+	client, err := gt.getConn(node)
 	if err != nil {
 		return nil, err
 	}
 
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
-	return client.XGet(conntx, &api.GetRequest{Key: key})
+	return client.XGet(conntx, req)
 }
 
-func (gt *GrpcTransport) SetKey(node *api.Node, key, value string) error {
-	client, err := gt.getConn(node.Addr)
+func (gt *GrpcTransport) SetKey(node *api.Node, req *api.SetRequest) error {
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
 
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
-	_, err = client.XSet(conntx, &api.SetRequest{Key: key, Value: value})
+	_, err = client.XSet(conntx, req)
 	return err
 }
 
-func (gt *GrpcTransport) DeleteKey(node *api.Node, key string) error {
-	client, err := gt.getConn(node.Addr)
+func (gt *GrpcTransport) DeleteKey(node *api.Node, req *api.DeleteRequest) error {
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
 
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
-	_, err = client.XDelete(conntx, &api.DeleteRequest{Key: key})
+	_, err = client.XDelete(conntx, req)
 	return err
 }
 
 func (gt *GrpcTransport) RequestKeys(node *api.Node, from, to []byte) ([]*api.KV, error) {
-	client, err := gt.getConn(node.Addr)
+	client, err := gt.getConn(node)
 	if err != nil {
 		return nil, err
 	}
@@ -362,8 +453,8 @@ func (gt *GrpcTransport) RequestKeys(node *api.Node, from, to []byte) ([]*api.KV
 	return val.Values, nil
 }
 
-func (gt *GrpcTransport) DeleteKeys(node *api.Node, keys []string) error {
-	client, err := gt.getConn(node.Addr)
+func (gt *GrpcTransport) DeleteKeys(node *api.Node, ns string, keys []string) error {
+	client, err := gt.getConn(node)
 	if err != nil {
 		return err
 	}
@@ -371,7 +462,195 @@ func (gt *GrpcTransport) DeleteKeys(node *api.Node, keys []string) error {
 	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
 	defer cancel()
 	_, err = client.XMultiDelete(
-		conntx, &api.MultiDeleteRequest{Keys: keys},
+		conntx, &api.MultiDeleteRequest{Namespace: ns, Keys: keys},
 	)
 	return err
 }
+
+func (gt *GrpcTransport) StoreKeys(node *api.Node, req *api.StoreKeysRequest) error {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	_, err = client.XStoreKeys(conntx, req)
+	return err
+}
+
+func (gt *GrpcTransport) DropNamespace(node *api.Node, req *api.NamespaceRequest) error {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	_, err = client.XDropNamespace(conntx, req)
+	return err
+}
+
+func (gt *GrpcTransport) ListNamespaces(node *api.Node) ([]*api.Namespace, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	res, err := client.XListNamespaces(conntx, emptyRequest)
+	if err != nil {
+		return nil, err
+	}
+	return res.Namespaces, nil
+}
+
+// Scan reads the keys streamed back by a remote node's XScan.
+func (gt *GrpcTransport) Scan(node *api.Node, req *api.ScanRequest) ([]*api.KV, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	stream, err := client.XScan(conntx, req)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*api.KV, 0, req.Limit)
+	for {
+		kv, err := stream.Recv()
+		if err == io.EOF {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, kv)
+	}
+}
+
+// Watch opens a stream of changes on a remote node. The stream has no
+// timeout and lasts until ctx is cancelled.
+func (gt *GrpcTransport) Watch(ctx context.Context, node *api.Node, req *api.WatchRequest) (api.Chord_XWatchClient, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+	return client.XWatch(ctx, req)
+}
+
+func (gt *GrpcTransport) Merkle(node *api.Node, req *api.MerkleRequest) (*api.MerkleResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XMerkle(conntx, req)
+}
+
+func (gt *GrpcTransport) Update(node *api.Node, req *api.UpdateRequest) (*api.UpdateResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XUpdate(conntx, req)
+}
+
+func (gt *GrpcTransport) Prepare(node *api.Node, req *api.TxnRequest) error {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	_, err = client.XPrepare(conntx, req)
+	return err
+}
+
+func (gt *GrpcTransport) CommitTxn(node *api.Node, req *api.TxnRequest) error {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	_, err = client.XCommitTxn(conntx, req)
+	return err
+}
+
+func (gt *GrpcTransport) AbortTxn(node *api.Node, req *api.TxnRequest) error {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	_, err = client.XAbortTxn(conntx, req)
+	return err
+}
+
+func (gt *GrpcTransport) Decide(node *api.Node, req *api.TxnRecord) (*api.TxnRecord, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XDecide(conntx, req)
+}
+
+func (gt *GrpcTransport) Lease(node *api.Node, req *api.LeaseRequest) (*api.Lease, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XLease(conntx, req)
+}
+
+func (gt *GrpcTransport) BatchGet(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XBatchGet(conntx, req)
+}
+
+func (gt *GrpcTransport) BatchSet(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XBatchSet(conntx, req)
+}
+
+func (gt *GrpcTransport) BatchDelete(node *api.Node, req *api.BatchRequest) (*api.BatchResponse, error) {
+	client, err := gt.getConn(node)
+	if err != nil {
+		return nil, err
+	}
+
+	conntx, cancel := context.WithTimeout(context.Background(), gt.timeout)
+	defer cancel()
+	return client.XBatchDelete(conntx, req)
+}
@@ -0,0 +1,554 @@
+package boopy
+
+import (
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ERR_TXN_CONFLICT = errors.New("transaction conflicts with another write")
+	ERR_TXN_ABORTED  = errors.New("transaction aborted")
+	ERR_TXN_IN_DOUBT = errors.New("transaction outcome unknown, its participants settle it with the coordinator record")
+	ERR_TXN_DONE     = errors.New("transaction already committed or aborted")
+	ERR_TXN_SIBLINGS = errors.New("transactions cannot write namespaces that keep siblings")
+	ERR_KEY_LOCKED   = errors.New("key is locked by a transaction")
+)
+
+// Transactions commit in two phases. The node running Commit coordinates:
+// it asks the owner of every key to prepare, which locks the key and checks
+// that what the transaction read is unchanged, then records the outcome in
+// the coordinator record and tells the owners to apply or drop the writes.
+//
+// Coordinator records live in the reserved txnNamespace, one key per
+// transaction, and are replicated like namespace definitions. Once a record
+// says COMMITTED or ABORTED it never changes, so owners left holding locks by
+// a coordinator that died settle the transaction themselves: after
+// TxnLockTimeout they record ABORTED, which only sticks if the coordinator
+// had not committed first, and follow whatever the record then says.
+const txnNamespace = "_transactions"
+
+var txnSettings = &api.Namespace{Name: txnNamespace, ReplicationFactor: 3}
+
+// txnTable holds the transactions prepared on this node and the keys they
+// lock. Guarded by stMtx.
+type txnTable struct {
+	locks    map[string]string // namespaced key -> transaction id
+	prepared map[string]*preparedTxn
+}
+
+type preparedTxn struct {
+	req      *api.TxnRequest
+	deadline time.Time
+}
+
+func newTxnTable() *txnTable {
+	return &txnTable{locks: make(map[string]string), prepared: make(map[string]*preparedTxn)}
+}
+
+// txnKeys returns every key a transaction touches, namespaced.
+func txnKeys(req *api.TxnRequest) []string {
+	keys := make([]string, 0, len(req.Writes)+len(req.Reads))
+	for _, kv := range req.Writes {
+		keys = append(keys, namespacedKey(kv.Namespace, kv.Key))
+	}
+	for _, kv := range req.Reads {
+		keys = append(keys, namespacedKey(kv.Namespace, kv.Key))
+	}
+	return keys
+}
+
+// check fails with ERR_KEY_LOCKED if a prepared transaction holds key.
+func (t *txnTable) check(ns, key string) error {
+	if _, ok := t.locks[namespacedKey(ns, key)]; ok {
+		return ERR_KEY_LOCKED
+	}
+	return nil
+}
+
+func (t *txnTable) prepare(req *api.TxnRequest, deadline time.Time) {
+	for _, key := range txnKeys(req) {
+		t.locks[key] = req.Id
+	}
+	t.prepared[req.Id] = &preparedTxn{req: req, deadline: deadline}
+}
+
+// release forgets a prepared transaction and unlocks its keys. Returns nil
+// if id is not prepared here.
+func (t *txnTable) release(id string) *api.TxnRequest {
+	p, ok := t.prepared[id]
+	if !ok {
+		return nil
+	}
+	for _, key := range txnKeys(p.req) {
+		if t.locks[key] == id {
+			delete(t.locks, key)
+		}
+	}
+	delete(t.prepared, id)
+	return p.req
+}
+
+// expired returns the transactions whose locks timed out before now.
+func (t *txnTable) expired(now time.Time) []string {
+	var ids []string
+	for id, p := range t.prepared {
+		if now.After(p.deadline) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Txn is a transaction over keys of any namespaces, started with Begin.
+// Reads go to the owners of the keys as they happen and writes are buffered
+// until Commit. A Txn is not safe for concurrent use.
+type Txn struct {
+	node   *Node
+	id     string
+	reads  map[string]*api.KV
+	writes map[string]*api.KV
+	done   bool
+}
+
+// Begin starts a transaction coordinated by this node.
+func (n *Node) Begin() *Txn {
+	return &Txn{
+		node:   n,
+		id:     hex.EncodeToString(n.Id) + "-" + strconv.FormatInt(n.clock.now(), 16),
+		reads:  make(map[string]*api.KV),
+		writes: make(map[string]*api.KV),
+	}
+}
+
+// Get reads a key, seeing the transaction's own writes. Commit fails if the
+// key changes before the transaction commits.
+func (t *Txn) Get(ns, key string) ([]byte, error) {
+	if t.done {
+		return nil, ERR_TXN_DONE
+	}
+	id := namespacedKey(ns, key)
+	if kv, ok := t.writes[id]; ok {
+		if kv.Deleted {
+			return nil, ERR_KEY_NOT_FOUND
+		}
+		return []byte(kv.Value), nil
+	}
+
+	res, err := t.node.lookup(ns, key)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	var version int64
+	if err == nil {
+		version = res.Version
+	}
+	if read, ok := t.reads[id]; !ok {
+		t.reads[id] = &api.KV{Namespace: ns, Key: key, Version: version}
+	} else if read.Version != version {
+		return nil, ERR_TXN_CONFLICT
+	}
+	if err != nil {
+		return nil, ERR_KEY_NOT_FOUND
+	}
+	if len(res.Siblings) > 1 {
+		return nil, ERR_SIBLINGS
+	}
+	return res.Value, nil
+}
+
+// Set writes a key when the transaction commits. Keys are written with
+// their namespace's default ttl.
+func (t *Txn) Set(ns, key, value string) error {
+	if t.done {
+		return ERR_TXN_DONE
+	}
+	t.writes[namespacedKey(ns, key)] = &api.KV{Namespace: ns, Key: key, Value: value}
+	return nil
+}
+
+// Delete removes a key when the transaction commits.
+func (t *Txn) Delete(ns, key string) error {
+	if t.done {
+		return ERR_TXN_DONE
+	}
+	t.writes[namespacedKey(ns, key)] = &api.KV{Namespace: ns, Key: key, Deleted: true}
+	return nil
+}
+
+// Abort drops the transaction. Nothing is sent to other nodes before
+// Commit, so there is nothing to undo.
+func (t *Txn) Abort() {
+	t.done = true
+}
+
+// Commit applies every write of the transaction, or none of them. It fails
+// with ERR_TXN_CONFLICT if another transaction holds one of the keys or a
+// key read has changed since, and with ERR_TXN_IN_DOUBT if the coordinator
+// record could not be written; the transaction may then still commit.
+func (t *Txn) Commit() error {
+	if t.done {
+		return ERR_TXN_DONE
+	}
+	t.done = true
+	if len(t.writes) == 0 && len(t.reads) == 0 {
+		return nil
+	}
+	n := t.node
+
+	reqs := make(map[string]*api.TxnRequest)
+	nodes := make(map[string]*api.Node)
+	participant := func(kv *api.KV) (*api.TxnRequest, error) {
+		owner, err := n.locate(namespacedKey(kv.Namespace, kv.Key))
+		if err != nil {
+			return nil, err
+		}
+		if reqs[owner.Addr] == nil {
+			reqs[owner.Addr] = &api.TxnRequest{Id: t.id}
+			nodes[owner.Addr] = owner
+		}
+		return reqs[owner.Addr], nil
+	}
+	for _, kv := range t.writes {
+		req, err := participant(kv)
+		if err != nil {
+			return err
+		}
+		req.Writes = append(req.Writes, kv)
+	}
+	for _, kv := range t.reads {
+		req, err := participant(kv)
+		if err != nil {
+			return err
+		}
+		req.Reads = append(req.Reads, kv)
+	}
+
+	record := &api.TxnRecord{Id: t.id}
+	for _, node := range nodes {
+		record.Participants = append(record.Participants, node)
+	}
+	if _, err := n.decide(record); err != nil {
+		return err
+	}
+
+	if err := n.eachParticipant(nodes, func(node *api.Node) error {
+		return n.prepareRPC(node, reqs[node.Addr])
+	}); err != nil {
+		n.settle(t.id, api.TxnRecord_ABORTED, nodes)
+		if status.Convert(err).Message() == ERR_TXN_CONFLICT.Error() {
+			err = ERR_TXN_CONFLICT
+		}
+		return err
+	}
+
+	state, err := n.settle(t.id, api.TxnRecord_COMMITTED, nodes)
+	switch {
+	case err != nil:
+		return ERR_TXN_IN_DOUBT
+	case state != api.TxnRecord_COMMITTED:
+		return ERR_TXN_ABORTED
+	}
+	return nil
+}
+
+// eachParticipant runs call against every node in parallel and returns the
+// first error.
+func (n *Node) eachParticipant(nodes map[string]*api.Node, call func(*api.Node) error) error {
+	var (
+		wg    sync.WaitGroup
+		mtx   sync.Mutex
+		first error
+	)
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *api.Node) {
+			defer wg.Done()
+			if err := call(node); err != nil {
+				if redirectOwner(err) != nil {
+					n.forgetLocation(node)
+				}
+				mtx.Lock()
+				if first == nil {
+					first = err
+				}
+				mtx.Unlock()
+			}
+		}(node)
+	}
+	wg.Wait()
+	return first
+}
+
+// settle records state as the outcome of transaction id, unless another
+// was recorded first, and tells the participants the outcome that stuck.
+// Participants that miss it settle the transaction once their locks time
+// out.
+func (n *Node) settle(id string, state api.TxnRecord_State, nodes map[string]*api.Node) (api.TxnRecord_State, error) {
+	record, err := n.decide(&api.TxnRecord{Id: id, State: state})
+	if err != nil {
+		return state, err
+	}
+	req := &api.TxnRequest{Id: id}
+	n.eachParticipant(nodes, func(node *api.Node) error {
+		var err error
+		if record.State == api.TxnRecord_COMMITTED {
+			err = n.commitTxnRPC(node, req)
+		} else {
+			err = n.abortTxnRPC(node, req)
+		}
+		if err != nil {
+			log.Println("error settling transaction: ", id, node.Addr, err)
+		}
+		return err
+	})
+	if record.State == api.TxnRecord_COMMITTED {
+		n.metrics.inc("transactions_committed")
+	} else {
+		n.metrics.inc("transactions_aborted")
+	}
+	return record.State, nil
+}
+
+// decide writes a coordinator record through the owner of its key.
+func (n *Node) decide(record *api.TxnRecord) (*api.TxnRecord, error) {
+	node, err := n.locate(namespacedKey(txnNamespace, record.Id))
+	if err != nil {
+		return nil, err
+	}
+	var stored *api.TxnRecord
+	err = n.followRedirects(node, func(node *api.Node) error {
+		stored, err = n.decideRPC(node, record)
+		return err
+	})
+	return stored, err
+}
+
+// settleExpired settles the transactions prepared here whose locks timed
+// out, following the coordinator record. Transactions whose record cannot
+// be reached keep their locks until the next try.
+func (n *Node) settleExpired() {
+	n.stMtx.RLock()
+	ids := n.txns.expired(time.Now())
+	n.stMtx.RUnlock()
+
+	for _, id := range ids {
+		record, err := n.decide(&api.TxnRecord{Id: id, State: api.TxnRecord_ABORTED})
+		if err != nil {
+			log.Println("error settling transaction: ", id, err)
+			continue
+		}
+		req := &api.TxnRequest{Id: id}
+		if record.State == api.TxnRecord_COMMITTED {
+			_, err = n.XCommitTxn(context.Background(), req)
+		} else {
+			_, err = n.XAbortTxn(context.Background(), req)
+		}
+		if err != nil {
+			log.Println("error settling transaction: ", id, err)
+			continue
+		}
+		n.metrics.inc("transactions_recovered")
+	}
+}
+
+func (n *Node) prepareRPC(node *api.Node, req *api.TxnRequest) error {
+	if bytesEqual(node.Id, n.Id) {
+		_, err := n.XPrepare(context.Background(), req)
+		return err
+	}
+	return n.transport.Prepare(node, req)
+}
+
+func (n *Node) commitTxnRPC(node *api.Node, req *api.TxnRequest) error {
+	if bytesEqual(node.Id, n.Id) {
+		_, err := n.XCommitTxn(context.Background(), req)
+		return err
+	}
+	return n.transport.CommitTxn(node, req)
+}
+
+func (n *Node) abortTxnRPC(node *api.Node, req *api.TxnRequest) error {
+	if bytesEqual(node.Id, n.Id) {
+		_, err := n.XAbortTxn(context.Background(), req)
+		return err
+	}
+	return n.transport.AbortTxn(node, req)
+}
+
+func (n *Node) decideRPC(node *api.Node, record *api.TxnRecord) (*api.TxnRecord, error) {
+	if bytesEqual(node.Id, n.Id) {
+		return n.XDecide(context.Background(), record)
+	}
+	return n.transport.Decide(node, record)
+}
+
+// txnNamespaces returns the settings of the namespaces written by req.
+func (n *Node) txnNamespaces(req *api.TxnRequest) (map[string]*api.Namespace, error) {
+	settings := make(map[string]*api.Namespace)
+	for _, kv := range req.Writes {
+		if _, ok := settings[kv.Namespace]; ok {
+			continue
+		}
+		ns, err := n.namespaceSettings(kv.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if ns.Siblings {
+			return nil, ERR_TXN_SIBLINGS
+		}
+		settings[kv.Namespace] = ns
+	}
+	return settings, nil
+}
+
+func (n *Node) XPrepare(ctx context.Context, req *api.TxnRequest) (*api.ER, error) {
+	// Keys are never forwarded: the coordinator regroups them by owner.
+	for _, key := range txnKeys(req) {
+		if _, err := n.misrouted(key, uint32(n.cnf.MaxForwardHops)); err != nil {
+			return emptyRequest, err
+		}
+	}
+	settings, err := n.txnNamespaces(req)
+	if err != nil {
+		return emptyRequest, err
+	}
+
+	n.stMtx.Lock()
+	defer n.stMtx.Unlock()
+	if _, ok := n.txns.prepared[req.Id]; ok {
+		return emptyRequest, nil
+	}
+	for _, key := range txnKeys(req) {
+		if holder, ok := n.txns.locks[key]; ok && holder != req.Id {
+			return emptyRequest, ERR_TXN_CONFLICT
+		}
+	}
+	for _, read := range req.Reads {
+		var version int64
+		if kv, err := n.storage.Lookup(read.Namespace, read.Key); err == nil && !kv.Deleted {
+			version = kv.Version
+		}
+		if version != read.Version {
+			return emptyRequest, ERR_TXN_CONFLICT
+		}
+	}
+	for _, kv := range req.Writes {
+		if kv.Deleted {
+			continue
+		}
+		if err := n.checkQuota(settings[kv.Namespace], kv.Key, kv.Value); err != nil {
+			return emptyRequest, err
+		}
+	}
+	n.txns.prepare(req, time.Now().Add(n.cnf.TxnLockTimeout))
+	return emptyRequest, nil
+}
+
+func (n *Node) XCommitTxn(ctx context.Context, req *api.TxnRequest) (*api.ER, error) {
+	n.stMtx.RLock()
+	p, ok := n.txns.prepared[req.Id]
+	n.stMtx.RUnlock()
+	if !ok {
+		return emptyRequest, nil
+	}
+	settings, err := n.txnNamespaces(p.req)
+	if err != nil {
+		return emptyRequest, err
+	}
+
+	written := make(map[string][]*api.KV)
+	n.stMtx.Lock()
+	prepared := n.txns.release(req.Id)
+	for _, write := range prepared.GetWrites() {
+		kv := &api.KV{Namespace: write.Namespace, Key: write.Key, Value: write.Value, Deleted: write.Deleted}
+		kv.Version = n.nextVersion(kv.Namespace, kv.Key)
+		if kv.Deleted {
+			if err := n.storage.Delete(kv.Namespace, kv.Key, kv.Version); err != nil {
+				log.Println("error committing transaction: ", req.Id, kv.Key, err)
+				continue
+			}
+			n.record(api.Change_DELETE, kv)
+			n.publishDelete(kv.Namespace, kv.Key)
+		} else {
+			expires := expiry(settings[kv.Namespace], 0)
+			if !expires.IsZero() {
+				kv.Expires = expires.UnixNano()
+			}
+			if err := n.storage.Set(kv.Namespace, kv.Key, kv.Value, expires, kv.Version); err != nil {
+				log.Println("error committing transaction: ", req.Id, kv.Key, err)
+				continue
+			}
+			n.record(api.Change_SET, kv)
+			n.publishSet(kv.Namespace, kv.Key, kv.Value)
+		}
+		written[kv.Namespace] = append(written[kv.Namespace], kv)
+	}
+	n.stMtx.Unlock()
+
+	for ns, kvs := range written {
+		n.replicate(settings[ns], kvs...)
+	}
+	return emptyRequest, nil
+}
+
+func (n *Node) XAbortTxn(ctx context.Context, req *api.TxnRequest) (*api.ER, error) {
+	n.stMtx.Lock()
+	n.txns.release(req.Id)
+	n.stMtx.Unlock()
+	return emptyRequest, nil
+}
+
+func (n *Node) XDecide(ctx context.Context, req *api.TxnRecord) (*api.TxnRecord, error) {
+	owner, err := n.misrouted(namespacedKey(txnNamespace, req.Id), req.Hops)
+	if err != nil {
+		return nil, err
+	}
+	if owner != nil {
+		forward := *req
+		forward.Hops++
+		return n.transport.Decide(owner, &forward)
+	}
+
+	record := &api.TxnRecord{Id: req.Id, State: req.State, Participants: req.Participants}
+	n.stMtx.Lock()
+	if old, err := n.storage.Lookup(txnNamespace, req.Id); err == nil && !old.Deleted {
+		stored := new(api.TxnRecord)
+		if err := proto.Unmarshal([]byte(old.Value), stored); err != nil {
+			n.stMtx.Unlock()
+			return nil, err
+		}
+		if stored.State != api.TxnRecord_PENDING || req.State == api.TxnRecord_PENDING {
+			n.stMtx.Unlock()
+			return stored, nil
+		}
+		record.Participants = stored.Participants
+	}
+	data, err := proto.Marshal(record)
+	if err != nil {
+		n.stMtx.Unlock()
+		return nil, err
+	}
+	kv := &api.KV{Namespace: txnNamespace, Key: req.Id, Value: string(data)}
+	kv.Version = n.nextVersion(txnNamespace, req.Id)
+	expires := time.Now().Add(n.cnf.TxnRecordRetention)
+	kv.Expires = expires.UnixNano()
+	err = n.storage.Set(txnNamespace, req.Id, kv.Value, expires, kv.Version)
+	if err == nil {
+		n.record(api.Change_SET, kv)
+	}
+	n.stMtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	n.replicate(txnSettings, kv)
+	return record, nil
+}
@@ -0,0 +1,147 @@
+package boopy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/status"
+)
+
+// splitKeys returns two keys of the default namespace held by different
+// nodes.
+func splitKeys(t *testing.T, nodes []*Node) (string, string) {
+	t.Helper()
+	first := ringOwner(nodes, "user1")
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("index%d", i)
+		if owner := ringOwner(nodes, key); owner != nil && !bytesEqual(owner.Id, first.Id) {
+			return "user1", key
+		}
+	}
+	t.Fatal("no keys on different owners")
+	return "", ""
+}
+
+func TestNode_Txn(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	user, index := splitKeys(t, nodes)
+
+	txn := nodes[0].Begin()
+	if _, err := txn.Get("", user); err != ERR_KEY_NOT_FOUND {
+		t.Fatalf("Get() of a new key error = %v, want %v", err, ERR_KEY_NOT_FOUND)
+	}
+	txn.Set("", user, "ann")
+	txn.Set("", index, user)
+	if val, err := txn.Get("", index); err != nil || string(val) != user {
+		t.Errorf("Get() of an own write = %q, %v", val, err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	for _, key := range []string{user, index} {
+		if _, err := nodes[1].Get(key); err != nil {
+			t.Errorf("Get(%q) after commit error = %v", key, err)
+		}
+	}
+	if err := txn.Commit(); err != ERR_TXN_DONE {
+		t.Errorf("second Commit() error = %v, want %v", err, ERR_TXN_DONE)
+	}
+
+	// A key read by the transaction changes before it commits.
+	txn = nodes[1].Begin()
+	if _, err := txn.Get("", user); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[2].Set(user, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	txn.Set("", user, "carl")
+	txn.Delete("", index)
+	if err := txn.Commit(); err != ERR_TXN_CONFLICT {
+		t.Errorf("Commit() of a stale read error = %v, want %v", err, ERR_TXN_CONFLICT)
+	}
+	if val, err := nodes[0].Get(user); err != nil || string(val) != "bob" {
+		t.Errorf("Get() after conflict = %q, %v, want bob", val, err)
+	}
+	if _, err := nodes[0].Get(index); err != nil {
+		t.Errorf("Get() of the other key after conflict error = %v", err)
+	}
+}
+
+func TestNode_TxnLocks(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	user, _ := splitKeys(t, nodes)
+	owner := ringOwner(nodes, user)
+
+	held := &api.TxnRequest{Id: "held", Writes: []*api.KV{{Key: user, Value: "x"}}}
+	if err := nodes[0].prepareRPC(owner, held); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[1].Set(user, "y"); status.Convert(err).Message() != ERR_KEY_LOCKED.Error() {
+		t.Errorf("Set() of a locked key error = %v, want %v", err, ERR_KEY_LOCKED)
+	}
+	txn := nodes[2].Begin()
+	txn.Set("", user, "z")
+	if err := txn.Commit(); err != ERR_TXN_CONFLICT {
+		t.Errorf("Commit() of a locked key error = %v, want %v", err, ERR_TXN_CONFLICT)
+	}
+
+	if err := nodes[0].abortTxnRPC(owner, held); err != nil {
+		t.Fatal(err)
+	}
+	if err := nodes[1].Set(user, "y"); err != nil {
+		t.Errorf("Set() after abort error = %v", err)
+	}
+}
+
+func TestNode_TxnRecovery(t *testing.T) {
+	nodes := newTestRing(t, 3, func(cnf *Config) {
+		cnf.TxnLockTimeout = time.Millisecond
+	})
+	user, index := splitKeys(t, nodes)
+	coordinator := nodes[0]
+
+	// prepare leaves a transaction prepared on every owner, as a coordinator
+	// that died before telling them the outcome would.
+	prepare := func(id string) {
+		for _, key := range []string{user, index} {
+			req := &api.TxnRequest{Id: id, Writes: []*api.KV{{Key: key, Value: id}}}
+			if err := coordinator.prepareRPC(ringOwner(nodes, key), req); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	settle := func() {
+		time.Sleep(5 * time.Millisecond)
+		for _, node := range nodes {
+			node.settleExpired()
+		}
+	}
+
+	// Died after recording the commit.
+	prepare("committed")
+	if record, err := coordinator.decide(&api.TxnRecord{Id: "committed", State: api.TxnRecord_COMMITTED}); err != nil || record.State != api.TxnRecord_COMMITTED {
+		t.Fatalf("decide() = %v, %v", record, err)
+	}
+	settle()
+	for _, key := range []string{user, index} {
+		if val, err := nodes[1].Get(key); err != nil || string(val) != "committed" {
+			t.Errorf("Get(%q) after recovery = %q, %v, want committed", key, val, err)
+		}
+	}
+
+	// Died before deciding: the participants abort and the coordinator can
+	// no longer commit.
+	prepare("lost")
+	settle()
+	for _, key := range []string{user, index} {
+		if val, err := nodes[1].Get(key); err != nil || string(val) != "committed" {
+			t.Errorf("Get(%q) after abort = %q, %v, want committed", key, val, err)
+		}
+	}
+	if record, err := coordinator.decide(&api.TxnRecord{Id: "lost", State: api.TxnRecord_COMMITTED}); err != nil || record.State != api.TxnRecord_ABORTED {
+		t.Errorf("decide() after recovery = %v, %v, want ABORTED", record, err)
+	}
+}
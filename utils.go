@@ -6,18 +6,71 @@ import (
 	"errors"
 	"math/rand"
 	"time"
+
+	"github.com/jseam2/boopy/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	ERR_NO_SUCCESSOR  = errors.New("cannot find successor")
-	ERR_NODE_EXISTS   = errors.New("node with id already exists")
-	ERR_KEY_NOT_FOUND = errors.New("key not found")
+	ERR_NO_SUCCESSOR       = errors.New("cannot find successor")
+	ERR_NODE_EXISTS        = errors.New("node with id already exists")
+	ERR_KEY_NOT_FOUND      = errors.New("key not found")
+	ERR_NOT_OWNER          = errors.New("key not owned by node")
+	ERR_TOO_MANY_REDIRECTS = errors.New("too many redirects")
 )
 
+// redirectError builds a gRPC error telling the caller that the key belongs
+// to owner. The owner travels as a status detail so it survives the wire.
+func redirectError(owner *api.Node) error {
+	st, err := status.New(codes.FailedPrecondition, ERR_NOT_OWNER.Error()).WithDetails(owner)
+	if err != nil {
+		return ERR_NOT_OWNER
+	}
+	return st.Err()
+}
+
+// redirectOwner extracts the owner from an error made by redirectError.
+// Returns nil for any other error.
+func redirectOwner(err error) *api.Node {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.FailedPrecondition {
+		return nil
+	}
+	for _, detail := range st.Details() {
+		if owner, ok := detail.(*api.Node); ok {
+			return owner
+		}
+	}
+	return nil
+}
+
+// isNotFound checks for ERR_KEY_NOT_FOUND, locally or from a remote node.
+func isNotFound(err error) bool {
+	return err != nil && status.Convert(err).Message() == ERR_KEY_NOT_FOUND.Error()
+}
+
+func isKeyLocked(err error) bool {
+	return err != nil && status.Convert(err).Message() == ERR_KEY_LOCKED.Error()
+}
+
 func bytesEqual(left, right []byte) bool {
 	return bytes.Compare(left, right) == 0
 }
 
+// containsID checks if id is one of ids
+func containsID(ids [][]byte, id []byte) bool {
+	for _, other := range ids {
+		if bytesEqual(other, id) {
+			return true
+		}
+	}
+	return false
+}
+
 func isPowerOfTwo(num int) bool {
 	nonZero := (num != 0)
 	evenBits := (num & (num - 1)) == 0
@@ -62,3 +115,15 @@ func GetHashID(key string) []byte {
 	val := h.Sum(nil)
 	return val
 }
+
+// nextID returns the ring position right after id, padded to size bytes.
+// An empty id stands for the start of the ring.
+func nextID(id []byte, size int) []byte {
+	next := make([]byte, size)
+	if len(id) == 0 {
+		return next
+	}
+	sum := fingerID(id, 0, size*8)
+	copy(next[size-len(sum):], sum)
+	return next
+}
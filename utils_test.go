@@ -3,6 +3,8 @@ package boopy
 import (
 	"testing"
 	"time"
+
+	"github.com/jseam2/boopy/api"
 )
 
 func Test_bytesEqual(t *testing.T) {
@@ -147,3 +149,27 @@ func Test_between(t *testing.T) {
 		})
 	}
 }
+
+func Test_redirectOwner(t *testing.T) {
+	owner := NewInode("1", "0.0.0.0:8001")
+	tests := []struct {
+		name string
+		err  error
+		want *api.Node
+	}{
+		{"nil", nil, nil},
+		{"plain", ERR_KEY_NOT_FOUND, nil},
+		{"redirect", redirectError(owner), owner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redirectOwner(tt.err)
+			if (got == nil) != (tt.want == nil) {
+				t.Fatalf("redirectOwner() = %v, want %v", got, tt.want)
+			}
+			if got != nil && (!bytesEqual(got.Id, tt.want.Id) || got.Addr != tt.want.Addr) {
+				t.Errorf("redirectOwner() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
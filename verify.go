@@ -0,0 +1,100 @@
+package boopy
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+var (
+	ERR_ID_ADDR_MISMATCH   = errors.New("node at address reports a different id")
+	ERR_NOT_NEIGHBOUR      = errors.New("caller is not the neighbour being replaced")
+	ERR_LEAVE_NOT_VERIFIED = errors.New("caller is not leaving the ring in favour of the new neighbour")
+	ERR_NOTIFY_NOT_PRED    = errors.New("notifying node does not consider us its successor")
+)
+
+// probeRPC asks a node who it is and who its neighbours are. Probing
+// ourselves skips the network.
+func (n *Node) probeRPC(node *api.Node) (*api.ProbeResponse, error) {
+	if bytesEqual(node.Id, n.Id) && node.Addr == n.Addr {
+		return n.Probe(context.Background(), emptyRequest)
+	}
+	return n.transport.Probe(node)
+}
+
+// verifyBinding checks that the node listening on node.Addr really has
+// node.Id, and returns what it reported.
+func (n *Node) verifyBinding(node *api.Node) (*api.ProbeResponse, error) {
+	res, err := n.probeRPC(node)
+	if err != nil {
+		return nil, err
+	}
+	if res.Node == nil || !bytesEqual(res.Node.Id, node.Id) {
+		return nil, ERR_ID_ADDR_MISMATCH
+	}
+	return res, nil
+}
+
+// verifyNotify checks that node, which claims to be our predecessor, exists
+// at its address and has us as its successor. Notify has already checked
+// with verifyPeer that the caller's certificate carries node.Id, and over TLS
+// the probe only reaches node.Addr if the certificate served there carries
+// it too, which binds the id to the address. Without TLS nothing
+// authenticates a node unknown to us, so rings exposed to untrusted networks
+// should run with TLS.
+func (n *Node) verifyNotify(node *api.Node) error {
+	res, err := n.verifyBinding(node)
+	if err != nil {
+		return err
+	}
+	if res.Successor == nil || !bytesEqual(res.Successor.Id, n.Id) {
+		return ERR_NOTIFY_NOT_PRED
+	}
+	return nil
+}
+
+// verifyLeave checks a request to replace the neighbour current with
+// req.Node. The caller must be current, at the address we know it by, must
+// be leaving, and must itself have req.Node as the neighbour on the same side
+// (picked by side). The address the caller gives is never probed: anyone can
+// claim current's id at an address they control.
+func (n *Node) verifyLeave(
+	ctx context.Context,
+	req *api.NeighbourRequest,
+	current *api.Node,
+	side func(*api.ProbeResponse) *api.Node,
+) error {
+	caller := req.Caller
+	if caller == nil || current == nil || req.Node == nil ||
+		!bytesEqual(caller.Id, current.Id) || caller.Addr != current.Addr {
+		return ERR_NOT_NEIGHBOUR
+	}
+	if err := n.verifyPeer(ctx, caller); err != nil {
+		return err
+	}
+
+	res, err := n.verifyBinding(current)
+	if err != nil {
+		return err
+	}
+	if !res.Leaving || side(res) == nil || !bytesEqual(side(res).Id, req.Node.Id) {
+		return ERR_LEAVE_NOT_VERIFIED
+	}
+
+	_, err = n.verifyBinding(req.Node)
+	return err
+}
+
+func (n *Node) isLeaving() bool {
+	return atomic.LoadInt32(&n.leaving) == 1
+}
+
+func probePredecessor(res *api.ProbeResponse) *api.Node {
+	return res.Predecessor
+}
+
+func probeSuccessor(res *api.ProbeResponse) *api.Node {
+	return res.Successor
+}
@@ -0,0 +1,150 @@
+package boopy
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jseam2/boopy/api"
+)
+
+func TestNode_ringUpdatesRejected(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	target := nodes[0]
+	target.succMtx.RLock()
+	succ := target.successor
+	target.succMtx.RUnlock()
+	target.predMtx.RLock()
+	pred := target.predecessor
+	target.predMtx.RUnlock()
+
+	// A node outside the ring, reachable and honest about its identity.
+	rogue, err := NewNode(testConfig(t, "99"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rogue.Stop()
+	// Claim the id right before target so Notify considers it a candidate.
+	below := new(big.Int).Sub(new(big.Int).SetBytes(target.Id), big.NewInt(1))
+	rogue.Node.Id = below.Bytes()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"SetSuccessor from stranger", func() error {
+			return rogue.setSuccessorRPC(target.Node, rogue.Node)
+		}},
+		{"SetPredecessor from stranger", func() error {
+			return rogue.setPredecessorRPC(target.Node, rogue.Node)
+		}},
+		{"SetSuccessor from neighbour not leaving", func() error {
+			return rogue.transport.SetSuccessor(target.Node, &api.NeighbourRequest{Node: rogue.Node, Caller: succ})
+		}},
+		{"SetPredecessor from neighbour not leaving", func() error {
+			return rogue.transport.SetPredecessor(target.Node, &api.NeighbourRequest{Node: rogue.Node, Caller: pred})
+		}},
+		{"Notify with fake address", func() error {
+			return rogue.transport.Notify(target.Node, &api.Node{Id: rogue.Id, Addr: freeAddr(t)})
+		}},
+		{"Notify with another node's address", func() error {
+			return rogue.transport.Notify(target.Node, &api.Node{Id: rogue.Id, Addr: succ.Addr})
+		}},
+		{"Notify from node not pointing at us", func() error {
+			return rogue.notify(target.Node, rogue.Node)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err == nil {
+				t.Errorf("%s accepted", tt.name)
+			}
+		})
+	}
+
+	target.succMtx.RLock()
+	gotSucc := target.successor
+	target.succMtx.RUnlock()
+	target.predMtx.RLock()
+	gotPred := target.predecessor
+	target.predMtx.RUnlock()
+	if !bytesEqual(gotSucc.Id, succ.Id) || !bytesEqual(gotPred.Id, pred.Id) {
+		t.Errorf("neighbours changed to %v, %v; want %v, %v", gotPred, gotSucc, pred, succ)
+	}
+	if got := target.Metrics()["rejected_ring_updates"]; got == 0 {
+		t.Errorf("rejected_ring_updates = 0")
+	}
+}
+
+func TestNode_StopRewiresNeighbours(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	leaving := nodes[1]
+	leaving.succMtx.RLock()
+	succ := leaving.successor
+	leaving.succMtx.RUnlock()
+	leaving.predMtx.RLock()
+	pred := leaving.predecessor
+	leaving.predMtx.RUnlock()
+
+	var succNode, predNode *Node
+	for _, node := range nodes {
+		if bytesEqual(node.Id, succ.Id) {
+			succNode = node
+		}
+		if bytesEqual(node.Id, pred.Id) {
+			predNode = node
+		}
+	}
+
+	leaving.Stop()
+
+	succNode.predMtx.RLock()
+	gotPred := succNode.predecessor
+	succNode.predMtx.RUnlock()
+	predNode.succMtx.RLock()
+	gotSucc := predNode.successor
+	predNode.succMtx.RUnlock()
+	if !bytesEqual(gotPred.Id, pred.Id) {
+		t.Errorf("successor's predecessor = %v, want %v", gotPred, pred)
+	}
+	if !bytesEqual(gotSucc.Id, succ.Id) {
+		t.Errorf("predecessor's successor = %v, want %v", gotSucc, succ)
+	}
+}
+
+func TestNode_leaveFromSpoofedAddress(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	target := nodes[0]
+	target.predMtx.RLock()
+	pred := target.predecessor
+	target.predMtx.RUnlock()
+
+	// An impostor reusing the predecessor's id at its own address, which
+	// claims to be leaving in favour of an honest node it controls.
+	replacement, err := NewNode(testConfig(t, "98"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replacement.Stop()
+	impostor, err := NewNode(testConfig(t, "99"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer impostor.Stop()
+	impostor.Node.Id = pred.Id
+	atomic.StoreInt32(&impostor.leaving, 1)
+	impostor.predMtx.Lock()
+	impostor.predecessor = replacement.Node
+	impostor.predMtx.Unlock()
+
+	req := &api.NeighbourRequest{Node: replacement.Node, Caller: impostor.Node}
+	if err := impostor.transport.SetPredecessor(target.Node, req); err == nil {
+		t.Error("SetPredecessor accepted a caller at another address than the predecessor")
+	}
+	target.predMtx.RLock()
+	got := target.predecessor
+	target.predMtx.RUnlock()
+	if got.Addr != pred.Addr {
+		t.Errorf("predecessor changed to %v, want %v", got, pred)
+	}
+}
@@ -0,0 +1,374 @@
+package boopy
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+	"golang.org/x/net/context"
+)
+
+const (
+	// watchBuffer is how many events a watch may fall behind before the
+	// node drops it. The client then re-subscribes.
+	watchBuffer = 64
+	// watchRetryInterval spaces out re-subscriptions after failures.
+	watchRetryInterval = 200 * time.Millisecond
+)
+
+////////////////////////////////////////////////////////////////
+// Server side
+////////////////////////////////////////////////////////////////
+
+// watch is one Watch stream served by this node.
+type watch struct {
+	req    *api.WatchRequest
+	id     []byte // ring position of the key, for key watches
+	events chan *api.WatchEvent
+}
+
+func (w *watch) matches(ns, key string) bool {
+	if w.req.Namespace != ns {
+		return false
+	}
+	if w.req.Prefix {
+		return strings.HasPrefix(key, w.req.Key)
+	}
+	return w.req.Key == key
+}
+
+// watchHub tracks the watches served by a node and fans events out to them.
+type watchHub struct {
+	mtx     sync.Mutex
+	watches map[*watch]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watches: make(map[*watch]struct{})}
+}
+
+func (h *watchHub) add(w *watch) {
+	h.mtx.Lock()
+	h.watches[w] = struct{}{}
+	h.mtx.Unlock()
+}
+
+// remove forgets w, closing its channel. Safe to call more than once.
+func (h *watchHub) remove(w *watch) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if _, ok := h.watches[w]; ok {
+		delete(h.watches, w)
+		close(w.events)
+	}
+}
+
+// publish sends a set or delete event to every matching watch. Watches
+// that have fallen too far behind are dropped and returned.
+func (h *watchHub) publish(ev *api.WatchEvent) int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	dropped := 0
+	for w := range h.watches {
+		if !w.matches(ev.Namespace, ev.Key) {
+			continue
+		}
+		select {
+		case w.events <- ev:
+		default:
+			delete(h.watches, w)
+			close(w.events)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// moved tells watches that keys now live on owner. Key watches for which
+// stillOurs returns false are ended after the event, prefix watches are
+// kept since they still cover the keys we have left.
+func (h *watchHub) moved(owner *api.Node, stillOurs func(w *watch) bool) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for w := range h.watches {
+		if !w.req.Prefix && stillOurs(w) {
+			continue
+		}
+		select {
+		case w.events <- &api.WatchEvent{Type: api.WatchEvent_MOVED, Owner: owner}:
+		default:
+		}
+		if !w.req.Prefix {
+			delete(h.watches, w)
+			close(w.events)
+		}
+	}
+}
+
+// closeAll ends every watch, used when the node leaves.
+func (h *watchHub) closeAll(owner *api.Node) {
+	h.moved(owner, func(*watch) bool { return false })
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	for w := range h.watches {
+		delete(h.watches, w)
+		close(w.events)
+	}
+}
+
+// publishSet and publishDelete report changes made by the owner of a key.
+func (n *Node) publishSet(ns, key, value string) {
+	n.publish(&api.WatchEvent{Type: api.WatchEvent_SET, Namespace: ns, Key: key, Value: value})
+}
+
+func (n *Node) publishDelete(ns, key string) {
+	n.publish(&api.WatchEvent{Type: api.WatchEvent_DELETE, Namespace: ns, Key: key})
+}
+
+func (n *Node) publish(ev *api.WatchEvent) {
+	if dropped := n.watches.publish(ev); dropped > 0 {
+		n.metrics.add("watch_overflows", uint64(dropped))
+	}
+}
+
+// rangeMoved is called once keys between pred and owner have been handed to
+// owner, so watchers follow them there.
+func (n *Node) rangeMoved(owner *api.Node) {
+	n.watches.moved(owner, func(w *watch) bool {
+		return n.owns(w.id)
+	})
+}
+
+func (n *Node) XWatch(req *api.WatchRequest, stream api.Chord_XWatchServer) error {
+	w := &watch{req: req, events: make(chan *api.WatchEvent, watchBuffer)}
+	if !req.Prefix {
+		id, err := n.hashKey(namespacedKey(req.Namespace, req.Key))
+		if err != nil {
+			return err
+		}
+		if !n.owns(id) {
+			owner, err := n.findSuccessor(id)
+			if err != nil {
+				return err
+			}
+			if !bytesEqual(owner.Id, n.Id) {
+				return redirectError(owner)
+			}
+		}
+		w.id = id
+	}
+
+	n.watches.add(w)
+	defer n.watches.remove(w)
+	for {
+		select {
+		case ev, ok := <-w.events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-n.shutdownCh:
+			return nil
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////
+// Client side
+////////////////////////////////////////////////////////////////
+
+// Watcher delivers the set and delete events for a key or prefix, following
+// the keys as they move between nodes. Events are delivered at most once;
+// changes made while re-subscribing to a new owner can be missed.
+type Watcher struct {
+	n   *Node
+	req *api.WatchRequest
+
+	events chan *api.WatchEvent
+	resync chan *api.Node // owner hint, or nil to look it up
+	done   chan struct{}
+	once   sync.Once
+
+	mtx  sync.Mutex
+	subs map[string]*subscription // by node address
+}
+
+type subscription struct {
+	node   *api.Node
+	cancel context.CancelFunc
+}
+
+// Watch starts watching key in a namespace, or every key starting with key
+// when prefix is set. Key watches follow the owner of the key, prefix
+// watches subscribe to every node in the ring.
+func (n *Node) Watch(ns, key string, prefix bool) *Watcher {
+	w := &Watcher{
+		n:      n,
+		req:    &api.WatchRequest{Namespace: ns, Key: key, Prefix: prefix},
+		events: make(chan *api.WatchEvent, watchBuffer),
+		resync: make(chan *api.Node, 1),
+		done:   make(chan struct{}),
+		subs:   make(map[string]*subscription),
+	}
+	go w.run()
+	return w
+}
+
+// Events returns the channel events are delivered on. It is not closed;
+// stop reading once the watcher is closed.
+func (w *Watcher) Events() <-chan *api.WatchEvent {
+	return w.events
+}
+
+// Close stops the watch.
+func (w *Watcher) Close() {
+	w.once.Do(func() { close(w.done) })
+}
+
+// resubscribe asks run to recompute the nodes to watch, starting with owner
+// if it is known.
+func (w *Watcher) resubscribe(owner *api.Node) {
+	select {
+	case w.resync <- owner:
+	default:
+	}
+}
+
+func (w *Watcher) run() {
+	var owner *api.Node
+	for {
+		if err := w.subscribe(owner); err != nil {
+			log.Println("error subscribing watch: ", w.req.Key, err)
+			time.AfterFunc(watchRetryInterval, func() { w.resubscribe(nil) })
+		}
+
+		select {
+		case owner = <-w.resync:
+		case <-w.done:
+			w.mtx.Lock()
+			for addr, sub := range w.subs {
+				sub.cancel()
+				delete(w.subs, addr)
+			}
+			w.mtx.Unlock()
+			return
+		}
+		// Do not hammer the ring while it settles.
+		select {
+		case <-time.After(watchRetryInterval):
+		case <-w.done:
+		}
+	}
+}
+
+// targets returns the nodes the watch should be subscribed to.
+func (w *Watcher) targets(owner *api.Node) ([]*api.Node, error) {
+	if !w.req.Prefix {
+		if owner != nil {
+			return []*api.Node{owner}, nil
+		}
+		owner, err := w.n.locate(namespacedKey(w.req.Namespace, w.req.Key))
+		if err != nil {
+			return nil, err
+		}
+		return []*api.Node{owner}, nil
+	}
+
+	nodes := make([]*api.Node, 0, 8)
+	err := w.n.walkRing(func(node *api.Node) error {
+		nodes = append(nodes, node)
+		return nil
+	})
+	if owner != nil {
+		nodes = append(nodes, owner)
+	}
+	return nodes, err
+}
+
+// subscribe brings the subscriptions in line with targets, keeping streams
+// to nodes that are still wanted.
+func (w *Watcher) subscribe(owner *api.Node) error {
+	targets, err := w.targets(owner)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]*api.Node, len(targets))
+	for _, node := range targets {
+		wanted[node.Addr] = node
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	for addr, sub := range w.subs {
+		if _, ok := wanted[addr]; !ok && !w.req.Prefix {
+			sub.cancel()
+			delete(w.subs, addr)
+		}
+	}
+	for addr, node := range wanted {
+		if _, ok := w.subs[addr]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		sub := &subscription{node: node, cancel: cancel}
+		w.subs[addr] = sub
+		go w.follow(ctx, sub)
+	}
+	return nil
+}
+
+// follow relays the events of one node until the stream ends, then asks
+// for the subscriptions to be recomputed.
+func (w *Watcher) follow(ctx context.Context, sub *subscription) {
+	var owner *api.Node
+	defer func() {
+		sub.cancel()
+		w.mtx.Lock()
+		if w.subs[sub.node.Addr] == sub {
+			delete(w.subs, sub.node.Addr)
+		}
+		w.mtx.Unlock()
+		if ctx.Err() == nil || owner != nil {
+			w.resubscribe(owner)
+		}
+	}()
+
+	stream, err := w.n.transport.Watch(ctx, sub.node, w.req)
+	if err != nil {
+		w.n.forgetLocation(sub.node)
+		owner = redirectOwner(err)
+		return
+	}
+	for {
+		ev, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() == nil {
+				w.n.forgetLocation(sub.node)
+				owner = redirectOwner(err)
+			}
+			return
+		}
+		if ev.Type == api.WatchEvent_MOVED {
+			w.n.forgetLocation(sub.node)
+			if !w.req.Prefix {
+				owner = ev.Owner
+				return
+			}
+			w.resubscribe(ev.Owner)
+			continue
+		}
+		select {
+		case w.events <- ev:
+		case <-w.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
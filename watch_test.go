@@ -0,0 +1,160 @@
+package boopy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jseam2/boopy/api"
+)
+
+// waitWatches waits until node serves count watches.
+func waitWatches(t *testing.T, node *Node, count int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		node.watches.mtx.Lock()
+		got := len(node.watches.watches)
+		node.watches.mtx.Unlock()
+		if got == count {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("node %s never served %d watches", node.Addr, count)
+}
+
+func nextEvent(t *testing.T, w *Watcher) *api.WatchEvent {
+	t.Helper()
+	select {
+	case ev := <-w.Events():
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no watch event")
+		return nil
+	}
+}
+
+func TestNode_Watch(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+
+	t.Run("key", func(t *testing.T) {
+		owner := ringOwner(nodes, "apple")
+		w := nodes[0].Watch("", "apple", false)
+		defer w.Close()
+		for _, node := range nodes {
+			if bytesEqual(node.Id, owner.Id) {
+				waitWatches(t, node, 1)
+			}
+		}
+
+		if err := nodes[1].Set("apple", "red"); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodes[2].Set("banana", "yellow"); err != nil {
+			t.Fatal(err)
+		}
+		if err := nodes[2].Delete("apple"); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			typ   api.WatchEvent_Type
+			value string
+		}{
+			{api.WatchEvent_SET, "red"},
+			{api.WatchEvent_DELETE, ""},
+		}
+		for _, tt := range tests {
+			ev := nextEvent(t, w)
+			if ev.Type != tt.typ || ev.Key != "apple" || ev.Value != tt.value {
+				t.Errorf("event = %v, want %v apple %q", ev, tt.typ, tt.value)
+			}
+		}
+
+		w.Close()
+		for _, node := range nodes {
+			waitWatches(t, node, 0)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		w := nodes[1].Watch("", "p/", true)
+		defer w.Close()
+		for _, node := range nodes {
+			waitWatches(t, node, 1)
+		}
+
+		want := make(map[string]bool)
+		for i := 0; i < 12; i++ {
+			key := fmt.Sprintf("p/%d", i)
+			want[key] = true
+			if err := nodes[i%3].Set(key, "v"); err != nil {
+				t.Fatal(err)
+			}
+			if err := nodes[i%3].Set(fmt.Sprintf("q/%d", i), "v"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for len(want) > 0 {
+			ev := nextEvent(t, w)
+			if !want[ev.Key] {
+				t.Fatalf("unexpected event for %q", ev.Key)
+			}
+			delete(want, ev.Key)
+		}
+
+		w.Close()
+		for _, node := range nodes {
+			waitWatches(t, node, 0)
+		}
+	})
+
+	t.Run("moved", func(t *testing.T) {
+		// Find a key the new node will take over from the current ring.
+		newID := GetHashID("4")
+		pred := nodes[0].Node
+		for _, node := range nodes {
+			if between(node.Id, pred.Id, newID) {
+				pred = node.Node
+			}
+		}
+		key := ""
+		for i := 0; key == ""; i++ {
+			if candidate := fmt.Sprintf("key%d", i); between(GetHashID(candidate), pred.Id, newID) {
+				key = candidate
+			}
+		}
+
+		w := nodes[0].Watch("", key, false)
+		defer w.Close()
+		for _, node := range nodes {
+			if bytesEqual(node.Id, ringOwner(nodes, key).Id) {
+				waitWatches(t, node, 1)
+			}
+		}
+
+		node, err := NewNode(testConfig(t, "4"), nodes[0].Node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			node.transport.Stop()
+			close(node.shutdownCh)
+		})
+		ring := append(nodes, node)
+		for round := 0; round < 8; round++ {
+			for _, node := range ring {
+				node.stabilize()
+			}
+		}
+		waitWatches(t, node, 1)
+
+		if err := nodes[2].Set(key, "moved"); err != nil {
+			t.Fatal(err)
+		}
+		if ev := nextEvent(t, w); ev.Type != api.WatchEvent_SET || ev.Value != "moved" {
+			t.Errorf("event = %v, want set of %q", ev, "moved")
+		}
+	})
+}